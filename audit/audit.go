@@ -0,0 +1,124 @@
+// Package audit writes structured audit events of parsing and signing tokens
+// using [log/slog].
+//
+// A [Logger] is registered as a hook with the parser and the tokens:
+//
+//	a := &audit.Logger{Logger: slog.Default()}
+//	parser := xwt.NewParser(xwt.WithParserHooks(a))
+//	token := xwt.NewWithClaims(method, claims, xwt.WithSignerHooks(a))
+//
+// Events contain the algorithm, the key ID, the issuer and the subject of the
+// token, but never the token itself. For tokens with an invalid signature,
+// these values are not trustworthy, which is indicated by the attribute
+// "verified" being false.
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+)
+
+// The messages of the audit events.
+const (
+	MessageAccepted        = "token accepted"
+	MessageRejected        = "token rejected"
+	MessageKeyLookupFailed = "token key lookup failed"
+	MessageSigned          = "token signed"
+	MessageSigningFailed   = "token signing failed"
+)
+
+// Logger writes audit events of parsing and signing tokens. It implements
+// [xwt.ParserHook] and [xwt.SignerHook].
+//
+// Accepted and signed tokens are logged at [slog.LevelInfo], rejected tokens
+// and failed key lookups at [slog.LevelWarn], and signing failures at
+// [slog.LevelError].
+type Logger struct {
+	// Logger is the logger the events are written to. Defaults to
+	// slog.Default().
+	Logger *slog.Logger
+}
+
+// OnParseStart implements the [xwt.ParserHook] interface.
+func (l *Logger) OnParseStart(raw string) {}
+
+// OnKeyLookup implements the [xwt.ParserHook] interface. Only failed lookups
+// are logged.
+func (l *Logger) OnKeyLookup(token *xwt.Token, duration time.Duration, err error) {
+	if err == nil {
+		return
+	}
+
+	l.log(slog.LevelWarn, MessageKeyLookupFailed, token, duration, err, slog.Bool("verified", false))
+}
+
+// OnVerifyResult implements the [xwt.ParserHook] interface. Only tokens, which
+// could not be parsed or verified, are logged.
+func (l *Logger) OnVerifyResult(token *xwt.Token, duration time.Duration, err error) {
+	if err == nil {
+		return
+	}
+
+	l.log(slog.LevelWarn, MessageRejected, token, duration, err, slog.Bool("verified", false))
+}
+
+// OnValidateResult implements the [xwt.ParserHook] interface.
+func (l *Logger) OnValidateResult(token *xwt.Token, duration time.Duration, err error) {
+	if err != nil {
+		l.log(slog.LevelWarn, MessageRejected, token, duration, err, slog.Bool("verified", true))
+		return
+	}
+
+	l.log(slog.LevelInfo, MessageAccepted, token, duration, nil, slog.Bool("verified", true))
+}
+
+// OnSign implements the [xwt.SignerHook] interface.
+func (l *Logger) OnSign(token *xwt.Token, duration time.Duration, err error) {
+	if err != nil {
+		l.log(slog.LevelError, MessageSigningFailed, token, duration, err)
+		return
+	}
+
+	l.log(slog.LevelInfo, MessageSigned, token, duration, nil)
+}
+
+// log writes an event about token with additional attributes.
+func (l *Logger) log(level slog.Level, msg string, token *xwt.Token, duration time.Duration, err error, extra ...slog.Attr) {
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	ctx := context.Background()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 9)
+	if token != nil {
+		attrs = append(attrs,
+			slog.String("alg", token.Header.Algorithm),
+			slog.String("kid", token.Header.KeyID),
+			slog.String("typ", token.Header.Type),
+		)
+		if token.Claims != nil {
+			attrs = append(attrs,
+				slog.String("iss", token.Claims.GetIssuer()),
+				slog.String("sub", token.Claims.GetSubject()),
+			)
+		}
+	}
+	attrs = append(attrs, extra...)
+	attrs = append(attrs, slog.Duration("duration", duration))
+	if err != nil {
+		attrs = append(attrs,
+			slog.String("reason", xwt.ErrorReason(err)),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	logger.LogAttrs(ctx, level, msg, attrs...)
+}
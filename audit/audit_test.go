@@ -0,0 +1,160 @@
+package audit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/audit"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// events decodes the events written by a JSON handler.
+func events(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var events []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		event := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func TestLogger(t *testing.T) {
+	sign := func(claims jwt.MapClaims, hooks ...xwt.SignerHook) string {
+		token, err := xwt.NewWithClaims(method.SigningMethodHS256, &claims, xwt.WithKeyID("k1"), xwt.WithSignerHooks(hooks...)).SignedString(testSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := sign(jwt.MapClaims{"iss": "https://auth.example.com", "sub": "alice", "exp": time.Now().Add(time.Hour).Unix()})
+	expired := sign(jwt.MapClaims{"iss": "https://auth.example.com", "sub": "alice", "exp": time.Now().Add(-time.Hour).Unix()})
+
+	secretKeyfunc := func(*xwt.Token) (interface{}, error) { return testSecret, nil }
+	otherKeyfunc := func(*xwt.Token) (interface{}, error) { return []byte("fedcba9876543210fedcba9876543210"), nil }
+	failingKeyfunc := func(*xwt.Token) (interface{}, error) { return nil, errors.New("unknown key") }
+
+	tests := []struct {
+		name    string
+		token   string
+		keyfunc xwt.Keyfunc
+		want    []map[string]interface{}
+	}{
+		{"accepted", valid, secretKeyfunc, []map[string]interface{}{
+			{"level": "INFO", "msg": audit.MessageAccepted, "verified": true, "alg": "HS256", "kid": "k1", "typ": "JWT", "iss": "https://auth.example.com", "sub": "alice"},
+		}},
+		{"expired", expired, secretKeyfunc, []map[string]interface{}{
+			{"level": "WARN", "msg": audit.MessageRejected, "verified": true, "reason": "expired", "sub": "alice"},
+		}},
+		{"signature invalid", valid, otherKeyfunc, []map[string]interface{}{
+			{"level": "WARN", "msg": audit.MessageRejected, "verified": false, "reason": "signature_invalid", "sub": "alice"},
+		}},
+		{"key lookup failed", valid, failingKeyfunc, []map[string]interface{}{
+			{"level": "WARN", "msg": audit.MessageKeyLookupFailed, "verified": false, "kid": "k1"},
+			{"level": "WARN", "msg": audit.MessageRejected, "verified": false},
+		}},
+		{"malformed", "not a token", secretKeyfunc, []map[string]interface{}{
+			{"level": "WARN", "msg": audit.MessageRejected, "verified": false, "reason": "malformed"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := &audit.Logger{Logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+
+			xwt.NewParser(xwt.WithParserHooks(l)).ParseWithClaims(tt.token, &jwt.MapClaims{}, tt.keyfunc)
+
+			got := events(t, &buf)
+			if len(got) != len(tt.want) {
+				t.Fatalf("logged %d events, want %d:\n%s", len(got), len(tt.want), buf.String())
+			}
+			for i, want := range tt.want {
+				for name, value := range want {
+					if got[i][name] != value {
+						t.Errorf("event %d: %s = %v, want %v", i, name, got[i][name], value)
+					}
+				}
+				if _, ok := got[i]["duration"]; !ok {
+					t.Errorf("event %d: duration is missing", i)
+				}
+			}
+
+			// The token, or any part of it, is never logged
+			for _, part := range strings.Split(tt.token, ".") {
+				if strings.Contains(buf.String(), part) {
+					t.Fatalf("event contains token part %s:\n%s", part, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestLoggerSigner(t *testing.T) {
+	var buf bytes.Buffer
+	l := &audit.Logger{Logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+
+	token, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"}, xwt.WithSignerHooks(l)).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Signing with a key of the wrong type fails
+	if _, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "bob"}, xwt.WithSignerHooks(l)).SignedString("secret"); err == nil {
+		t.Fatal("SignedString() with string key succeeded")
+	}
+
+	got := events(t, &buf)
+	if len(got) != 2 {
+		t.Fatalf("logged %d events, want 2:\n%s", len(got), buf.String())
+	}
+	if got[0]["level"] != "INFO" || got[0]["msg"] != audit.MessageSigned || got[0]["sub"] != "alice" {
+		t.Errorf("event 0 = %v, want signed token of alice", got[0])
+	}
+	if got[1]["level"] != "ERROR" || got[1]["msg"] != audit.MessageSigningFailed || got[1]["reason"] == nil || got[1]["error"] == nil {
+		t.Errorf("event 1 = %v, want signing failure", got[1])
+	}
+	if _, ok := got[0]["verified"]; ok {
+		t.Errorf("event 0 of signed token contains verified")
+	}
+
+	for _, part := range strings.Split(token, ".") {
+		if strings.Contains(buf.String(), part) {
+			t.Fatalf("event contains token part %s:\n%s", part, buf.String())
+		}
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := &audit.Logger{Logger: slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))}
+
+	token, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"}, xwt.WithSignerHooks(l)).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xwt.NewParser(xwt.WithParserHooks(l)).ParseWithClaims(token, &jwt.MapClaims{}, func(*xwt.Token) (interface{}, error) {
+		return testSecret, nil
+	})
+
+	// Accepted and signed tokens are only logged at info level
+	if buf.Len() != 0 {
+		t.Fatalf("logged events below the level:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,57 @@
+package xwt
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+
+	"github.com/lkyzhu/xwt/internal"
+)
+
+// CertificateBoundClaims is implemented by claims, which can be bound to a
+// mutual TLS client certificate using the `x5t#S256` member of the `cnf` claim,
+// as described in https://datatracker.ietf.org/doc/html/rfc8705#section-3.
+// [jwt.RegisteredClaims], [jwt.MapClaims] and [pwt.RegisteredClaims] implement
+// it.
+type CertificateBoundClaims interface {
+	Claims
+	GetConfirmationX509Thumbprint() string
+}
+
+// CertificateThumbprint returns the base64url encoded SHA-256 thumbprint of the
+// DER encoding of cert. It is used for the `x5t#S256` header parameter, as well
+// as the `x5t#S256` member of the `cnf` claim of certificate-bound tokens.
+func CertificateThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// isCertificateBound reports whether claims contain the `x5t#S256` member of the
+// `cnf` claim.
+func isCertificateBound(claims Claims) bool {
+	bound, ok := claims.(CertificateBoundClaims)
+	return ok && bound.GetConfirmationX509Thumbprint() != ""
+}
+
+// verifyCertificateBinding compares the `x5t#S256` member of the `cnf` claim
+// against the client certificate of the TLS connection the token was presented
+// on.
+func (v *Validator) verifyCertificateBinding(claims Claims, state *tls.ConnectionState) error {
+	bound, ok := claims.(CertificateBoundClaims)
+	if !ok || bound.GetConfirmationX509Thumbprint() == "" {
+		return errorIfRequired(true, "cnf")
+	}
+
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return internal.NewError("connection does not have a client certificate", internal.ErrTokenInvalidConfirmation)
+	}
+
+	thumbprint := CertificateThumbprint(state.PeerCertificates[0])
+	if subtle.ConstantTimeCompare([]byte(thumbprint), []byte(bound.GetConfirmationX509Thumbprint())) != 1 {
+		return internal.NewError("token is not bound to the client certificate", internal.ErrTokenInvalidConfirmation)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package xwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+func newClientCertificate(t *testing.T, name string) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestCertificateBinding(t *testing.T) {
+	alice := newClientCertificate(t, "alice")
+	bob := newClientCertificate(t, "bob")
+
+	if got, want := jwt.NewCertificateConfirmation(alice).X509CertThumbprintS256, xwt.CertificateThumbprint(alice); got != want {
+		t.Fatalf("NewCertificateConfirmation() = %s, want %s", got, want)
+	}
+
+	sign := func(claims xwt.Claims) string {
+		token, err := xwt.NewWithClaims(method.SigningMethodHS256, claims).SignedString(testSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	bound := sign(&jwt.RegisteredClaims{Subject: "alice", Confirmation: jwt.NewCertificateConfirmation(alice)})
+	boundMap := sign(&jwt.MapClaims{"sub": "alice", "cnf": map[string]interface{}{"x5t#S256": xwt.CertificateThumbprint(alice)}})
+	unbound := sign(&jwt.RegisteredClaims{Subject: "alice"})
+
+	connection := func(cert *x509.Certificate) *tls.ConnectionState {
+		return &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	}
+
+	// The parsers are shared by all connections.
+	required := xwt.NewParser(xwt.WithCertificateBinding())
+	optional := xwt.NewParser()
+
+	tests := []struct {
+		name    string
+		parser  *xwt.Parser
+		token   string
+		claims  xwt.Claims
+		state   *tls.ConnectionState
+		wantErr error
+	}{
+		{"bound", required, bound, &jwt.RegisteredClaims{}, connection(alice), nil},
+		{"bound map claims", required, boundMap, &jwt.MapClaims{}, connection(alice), nil},
+		{"other certificate", required, bound, &jwt.RegisteredClaims{}, connection(bob), internal.ErrTokenInvalidConfirmation},
+		{"no client certificate", required, bound, &jwt.RegisteredClaims{}, &tls.ConnectionState{}, internal.ErrTokenInvalidConfirmation},
+		{"no connection", required, bound, &jwt.RegisteredClaims{}, nil, internal.ErrTokenInvalidConfirmation},
+		{"unbound", required, unbound, &jwt.RegisteredClaims{}, connection(alice), internal.ErrTokenRequiredClaimMissing},
+		{"optional bound", optional, bound, &jwt.RegisteredClaims{}, connection(alice), nil},
+		{"optional other certificate", optional, bound, &jwt.RegisteredClaims{}, connection(bob), internal.ErrTokenInvalidConfirmation},
+		// A bound token is never accepted without verifying the binding
+		{"optional no client certificate", optional, bound, &jwt.RegisteredClaims{}, &tls.ConnectionState{}, internal.ErrTokenInvalidConfirmation},
+		{"optional no connection", optional, bound, &jwt.RegisteredClaims{}, nil, internal.ErrTokenInvalidConfirmation},
+		{"optional map claims no connection", optional, boundMap, &jwt.MapClaims{}, nil, internal.ErrTokenInvalidConfirmation},
+		{"optional unbound no connection", optional, unbound, &jwt.RegisteredClaims{}, nil, nil},
+		{"optional unbound", optional, unbound, &jwt.RegisteredClaims{}, connection(alice), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.parser.ParseWithConnection(tt.token, tt.state, tt.claims, func(*xwt.Token) (interface{}, error) {
+				return testSecret, nil
+			})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ParseWithConnection() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseWithConnection() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Without a connection, a bound token cannot be verified.
+	for _, p := range []*xwt.Parser{required, optional} {
+		if _, err := p.ParseWithClaims(bound, &jwt.RegisteredClaims{}, func(*xwt.Token) (interface{}, error) {
+			return testSecret, nil
+		}); !errors.Is(err, internal.ErrTokenInvalidConfirmation) {
+			t.Fatalf("ParseWithClaims() error = %v, want %v", err, internal.ErrTokenInvalidConfirmation)
+		}
+	}
+
+	v := xwt.NewValidator(xwt.WithCertificateBinding())
+	claims := &jwt.RegisteredClaims{Confirmation: jwt.NewCertificateConfirmation(alice)}
+	if err := v.ValidateWithConnection(claims, connection(alice)); err != nil {
+		t.Fatalf("ValidateWithConnection() error = %v", err)
+	}
+	if err := v.ValidateWithConnection(claims, connection(bob)); !errors.Is(err, internal.ErrTokenInvalidConfirmation) {
+		t.Fatalf("ValidateWithConnection() with other certificate error = %v, want %v", err, internal.ErrTokenInvalidConfirmation)
+	}
+	if err := xwt.NewValidator().Validate(claims); !errors.Is(err, internal.ErrTokenInvalidConfirmation) {
+		t.Fatalf("Validate() of bound claims error = %v, want %v", err, internal.ErrTokenInvalidConfirmation)
+	}
+}
@@ -1,10 +1,15 @@
 package xwt
 
+import "time"
+
 // Claims represent any form of a *WT(JWT/PWT) Claims
+//
+// The time getters return the zero time, if the claim is not set. Times may
+// carry sub-second precision, which is retained by the [Validator].
 type Claims interface {
-	GetExpirationTime() int64
-	GetIssuedAt() int64
-	GetNotBefore() int64
+	GetExpirationTime() time.Time
+	GetIssuedAt() time.Time
+	GetNotBefore() time.Time
 	GetIssuer() string
 	GetSubject() string
 	GetAudience() []string
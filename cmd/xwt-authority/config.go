@@ -0,0 +1,281 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lkyzhu/xwt/method"
+)
+
+// Config is the configuration file of the authority, e.g.
+//
+//	{
+//	    "issuer": "https://authority.local",
+//	    "listen": ":8080",
+//	    "format": "jwt",
+//	    "ttl": "15m",
+//	    "keys": [{"file": "signing.pem", "alg": "ES256"}],
+//	    "clients": [{
+//	        "id": "billing",
+//	        "secret_sha256": "5e884898da28047151d0e56f8dc62927...",
+//	        "scopes": ["orders:read"],
+//	        "audiences": ["https://orders.local"],
+//	        "ttl": "5m"
+//	    }]
+//	}
+type Config struct {
+	// Issuer is the `iss` claim of all tokens.
+	Issuer string `json:"issuer"`
+
+	// Listen is the address the server listens on. Defaults to ":8080".
+	Listen string `json:"listen,omitempty"`
+
+	// Format is the default token format, either "jwt" or "pwt". Defaults to
+	// "jwt".
+	Format string `json:"format,omitempty"`
+
+	// TTL is the default lifetime of tokens. Defaults to 15 minutes.
+	TTL Duration `json:"ttl,omitempty"`
+
+	// Keys are the signing keys. The first key signs all tokens, the others
+	// are only published in the JWKS, e.g. during a key rotation.
+	Keys []KeyConfig `json:"keys"`
+
+	// Clients are the clients allowed to request tokens.
+	Clients []ClientConfig `json:"clients"`
+}
+
+// KeyConfig configures a signing key.
+type KeyConfig struct {
+	// File is the path of the PEM encoded private key, relative to the
+	// configuration file.
+	File string `json:"file"`
+
+	// Alg is the signing algorithm, e.g. "ES256". HMAC algorithms are not
+	// supported, as their keys cannot be published.
+	Alg string `json:"alg"`
+
+	// Kid is the key ID. Defaults to the JWK thumbprint of the key.
+	Kid string `json:"kid,omitempty"`
+}
+
+// ClientConfig configures a client.
+type ClientConfig struct {
+	// ID is the client ID, which is used as `sub` and `client_id` claim.
+	ID string `json:"id"`
+
+	// Secret is the client secret in plain text. Prefer SecretSHA256 outside
+	// of tests.
+	Secret string `json:"secret,omitempty"`
+
+	// SecretSHA256 is the hex encoded SHA-256 hash of the client secret.
+	SecretSHA256 string `json:"secret_sha256,omitempty"`
+
+	// Scopes are the scopes the client may request. If the client does not
+	// request any scope, all of them are granted.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Audiences are the audiences the client may request. If the client does
+	// not request any audience, all of them are granted.
+	Audiences []string `json:"audiences,omitempty"`
+
+	// TTL overrides the default lifetime of tokens for this client.
+	TTL Duration `json:"ttl,omitempty"`
+
+	// Format overrides the default token format for this client.
+	Format string `json:"format,omitempty"`
+}
+
+// Duration is a time.Duration, which is represented as string such as "15m"
+// in the configuration file.
+type Duration time.Duration
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+
+	return nil
+}
+
+// signingKey is a loaded signing key.
+type signingKey struct {
+	method method.SigningMethod
+	signer crypto.Signer
+	kid    string
+}
+
+// LoadConfig reads and validates the configuration file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	if err = json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("could not decode %s: %w", path, err)
+	}
+
+	// Key files are relative to the configuration file
+	dir := filepath.Dir(path)
+	for i := range config.Keys {
+		if !filepath.IsAbs(config.Keys[i].File) {
+			config.Keys[i].File = filepath.Join(dir, config.Keys[i].File)
+		}
+	}
+
+	if config.Listen == "" {
+		config.Listen = ":8080"
+	}
+	if config.TTL == 0 {
+		config.TTL = Duration(15 * time.Minute)
+	}
+
+	if err = config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// validate checks the configuration for missing or conflicting values.
+func (c *Config) validate() error {
+	if c.Issuer == "" {
+		return errors.New("issuer is missing")
+	}
+	if len(c.Keys) == 0 {
+		return errors.New("at least one key is required")
+	}
+	if !validFormat(c.Format) {
+		return fmt.Errorf("unknown format %s", c.Format)
+	}
+
+	ids := map[string]bool{}
+	for _, client := range c.Clients {
+		if client.ID == "" {
+			return errors.New("client id is missing")
+		}
+		if ids[client.ID] {
+			return fmt.Errorf("client %s is configured twice", client.ID)
+		}
+		ids[client.ID] = true
+
+		if (client.Secret == "") == (client.SecretSHA256 == "") {
+			return fmt.Errorf("client %s must have exactly one of secret and secret_sha256", client.ID)
+		}
+		if !validFormat(client.Format) {
+			return fmt.Errorf("client %s has unknown format %s", client.ID, client.Format)
+		}
+	}
+
+	return nil
+}
+
+// loadKeys loads the signing keys of the configuration.
+func (c *Config) loadKeys() ([]*signingKey, error) {
+	keys := make([]*signingKey, 0, len(c.Keys))
+
+	for _, kc := range c.Keys {
+		key, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("could not load key %s: %w", kc.File, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// loadKey loads a PEM encoded private key, which must match the signing method.
+func loadKey(kc KeyConfig) (*signingKey, error) {
+	m := method.GetSigningMethod(kc.Alg)
+	if m == nil {
+		return nil, fmt.Errorf("unknown algorithm %s", kc.Alg)
+	}
+
+	data, err := os.ReadFile(kc.File)
+	if err != nil {
+		return nil, err
+	}
+
+	var key crypto.PrivateKey
+	switch m := m.(type) {
+	case *method.SigningMethodRSA, *method.SigningMethodRSAPSS:
+		key, err = method.ParseRSAPrivateKeyFromPEM(data)
+	case *method.SigningMethodECDSA:
+		var ecKey *ecdsa.PrivateKey
+		if ecKey, err = method.ParseECPrivateKeyFromPEM(data); err == nil {
+			// The curve is fixed by the algorithm, e.g. P-256 for ES256
+			if curve := ecKey.Curve.Params().Name; m.Curve != nil && curve != m.Curve.Params().Name {
+				return nil, fmt.Errorf("curve %s of key %s cannot be used with %s", curve, kc.File, kc.Alg)
+			}
+		}
+		key = ecKey
+	case *method.SigningMethodEd25519:
+		key, err = method.ParseEdPrivateKeyFromPEM(data)
+	default:
+		return nil, fmt.Errorf("algorithm %s is not supported", kc.Alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("key cannot be used for signing")
+	}
+
+	// Make sure the key actually works with the algorithm
+	sig, err := m.Sign("xwt-authority", signer)
+	if err == nil {
+		err = m.Verify("xwt-authority", sig, signer.Public())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key cannot be used with %s: %w", kc.Alg, err)
+	}
+
+	kid := kc.Kid
+	if kid == "" {
+		if kid, err = method.JWKThumbprint(signer); err != nil {
+			return nil, err
+		}
+	}
+
+	return &signingKey{method: m, signer: signer, kid: kid}, nil
+}
+
+// authenticate checks the secret of the client in constant time.
+func (c *ClientConfig) authenticate(secret string) bool {
+	if c.SecretSHA256 != "" {
+		expected, err := hex.DecodeString(c.SecretSHA256)
+		if err != nil {
+			return false
+		}
+		sum := sha256.Sum256([]byte(secret))
+		return subtle.ConstantTimeCompare(sum[:], expected) == 1
+	}
+
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(c.Secret)) == 1
+}
+
+func validFormat(format string) bool {
+	return format == "" || format == formatJWT || format == formatPWT
+}
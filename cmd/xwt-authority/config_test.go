@@ -0,0 +1,38 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadKeyCurve(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "signing.pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadKey(KeyConfig{File: file, Alg: "ES384"}); err != nil {
+		t.Fatalf("loadKey() error = %v", err)
+	}
+
+	for _, alg := range []string{"ES256", "ES512", "ES256K"} {
+		if _, err := loadKey(KeyConfig{File: file, Alg: alg}); err == nil || !strings.Contains(err.Error(), "curve P-384") {
+			t.Fatalf("loadKey() with %s error = %v, want curve mismatch", alg, err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Command xwt-authority is a minimal token authority for development and test
+// clusters, as well as air-gapped deployments without an external identity
+// provider.
+//
+// It issues JWTs or PWTs to the clients listed in its configuration file using
+// the OAuth 2.0 client credentials grant, and publishes the public keys to
+// verify them:
+//
+//	POST /token                                  client credentials grant
+//	GET  /.well-known/jwks.json                  JSON Web Key Set
+//	GET  /.well-known/oauth-authorization-server server metadata
+//
+// Start it with the path of the configuration file, see [Config]:
+//
+//	xwt-authority --config authority.json
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	cmd := cobra.Command{
+		Use:   "xwt-authority",
+		Short: "Issue tokens using the client credentials grant",
+		RunE:  run,
+	}
+	cmd.Flags().String("config", "authority.json", "path of the configuration file")
+
+	if err := cmd.Execute(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	path, _ := cmd.Flags().GetString("config")
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	server, err := NewServer(config)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              config.Listen,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("issuing tokens for %s on %s", config.Issuer, config.Listen)
+
+	return srv.ListenAndServe()
+}
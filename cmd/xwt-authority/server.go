@@ -0,0 +1,289 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/method"
+	"github.com/lkyzhu/xwt/pwt"
+	"github.com/lkyzhu/xwt/pwt/pb"
+	"github.com/lkyzhu/xwt/session"
+)
+
+const (
+	formatJWT = "jwt"
+	formatPWT = "pwt"
+
+	jwksPath     = "/.well-known/jwks.json"
+	metadataPath = "/.well-known/oauth-authorization-server"
+	tokenPath    = "/token"
+)
+
+// Server issues tokens to the configured clients using the client credentials
+// grant, see https://datatracker.ietf.org/doc/html/rfc6749#section-4.4, and
+// publishes the public keys to verify them.
+type Server struct {
+	config  *Config
+	keys    []*signingKey
+	clients map[string]*ClientConfig
+	jwks    []byte
+
+	// now supplies the current time.
+	now func() time.Time
+}
+
+// NewServer loads the keys of config and creates a server.
+func NewServer(config *Config) (*Server, error) {
+	keys, err := config.loadKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		config:  config,
+		keys:    keys,
+		clients: make(map[string]*ClientConfig, len(config.Clients)),
+		now:     time.Now,
+	}
+
+	for i := range config.Clients {
+		s.clients[config.Clients[i].ID] = &config.Clients[i]
+	}
+
+	if s.jwks, err = s.keySet(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Handler returns the HTTP handler of the server.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(jwksPath, s.handleJWKS)
+	mux.HandleFunc(metadataPath, s.handleMetadata)
+	mux.HandleFunc(tokenPath, s.handleToken)
+
+	return mux
+}
+
+// keySet creates the JWKS containing the public keys of all signing keys.
+func (s *Server) keySet() ([]byte, error) {
+	keys := make([]map[string]interface{}, 0, len(s.keys))
+
+	for _, key := range s.keys {
+		data, err := method.MarshalPublicKeyToJWK(key.signer.Public())
+		if err != nil {
+			return nil, err
+		}
+
+		jwk := map[string]interface{}{}
+		if err = json.Unmarshal(data, &jwk); err != nil {
+			return nil, err
+		}
+		jwk["kid"] = key.kid
+		jwk["alg"] = key.method.Alg()
+		jwk["use"] = "sig"
+
+		keys = append(keys, jwk)
+	}
+
+	return json.Marshal(map[string]interface{}{"keys": keys})
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "max-age=300")
+	w.Write(s.jwks)
+}
+
+// handleMetadata serves the authorization server metadata, see
+// https://datatracker.ietf.org/doc/html/rfc8414.
+func (s *Server) handleMetadata(w http.ResponseWriter, r *http.Request) {
+	issuer := strings.TrimSuffix(s.config.Issuer, "/")
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.config.Issuer,
+		"token_endpoint":                        issuer + tokenPath,
+		"jwks_uri":                              issuer + jwksPath,
+		"grant_types_supported":                 []string{"client_credentials"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+	})
+}
+
+// handleToken implements the token endpoint for the client credentials grant.
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		tokenError(w, http.StatusMethodNotAllowed, "invalid_request", "token endpoint requires POST")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, http.StatusBadRequest, "invalid_request", "could not parse request")
+		return
+	}
+
+	client, ok := s.authenticate(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="xwt-authority"`)
+		tokenError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+
+	if grantType := r.PostForm.Get("grant_type"); grantType != "client_credentials" {
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type", "only client_credentials is supported")
+		return
+	}
+
+	scopes, ok := grant(strings.Fields(r.PostForm.Get("scope")), client.Scopes)
+	if !ok {
+		tokenError(w, http.StatusBadRequest, "invalid_scope", "requested scope is not allowed")
+		return
+	}
+
+	// Audiences may be requested using the audience parameter or resource
+	// indicators, see https://datatracker.ietf.org/doc/html/rfc8707
+	var requested []string
+	requested = append(requested, r.PostForm["audience"]...)
+	requested = append(requested, r.PostForm["resource"]...)
+	audiences, ok := grant(requested, client.Audiences)
+	if !ok {
+		tokenError(w, http.StatusBadRequest, "invalid_target", "requested audience is not allowed")
+		return
+	}
+
+	ttl := time.Duration(s.config.TTL)
+	if client.TTL != 0 {
+		ttl = time.Duration(client.TTL)
+	}
+
+	token, err := s.issue(client, scopes, audiences, ttl)
+	if err != nil {
+		log.Printf("could not issue token for client %s: %v", client.ID, err)
+		tokenError(w, http.StatusInternalServerError, "server_error", "could not issue token")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	response := map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int64(ttl / time.Second),
+	}
+	if len(scopes) > 0 {
+		response["scope"] = strings.Join(scopes, " ")
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// authenticate authenticates the client using HTTP Basic authentication or the
+// client_id and client_secret parameters, see
+// https://datatracker.ietf.org/doc/html/rfc6749#section-2.3.1.
+func (s *Server) authenticate(r *http.Request) (*ClientConfig, bool) {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	client, found := s.clients[id]
+	if !found || id == "" {
+		return nil, false
+	}
+
+	return client, client.authenticate(secret)
+}
+
+// issue signs a token for client using the first signing key.
+func (s *Server) issue(client *ClientConfig, scopes, audiences []string, ttl time.Duration) (string, error) {
+	key := s.keys[0]
+	now := s.now()
+	exp := now.Add(ttl)
+
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	format := s.config.Format
+	if client.Format != "" {
+		format = client.Format
+	}
+
+	var claims xwt.Claims
+	switch format {
+	case formatPWT:
+		claims = &pwt.RegisteredClaims{StandardClaims: pb.StandardClaims{
+			Issuer:    s.config.Issuer,
+			Subject:   client.ID,
+			Audience:  audiences,
+			ExpiresAt: exp.Unix(),
+			IssuedAt:  now.Unix(),
+			ID:        id,
+			Scope:     strings.Join(scopes, " "),
+		}}
+	default:
+		claims = session.DefaultAccessClaims(s.config.Issuer, &session.Grant{
+			Subject:  client.ID,
+			ClientID: client.ID,
+			Audience: audiences,
+			Scope:    scopes,
+		}, id, now, exp)
+	}
+
+	return xwt.NewWithClaims(key.method, claims, xwt.WithKeyID(key.kid)).SignedString(key.signer)
+}
+
+// grant returns the requested values, if all of them are allowed, or all
+// allowed values if none were requested.
+func grant(requested, allowed []string) ([]string, bool) {
+	if len(requested) == 0 {
+		return allowed, true
+	}
+
+	for _, value := range requested {
+		if !slices.Contains(allowed, value) {
+			return nil, false
+		}
+	}
+
+	return requested, true
+}
+
+// tokenError writes an error response, see
+// https://datatracker.ietf.org/doc/html/rfc6749#section-5.2.
+func tokenError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// newID returns a random, base64url encoded token ID.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
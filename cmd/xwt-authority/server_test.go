@@ -0,0 +1,291 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/oidc"
+	"github.com/lkyzhu/xwt/pwt"
+)
+
+const testIssuer = "https://authority.local"
+
+// newTestServer starts a server with an ES256 key and the clients billing,
+// which authenticates with a plain secret, and reports, which authenticates
+// with a hashed secret and receives PWTs with a shorter lifetime.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "signing.pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte("hunter2"))
+	s, err := NewServer(&Config{
+		Issuer: testIssuer,
+		TTL:    Duration(15 * time.Minute),
+		Keys:   []KeyConfig{{File: file, Alg: "ES256"}},
+		Clients: []ClientConfig{
+			{
+				ID:        "billing",
+				Secret:    "s3cret",
+				Scopes:    []string{"orders:read", "orders:write"},
+				Audiences: []string{"https://orders.local", "https://invoices.local"},
+			},
+			{
+				ID:           "reports",
+				SecretSHA256: hex.EncodeToString(sum[:]),
+				Scopes:       []string{"reports:read"},
+				Audiences:    []string{"https://reports.local"},
+				TTL:          Duration(5 * time.Minute),
+				Format:       formatPWT,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// requestToken posts form to the token endpoint. If id is not empty, the client
+// authenticates using HTTP Basic authentication.
+func requestToken(t *testing.T, srv *httptest.Server, id, secret string, form url.Values) (*http.Response, map[string]interface{}) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+tokenPath, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if id != "" {
+		req.SetBasicAuth(id, secret)
+	}
+
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body := map[string]interface{}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	return res, body
+}
+
+func TestTokenEndpoint(t *testing.T) {
+	srv := newTestServer(t)
+
+	clientCredentials := url.Values{"grant_type": {"client_credentials"}}
+	with := func(values ...string) url.Values {
+		form := url.Values{"grant_type": {"client_credentials"}}
+		for i := 0; i < len(values); i += 2 {
+			form.Add(values[i], values[i+1])
+		}
+		return form
+	}
+
+	tests := []struct {
+		name       string
+		id, secret string
+		form       url.Values
+		wantStatus int
+		wantError  string
+	}{
+		{"basic", "billing", "s3cret", clientCredentials, http.StatusOK, ""},
+		{"post", "", "", with("client_id", "billing", "client_secret", "s3cret"), http.StatusOK, ""},
+		{"hashed secret", "reports", "hunter2", clientCredentials, http.StatusOK, ""},
+		{"basic wrong secret", "billing", "wrong", clientCredentials, http.StatusUnauthorized, "invalid_client"},
+		{"post wrong secret", "", "", with("client_id", "billing", "client_secret", "wrong"), http.StatusUnauthorized, "invalid_client"},
+		{"hashed secret wrong", "reports", "hunter2x", clientCredentials, http.StatusUnauthorized, "invalid_client"},
+		{"unknown client", "other", "s3cret", clientCredentials, http.StatusUnauthorized, "invalid_client"},
+		{"no authentication", "", "", clientCredentials, http.StatusUnauthorized, "invalid_client"},
+		{"grant type", "billing", "s3cret", url.Values{"grant_type": {"password"}}, http.StatusBadRequest, "unsupported_grant_type"},
+		{"scope", "billing", "s3cret", with("scope", "orders:read reports:read"), http.StatusBadRequest, "invalid_scope"},
+		{"audience", "billing", "s3cret", with("audience", "https://reports.local"), http.StatusBadRequest, "invalid_target"},
+		{"resource", "billing", "s3cret", with("resource", "https://orders.local", "resource", "https://reports.local"), http.StatusBadRequest, "invalid_target"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, body := requestToken(t, srv, tt.id, tt.secret, tt.form)
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %v", res.StatusCode, tt.wantStatus, body)
+			}
+			if got := res.Header.Get("Cache-Control"); got != "no-store" {
+				t.Fatalf("Cache-Control = %q, want no-store", got)
+			}
+			if tt.wantError != "" {
+				if body["error"] != tt.wantError {
+					t.Fatalf("error = %v, want %s", body["error"], tt.wantError)
+				}
+				return
+			}
+			if body["access_token"] == nil || body["token_type"] != "Bearer" {
+				t.Fatalf("response = %v, want access token", body)
+			}
+		})
+	}
+
+	res, _ := requestToken(t, srv, "", "", clientCredentials)
+	if got := res.Header.Get("WWW-Authenticate"); !strings.HasPrefix(got, "Basic") {
+		t.Fatalf("WWW-Authenticate = %q, want Basic challenge", got)
+	}
+
+	res, err := srv.Client().Get(srv.URL + tokenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("GET status = %d, want %d", res.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestTokenGrant(t *testing.T) {
+	srv := newTestServer(t)
+
+	// The tokens are verified using the published keys.
+	keys := oidc.NewRemoteKeySet(srv.URL+jwksPath, srv.Client())
+	parser := xwt.NewParser(xwt.WithIssuer(testIssuer), xwt.WithExpirationRequired())
+
+	tests := []struct {
+		name          string
+		id, secret    string
+		form          url.Values
+		wantType      string
+		wantScopes    []string
+		wantAudiences []string
+		wantTTL       time.Duration
+	}{
+		{"all allowed", "billing", "s3cret", url.Values{}, jwt.Type, []string{"orders:read", "orders:write"}, []string{"https://orders.local", "https://invoices.local"}, 15 * time.Minute},
+		{"narrowed scope", "billing", "s3cret", url.Values{"scope": {"orders:read"}}, jwt.Type, []string{"orders:read"}, []string{"https://orders.local", "https://invoices.local"}, 15 * time.Minute},
+		{"narrowed audience", "billing", "s3cret", url.Values{"audience": {"https://orders.local"}}, jwt.Type, []string{"orders:read", "orders:write"}, []string{"https://orders.local"}, 15 * time.Minute},
+		{"resource indicator", "billing", "s3cret", url.Values{"resource": {"https://invoices.local"}}, jwt.Type, []string{"orders:read", "orders:write"}, []string{"https://invoices.local"}, 15 * time.Minute},
+		{"client format and ttl", "reports", "hunter2", url.Values{}, pwt.Type, []string{"reports:read"}, []string{"https://reports.local"}, 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.form.Set("grant_type", "client_credentials")
+			res, body := requestToken(t, srv, tt.id, tt.secret, tt.form)
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %v", res.StatusCode, http.StatusOK, body)
+			}
+			if got, want := body["expires_in"], float64(tt.wantTTL/time.Second); got != want {
+				t.Fatalf("expires_in = %v, want %v", got, want)
+			}
+			if got, want := body["scope"], strings.Join(tt.wantScopes, " "); got != want {
+				t.Fatalf("scope = %v, want %v", got, want)
+			}
+
+			var claims xwt.ScopedClaims = &jwt.MapClaims{}
+			if tt.wantType == pwt.Type {
+				claims = &pwt.RegisteredClaims{}
+			}
+			token, err := parser.ParseWithClaims(body["access_token"].(string), claims, keys.Keyfunc)
+			if err != nil {
+				t.Fatalf("ParseWithClaims() error = %v", err)
+			}
+
+			if token.Header.Type != tt.wantType {
+				t.Fatalf("typ = %s, want %s", token.Header.Type, tt.wantType)
+			}
+			if claims.GetSubject() != tt.id {
+				t.Fatalf("sub = %s, want %s", claims.GetSubject(), tt.id)
+			}
+			if !reflect.DeepEqual(claims.GetScopes(), tt.wantScopes) {
+				t.Fatalf("scopes = %v, want %v", claims.GetScopes(), tt.wantScopes)
+			}
+			if !reflect.DeepEqual(claims.GetAudience(), tt.wantAudiences) {
+				t.Fatalf("aud = %v, want %v", claims.GetAudience(), tt.wantAudiences)
+			}
+			if ttl := claims.GetExpirationTime().Sub(claims.GetIssuedAt()); ttl != tt.wantTTL {
+				t.Fatalf("lifetime = %v, want %v", ttl, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	srv := newTestServer(t)
+
+	res, err := srv.Client().Get(srv.URL + jwksPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+	if got := res.Header.Get("Content-Type"); got != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", got)
+	}
+
+	var set struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Keys) != 1 {
+		t.Fatalf("JWKS contains %d keys, want 1", len(set.Keys))
+	}
+
+	key := set.Keys[0]
+	for name, want := range map[string]interface{}{"kty": "EC", "crv": "P-256", "alg": "ES256", "use": "sig"} {
+		if key[name] != want {
+			t.Errorf("%s = %v, want %v", name, key[name], want)
+		}
+	}
+	if key["kid"] == nil || key["kid"] == "" {
+		t.Error("kid is missing")
+	}
+	if _, ok := key["d"]; ok {
+		t.Error("JWKS contains the private key")
+	}
+
+	// The key ID of issued tokens refers to the published key.
+	_, body := requestToken(t, srv, "billing", "s3cret", url.Values{"grant_type": {"client_credentials"}})
+	token, _, err := xwt.NewParser().ParseUnverified(body["access_token"].(string), &jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Header.KeyID != key["kid"] {
+		t.Fatalf("kid = %s, want %v", token.Header.KeyID, key["kid"])
+	}
+}
@@ -0,0 +1,74 @@
+package xwt_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+// signHeader signs a token with the given raw JSON header and an empty claims
+// set, so that malformed headers can be constructed.
+func signHeader(t *testing.T, header string) string {
+	t.Helper()
+
+	input := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte("{}"))
+	sig, err := method.SigningMethodHS256.Sign(input, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestCriticalHeader(t *testing.T) {
+	errHandler := errors.New("unknown tenant")
+	tenant := func(value interface{}) error {
+		if value != "acme" {
+			return errHandler
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		opts    []xwt.ParserOption
+		wantErr []error
+	}{
+		{"no crit", `{"alg":"HS256","tenant":"acme"}`, nil, nil},
+		{"unknown", `{"alg":"HS256","crit":["tenant"],"tenant":"acme"}`, nil, []error{internal.ErrTokenUnverifiable}},
+		{"missing", `{"alg":"HS256","crit":["tenant"]}`, []xwt.ParserOption{xwt.WithCriticalHeaderHandler("tenant", nil)}, []error{internal.ErrTokenMalformed}},
+		{"empty", `{"alg":"HS256","crit":[]}`, nil, []error{internal.ErrTokenMalformed}},
+		{"duplicate", `{"alg":"HS256","crit":["tenant","tenant"],"tenant":"acme"}`, []xwt.ParserOption{xwt.WithCriticalHeaderHandler("tenant", nil)}, []error{internal.ErrTokenMalformed}},
+		{"registered", `{"alg":"HS256","crit":["kid"],"kid":"k1"}`, []xwt.ParserOption{xwt.WithCriticalHeaderHandler("kid", nil)}, []error{internal.ErrTokenMalformed}},
+		{"not a list", `{"alg":"HS256","crit":"tenant","tenant":"acme"}`, nil, []error{internal.ErrTokenMalformed}},
+		{"understood", `{"alg":"HS256","crit":["tenant"],"tenant":"acme"}`, []xwt.ParserOption{xwt.WithCriticalHeaderHandler("tenant", nil)}, nil},
+		{"handler", `{"alg":"HS256","crit":["tenant"],"tenant":"acme"}`, []xwt.ParserOption{xwt.WithCriticalHeaderHandler("tenant", tenant)}, nil},
+		{"handler error", `{"alg":"HS256","crit":["tenant"],"tenant":"other"}`, []xwt.ParserOption{xwt.WithCriticalHeaderHandler("tenant", tenant)}, []error{internal.ErrTokenUnverifiable, errHandler}},
+		{"other handler", `{"alg":"HS256","crit":["tenant"],"tenant":"acme"}`, []xwt.ParserOption{xwt.WithCriticalHeaderHandler("region", nil)}, []error{internal.ErrTokenUnverifiable}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := xwt.NewParser(tt.opts...).ParseWithClaims(signHeader(t, tt.header), &jwt.MapClaims{}, func(*xwt.Token) (interface{}, error) {
+				return testSecret, nil
+			})
+			if tt.wantErr == nil {
+				if err != nil || !token.Valid {
+					t.Fatalf("ParseWithClaims() error = %v", err)
+				}
+				return
+			}
+			for _, want := range tt.wantErr {
+				if !errors.Is(err, want) {
+					t.Fatalf("ParseWithClaims() error = %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package xwt_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+	"github.com/lkyzhu/xwt/pwt"
+	"github.com/lkyzhu/xwt/pwt/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func secretKeyfunc(*xwt.Token) (interface{}, error) {
+	return testSecret, nil
+}
+
+func TestDetachedUnencodedPayload(t *testing.T) {
+	claims := &pwt.RegisteredClaims{StandardClaims: pb.StandardClaims{
+		Subject:  "alice",
+		Audience: []string{"https://api.example.com"},
+		Scope:    "orders:read",
+	}}
+
+	s, payload, err := xwt.NewWithClaims(method.SigningMethodHS256, claims, xwt.WithUnencodedPayload()).SignedDetached(testSecret)
+	if err != nil {
+		t.Fatalf("SignedDetached() error = %v", err)
+	}
+	if parts := strings.Split(s, "."); len(parts) != 3 || parts[1] != "" {
+		t.Fatalf("SignedDetached() = %s, want detached content", s)
+	}
+
+	// The payload is the raw protobuf encoding of the claims
+	want, err := proto.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(payload, want) {
+		t.Fatalf("SignedDetached() payload = %x, want %x", payload, want)
+	}
+
+	parsed := &pwt.RegisteredClaims{}
+	token, err := xwt.NewParser().ParseDetached(s, payload, parsed, secretKeyfunc)
+	if err != nil {
+		t.Fatalf("ParseDetached() error = %v", err)
+	}
+	if !token.Valid || token.Header.Extra["b64"] != false || parsed.Subject != "alice" || parsed.Scope != "orders:read" {
+		t.Fatalf("ParseDetached() = %+v, %+v", token.Header, parsed)
+	}
+
+	// The payload cannot be altered
+	tampered := append([]byte(nil), payload...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err = xwt.NewParser().ParseDetached(s, tampered, &pwt.RegisteredClaims{}, secretKeyfunc); !errors.Is(err, internal.ErrTokenSignatureInvalid) {
+		t.Fatalf("ParseDetached() of tampered payload error = %v, want %v", err, internal.ErrTokenSignatureInvalid)
+	}
+
+	// The detached token cannot be used with another payload segment
+	if _, err = xwt.NewParser().ParseWithClaims(s, &pwt.RegisteredClaims{}, secretKeyfunc); err == nil {
+		t.Fatal("ParseWithClaims() of detached token succeeded")
+	}
+}
+
+func TestDetachedEncodedPayload(t *testing.T) {
+	s, payload, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"}).SignedDetached(testSecret)
+	if err != nil {
+		t.Fatalf("SignedDetached() error = %v", err)
+	}
+	if string(payload) != `{"sub":"alice"}` {
+		t.Fatalf("SignedDetached() payload = %s", payload)
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err = xwt.NewParser().ParseDetached(s, payload, &claims, secretKeyfunc); err != nil || claims["sub"] != "alice" {
+		t.Fatalf("ParseDetached() = %v, error = %v", claims, err)
+	}
+	if _, err = xwt.NewParser().ParseDetached(s, []byte(`{"sub":"bob"}`), &jwt.MapClaims{}, secretKeyfunc); !errors.Is(err, internal.ErrTokenSignatureInvalid) {
+		t.Fatalf("ParseDetached() of tampered payload error = %v, want %v", err, internal.ErrTokenSignatureInvalid)
+	}
+
+	// A token with attached content is not detached
+	compact, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"}).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = xwt.NewParser().ParseDetached(compact, payload, &jwt.MapClaims{}, secretKeyfunc); !errors.Is(err, internal.ErrTokenMalformed) {
+		t.Fatalf("ParseDetached() of compact token error = %v, want %v", err, internal.ErrTokenMalformed)
+	}
+}
+
+func TestUnencodedPayloadCritical(t *testing.T) {
+	// b64 must be listed in crit, otherwise recipients not understanding it
+	// would verify a different signing input
+	s, payload, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"}, xwt.WithHeader("b64", false)).SignedDetached(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = xwt.NewParser().ParseDetached(s, payload, &jwt.MapClaims{}, secretKeyfunc); !errors.Is(err, internal.ErrTokenMalformed) {
+		t.Fatalf("ParseDetached() without crit error = %v, want %v", err, internal.ErrTokenMalformed)
+	}
+
+	s, payload, err = xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"}, xwt.WithHeader("b64", "false"), xwt.WithHeader("crit", []string{"b64"})).SignedDetached(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = xwt.NewParser().ParseDetached(s, payload, &jwt.MapClaims{}, secretKeyfunc); !errors.Is(err, internal.ErrTokenMalformed) {
+		t.Fatalf("ParseDetached() with string b64 error = %v, want %v", err, internal.ErrTokenMalformed)
+	}
+}
+
+func TestUnencodedPayloadCompact(t *testing.T) {
+	// Without a period, the unencoded payload can be part of the compact
+	// serialization
+	s, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"}, xwt.WithUnencodedPayload()).SignedString(testSecret)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	if parts := strings.Split(s, "."); len(parts) != 3 || parts[1] != `{"sub":"alice"}` {
+		t.Fatalf("SignedString() = %s, want unencoded payload", s)
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err = xwt.NewParser().ParseWithClaims(s, &claims, secretKeyfunc); err != nil || claims["sub"] != "alice" {
+		t.Fatalf("ParseWithClaims() = %v, error = %v", claims, err)
+	}
+
+	_, err = xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"iss": "https://auth.example.com"}, xwt.WithUnencodedPayload()).SignedString(testSecret)
+	if err == nil || !strings.Contains(err.Error(), "period") {
+		t.Fatalf("SignedString() of payload with period error = %v, want error", err)
+	}
+}
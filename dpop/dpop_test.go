@@ -0,0 +1,359 @@
+package dpop_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/dpop"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+const testURI = "https://server.example.com/token"
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// jwkOf returns the JWK of a key as header parameter.
+func jwkOf(t *testing.T, key *ecdsa.PublicKey) map[string]interface{} {
+	t.Helper()
+
+	b, err := method.MarshalPublicKeyToJWK(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jwk map[string]interface{}
+	if err := json.Unmarshal(b, &jwk); err != nil {
+		t.Fatal(err)
+	}
+	return jwk
+}
+
+// signProof creates a proof with arbitrary claims and header, which the
+// Proofer would not create.
+func signProof(t *testing.T, key *ecdsa.PrivateKey, claims *dpop.ProofClaims, opts ...xwt.TokenOption) string {
+	t.Helper()
+
+	opts = append([]xwt.TokenOption{xwt.WithHeader("jwk", jwkOf(t, &key.PublicKey))}, opts...)
+	proof, err := xwt.NewWithClaims(method.SigningMethodES256, claims, opts...).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+func proofClaims(htm, htu string, iat time.Time) *dpop.ProofClaims {
+	return &dpop.ProofClaims{
+		RegisteredClaims: jwt.RegisteredClaims{ID: base64.RawURLEncoding.EncodeToString([]byte(iat.String())), IssuedAt: jwt.NewNumericDate(iat)},
+		HTTPMethod:       htm,
+		HTTPURI:          htu,
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	key := newKey(t)
+	proofer, err := dpop.NewProofer(method.SigningMethodES256, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof := func(htm, htu string, opts ...dpop.ProofOption) string {
+		p, err := proofer.Proof(htm, htu, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	// The jwk must not contain the private key.
+	privateJWK := jwkOf(t, &key.PublicKey)
+	privateJWK["d"] = base64.RawURLEncoding.EncodeToString(key.D.FillBytes(make([]byte, 32)))
+
+	now := time.Now()
+	tests := []struct {
+		name        string
+		verifier    *dpop.Verifier
+		proof       string
+		htm         string
+		htu         string
+		accessToken string
+		wantErr     error
+	}{
+		{"valid", &dpop.Verifier{}, proof("POST", testURI), "POST", testURI, "", nil},
+		{"htm mismatch", &dpop.Verifier{}, proof("POST", testURI), "GET", testURI, "", dpop.ErrInvalidProof},
+		{"htu mismatch", &dpop.Verifier{}, proof("POST", testURI), "POST", "https://server.example.com/other", "", dpop.ErrInvalidProof},
+		{"htu normalized", &dpop.Verifier{}, proof("POST", "HTTPS://Server.Example.com:443/token"), "POST", testURI + "?query#fragment", "", nil},
+		{"htu port", &dpop.Verifier{}, proof("POST", "https://server.example.com:8443/token"), "POST", testURI, "", dpop.ErrInvalidProof},
+		{"too old", &dpop.Verifier{TimeFunc: func() time.Time { return now.Add(2 * dpop.DefaultMaxAge) }}, proof("POST", testURI), "POST", testURI, "", dpop.ErrInvalidProof},
+		{"max age", &dpop.Verifier{MaxAge: time.Hour, TimeFunc: func() time.Time { return now.Add(2 * dpop.DefaultMaxAge) }}, proof("POST", testURI), "POST", testURI, "", nil},
+		{"future", &dpop.Verifier{}, signProof(t, key, proofClaims("POST", testURI, now.Add(time.Hour))), "POST", testURI, "", dpop.ErrInvalidProof},
+		{"future within leeway", &dpop.Verifier{Leeway: 2 * time.Hour}, signProof(t, key, proofClaims("POST", testURI, now.Add(time.Hour))), "POST", testURI, "", nil},
+		{"ath", &dpop.Verifier{}, proof("GET", testURI, dpop.WithAccessToken("token")), "GET", testURI, "token", nil},
+		{"ath mismatch", &dpop.Verifier{}, proof("GET", testURI, dpop.WithAccessToken("token")), "GET", testURI, "other", dpop.ErrInvalidProof},
+		{"ath missing", &dpop.Verifier{}, proof("GET", testURI), "GET", testURI, "token", dpop.ErrInvalidProof},
+		{"private key in jwk", &dpop.Verifier{}, signProof(t, key, proofClaims("POST", testURI, now), xwt.WithHeader("jwk", privateJWK)), "POST", testURI, "", dpop.ErrInvalidProof},
+		{"wrong typ", &dpop.Verifier{}, signProof(t, key, proofClaims("POST", testURI, now), xwt.WithHeader("typ", "JWT")), "POST", testURI, "", dpop.ErrInvalidProof},
+		{"jwk of other key", &dpop.Verifier{}, signProof(t, key, proofClaims("POST", testURI, now), xwt.WithHeader("jwk", jwkOf(t, &newKey(t).PublicKey))), "POST", testURI, "", dpop.ErrInvalidProof},
+		{"invalid method", &dpop.Verifier{ValidMethods: []string{"EdDSA"}}, proof("POST", testURI), "POST", testURI, "", dpop.ErrInvalidProof},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.verifier.Verify(tt.proof, tt.htm, tt.htu, tt.accessToken)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && result.Thumbprint != proofer.Thumbprint() {
+				t.Fatalf("Verify() thumbprint = %s, want %s", result.Thumbprint, proofer.Thumbprint())
+			}
+		})
+	}
+}
+
+func TestVerifierReplay(t *testing.T) {
+	proofer, err := dpop.NewProofer(method.SigningMethodES256, newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := proofer.Proof("POST", testURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := &dpop.Verifier{ReplayCache: dpop.NewMemoryReplayCache()}
+	if _, err := v.Verify(proof, "POST", testURI, ""); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if _, err := v.Verify(proof, "POST", testURI, ""); !errors.Is(err, dpop.ErrProofReplayed) {
+		t.Fatalf("Verify() of replayed proof error = %v, want %v", err, dpop.ErrProofReplayed)
+	}
+}
+
+func TestMemoryReplayCache(t *testing.T) {
+	c := dpop.NewMemoryReplayCache()
+	now := time.Now()
+
+	if c.Seen("a", now.Add(-time.Second)) {
+		t.Fatal("Seen(a) = true, want false")
+	}
+	if c.Seen("b", now.Add(time.Hour)) {
+		t.Fatal("Seen(b) = true, want false")
+	}
+	if !c.Seen("b", now.Add(time.Hour)) {
+		t.Fatal("Seen(b) again = false, want true")
+	}
+
+	// a has expired, so it is recorded anew.
+	if c.Seen("a", now.Add(time.Hour)) {
+		t.Fatal("Seen(a) after expiration = true, want false")
+	}
+	if !c.Seen("a", now.Add(time.Hour)) {
+		t.Fatal("Seen(a) again = false, want true")
+	}
+}
+
+func TestVerifyBinding(t *testing.T) {
+	proofer, err := dpop.NewProofer(method.SigningMethodES256, newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := proofer.Proof("GET", testURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := &dpop.Verifier{}
+	result, err := v.Verify(proof, "GET", testURI, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		claims  dpop.BoundClaims
+		wantErr error
+	}{
+		{"bound", &jwt.RegisteredClaims{Confirmation: &jwt.Confirmation{JWKThumbprint: proofer.Thumbprint()}}, nil},
+		{"bound map claims", &jwt.MapClaims{"cnf": map[string]interface{}{"jkt": proofer.Thumbprint()}}, nil},
+		{"other key", &jwt.RegisteredClaims{Confirmation: &jwt.Confirmation{JWKThumbprint: "other"}}, dpop.ErrTokenNotBound},
+		{"not bound", &jwt.RegisteredClaims{}, dpop.ErrMissingBinding},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := v.VerifyBinding(result, tt.claims); err != tt.wantErr {
+				t.Fatalf("VerifyBinding() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	const uri = "http://api.example.com/resource"
+
+	serverKey := newKey(t)
+	proofer, err := dpop.NewProofer(method.SigningMethodES256, newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accessToken := func(jkt string) string {
+		token, err := xwt.NewWithClaims(method.SigningMethodES256, &jwt.MapClaims{
+			"sub": "alice",
+			"exp": time.Now().Add(time.Hour).Unix(),
+			"cnf": map[string]interface{}{"jkt": jkt},
+		}).SignedString(serverKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	// Neither Verifier nor NewClaims are set, so the defaults are used.
+	m := &dpop.Middleware{
+		Keyfunc: func(*xwt.Token) (interface{}, error) {
+			return &serverKey.PublicKey, nil
+		},
+	}
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := dpop.TokenFromContext(r.Context()); !ok {
+			t.Error("TokenFromContext() = false")
+		}
+		if proof, ok := dpop.ProofFromContext(r.Context()); !ok || proof.Thumbprint != proofer.Thumbprint() {
+			t.Error("ProofFromContext() does not return the proof")
+		}
+	}))
+
+	serve := func(token, proof string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, uri, nil)
+		r.Header.Set("Authorization", dpop.AuthScheme+" "+token)
+		if proof != "" {
+			r.Header.Set(dpop.HeaderName, proof)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	newProof := func(token string) string {
+		proof, err := proofer.Proof(http.MethodGet, uri, dpop.WithAccessToken(token))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return proof
+	}
+
+	bound := accessToken(proofer.Thumbprint())
+	proof := newProof(bound)
+	if w := serve(bound, proof); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// The default Verifier is kept across requests, so it detects the replay.
+	w := serve(bound, proof)
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Header().Get("WWW-Authenticate"), `error="invalid_dpop_proof"`) {
+		t.Fatalf("replayed proof: status = %d, WWW-Authenticate = %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+
+	other := accessToken("other")
+	w = serve(other, newProof(other))
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Header().Get("WWW-Authenticate"), `error="invalid_token"`) {
+		t.Fatalf("token bound to other key: status = %d, WWW-Authenticate = %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+
+	w = serve(bound, "")
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Header().Get("WWW-Authenticate"), `error="invalid_dpop_proof"`) {
+		t.Fatalf("missing proof: status = %d, WWW-Authenticate = %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestMiddlewareURL(t *testing.T) {
+	const uri = "https://api.example.com/resource"
+
+	serverKey := newKey(t)
+	proofer, err := dpop.NewProofer(method.SigningMethodES256, newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := xwt.NewWithClaims(method.SigningMethodES256, &jwt.MapClaims{
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"cnf": map[string]interface{}{"jkt": proofer.Thumbprint()},
+	}).SignedString(serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		target   string // the URL received by the server
+		host     string
+		baseURL  string
+		url      func(*http.Request) string
+		wantCode int
+	}{
+		{"reconstructed", uri, "", "", nil, http.StatusOK},
+		// Behind a reverse proxy, the request is received as plain HTTP
+		{"reconstructed behind proxy", "http://backend:8080/resource", "", "", nil, http.StatusUnauthorized},
+		{"base URL", "http://backend:8080/resource", "", "https://api.example.com", nil, http.StatusOK},
+		{"base URL with slash", "http://backend:8080/resource", "", "https://api.example.com/", nil, http.StatusOK},
+		{"base URL of other server", uri, "", "https://other.example.com", nil, http.StatusUnauthorized},
+		// The Host header is ignored, if the base URL is set
+		{"base URL with forged host", "https://other.example.com/resource", "api.example.com", "https://other.example.com", nil, http.StatusUnauthorized},
+		{"url", "http://backend:8080/resource", "", "", func(r *http.Request) string { return "https://api.example.com" + r.URL.Path }, http.StatusOK},
+		{"url before base URL", "http://backend:8080/resource", "", "https://other.example.com", func(r *http.Request) string { return "https://api.example.com" + r.URL.Path }, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &dpop.Middleware{
+				BaseURL: tt.baseURL,
+				URL:     tt.url,
+				Keyfunc: func(*xwt.Token) (interface{}, error) {
+					return &serverKey.PublicKey, nil
+				},
+			}
+			handler := m.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			proof, err := proofer.Proof(http.MethodGet, uri, dpop.WithAccessToken(token))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.host != "" {
+				r.Host = tt.host
+			}
+			r.Header.Set("Authorization", dpop.AuthScheme+" "+token)
+			r.Header.Set(dpop.HeaderName, proof)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
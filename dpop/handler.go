@@ -0,0 +1,219 @@
+package dpop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+)
+
+type contextKey int
+
+const (
+	tokenContextKey contextKey = iota
+	proofContextKey
+)
+
+// Middleware protects HTTP handlers with DPoP-bound access tokens, as described
+// in https://datatracker.ietf.org/doc/html/rfc9449#section-7. For every
+// request, it verifies the DPoP proof, the access token in the Authorization
+// header and that the token is bound to the key of the proof.
+//
+//	m := &dpop.Middleware{
+//	    BaseURL:   "https://api.example.com",
+//	    Verifier:  &dpop.Verifier{ReplayCache: dpop.NewMemoryReplayCache()},
+//	    Keyfunc:   keyFunc,
+//	    NewClaims: func() dpop.BoundClaims { return &pwt.RegisteredClaims{} },
+//	}
+//	http.Handle("/api", m.Handler(api))
+type Middleware struct {
+	// Verifier verifies the DPoP proofs. If nil, a Verifier with a
+	// MemoryReplayCache is used, which is shared by all requests.
+	Verifier *Verifier
+
+	// Parser parses the access tokens. If nil, xwt.NewParser() is used.
+	Parser *xwt.Parser
+
+	// Keyfunc supplies the keys to verify the access tokens.
+	Keyfunc xwt.Keyfunc
+
+	// NewClaims returns an empty claims object for each access token. If nil,
+	// jwt.MapClaims are used.
+	NewClaims func() BoundClaims
+
+	// BaseURL is the external URL of the server, e.g.
+	// "https://api.example.com". The path of each request is appended to it
+	// to obtain the URI, which is compared against the `htu` claim.
+	//
+	// If neither BaseURL nor URL are set, the URI is reconstructed from the
+	// TLS state and the Host header of the request. As the Host header is
+	// chosen by the client, proofs created for another server using the same
+	// keys could then be accepted. BaseURL should therefore always be set in
+	// production, in particular if the server is running behind a reverse
+	// proxy.
+	BaseURL string
+
+	// URL returns the absolute URI of a request, which is compared against the
+	// `htu` claim. It takes precedence over BaseURL and can be used if the
+	// URI cannot be derived from a single base URL.
+	URL func(r *http.Request) string
+
+	once     sync.Once
+	verifier *Verifier
+}
+
+// Handler returns a handler that only calls next, if the request carries a
+// valid DPoP proof and access token. The verified token and proof can be
+// retrieved from the request context using [TokenFromContext] and
+// [ProofFromContext]. Otherwise, it responds with 401 Unauthorized and a DPoP
+// challenge.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, proof, err := m.verify(r)
+		if err != nil {
+			m.challenge(w, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), tokenContextKey, token)
+		ctx = context.WithValue(ctx, proofContextKey, proof)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// verify verifies proof and access token of a request.
+func (m *Middleware) verify(r *http.Request) (*xwt.Token, *Proof, error) {
+	accessToken, ok := accessTokenFromRequest(r)
+	if !ok {
+		return nil, nil, errMissingToken
+	}
+
+	proofs := r.Header.Values(HeaderName)
+	if len(proofs) != 1 {
+		return nil, nil, internal.NewError("request must contain exactly one DPoP header", ErrInvalidProof)
+	}
+
+	verifier := m.defaultVerifier()
+
+	htu := m.requestURL(r)
+
+	proof, err := verifier.Verify(proofs[0], r.Method, htu, accessToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parser := m.Parser
+	if parser == nil {
+		parser = xwt.NewParser()
+	}
+
+	var claims BoundClaims = &jwt.MapClaims{}
+	if m.NewClaims != nil {
+		claims = m.NewClaims()
+	}
+	token, err := parser.ParseWithClaims(accessToken, claims, m.Keyfunc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = verifier.VerifyBinding(proof, claims); err != nil {
+		return nil, nil, err
+	}
+
+	return token, proof, nil
+}
+
+// defaultVerifier returns the Verifier, or a Verifier with a replay cache, if
+// none is configured. The replay cache must outlive the request, so it is
+// created only once.
+func (m *Middleware) defaultVerifier() *Verifier {
+	if m.Verifier != nil {
+		return m.Verifier
+	}
+
+	m.once.Do(func() {
+		m.verifier = &Verifier{ReplayCache: NewMemoryReplayCache()}
+	})
+
+	return m.verifier
+}
+
+// errMissingToken is returned if the request does not carry a DPoP-bound
+// access token. It results in a challenge without error code, see RFC 6750
+// section 3.1.
+var errMissingToken = errors.New("request does not contain a DPoP access token")
+
+// challenge responds with a DPoP challenge for err, as described in
+// https://datatracker.ietf.org/doc/html/rfc9449#section-7.1.
+func (m *Middleware) challenge(w http.ResponseWriter, err error) {
+	var params []string
+	if m.Verifier != nil && len(m.Verifier.ValidMethods) > 0 {
+		params = append(params, fmt.Sprintf(`algs="%s"`, strings.Join(m.Verifier.ValidMethods, " ")))
+	}
+
+	switch {
+	case errors.Is(err, errMissingToken):
+	case errors.Is(err, ErrInvalidProof), errors.Is(err, ErrProofReplayed):
+		params = append(params, `error="invalid_dpop_proof"`)
+	default:
+		params = append(params, `error="invalid_token"`)
+	}
+
+	challenge := AuthScheme
+	if len(params) > 0 {
+		challenge += " " + strings.Join(params, ", ")
+	}
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// TokenFromContext returns the access token verified by the [Middleware].
+func TokenFromContext(ctx context.Context) (*xwt.Token, bool) {
+	token, ok := ctx.Value(tokenContextKey).(*xwt.Token)
+	return token, ok
+}
+
+// ProofFromContext returns the DPoP proof verified by the [Middleware].
+func ProofFromContext(ctx context.Context) (*Proof, bool) {
+	proof, ok := ctx.Value(proofContextKey).(*Proof)
+	return proof, ok
+}
+
+// accessTokenFromRequest returns the access token of the Authorization header,
+// if it uses the DPoP scheme.
+func accessTokenFromRequest(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, AuthScheme) {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+
+	return token, token != ""
+}
+
+// requestURL returns the absolute URI of a request, without query and
+// fragment.
+func (m *Middleware) requestURL(r *http.Request) string {
+	switch {
+	case m.URL != nil:
+		return m.URL(r)
+	case m.BaseURL != "":
+		return strings.TrimSuffix(m.BaseURL, "/") + r.URL.EscapedPath()
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host + r.URL.EscapedPath()
+}
@@ -0,0 +1,172 @@
+// Package dpop implements Demonstrating Proof of Possession (DPoP), as
+// specified in https://datatracker.ietf.org/doc/html/rfc9449.
+//
+// A DPoP proof is a short-lived JWT, signed by a key of the client, which is
+// sent along with every request in the DPoP header. An access token that is
+// bound to this key, using the `jkt` member of its `cnf` claim, can only be
+// used together with a valid proof, so a stolen token cannot be replayed
+// without the private key of the client.
+//
+// On the client side, a [Proofer] creates the proofs and a [Transport]
+// attaches them to outgoing requests. On the server side, a [Verifier] checks
+// the proofs and the [Middleware] verifies proof and access token together.
+package dpop
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+const (
+	// TokenType is the `typ` header parameter of a DPoP proof.
+	TokenType = "dpop+jwt"
+
+	// HeaderName is the name of the HTTP header carrying the DPoP proof.
+	HeaderName = "DPoP"
+
+	// NonceHeaderName is the name of the HTTP header a server uses to supply a
+	// nonce, which the client must include in its subsequent proofs.
+	NonceHeaderName = "DPoP-Nonce"
+
+	// AuthScheme is the authentication scheme of DPoP-bound access tokens in
+	// the Authorization header.
+	AuthScheme = "DPoP"
+)
+
+// ProofClaims are the claims of a DPoP proof, as described in
+// https://datatracker.ietf.org/doc/html/rfc9449#section-4.2. Of the registered
+// claims, only `jti` and `iat` are used.
+type ProofClaims struct {
+	jwt.RegisteredClaims
+
+	// the `htm` claim, the HTTP method of the request
+	HTTPMethod string `json:"htm"`
+
+	// the `htu` claim, the HTTP target URI of the request, without query and
+	// fragment
+	HTTPURI string `json:"htu"`
+
+	// the `ath` claim, the hash of the access token, see [AccessTokenHash]
+	AccessTokenHash string `json:"ath,omitempty"`
+
+	// the `nonce` claim, a nonce previously provided by the server
+	Nonce string `json:"nonce,omitempty"`
+}
+
+// Type implements the Claims interface.
+func (c *ProofClaims) Type() string {
+	return TokenType
+}
+
+// Marshal implements the Claims interface.
+func (c *ProofClaims) Marshal() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// Unmarshal implements the Claims interface.
+func (c *ProofClaims) Unmarshal(data []byte) error {
+	return json.Unmarshal(data, c)
+}
+
+// AccessTokenHash returns the value of the `ath` claim for an access token,
+// which is the base64url encoded SHA-256 hash of the token.
+func AccessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Proofer creates DPoP proofs signed by a single private key. It is safe for
+// concurrent use. The [NewProofer] function should be used to create an
+// instance of this struct.
+type Proofer struct {
+	method     method.SigningMethod
+	key        crypto.Signer
+	jwk        map[string]interface{}
+	thumbprint string
+}
+
+// NewProofer creates a Proofer signing with the given asymmetric signing method
+// and key. The public key of key is embedded in every proof, so it must be one
+// of the types supported by [method.MarshalPublicKeyToJWK].
+func NewProofer(m method.SigningMethod, key crypto.Signer) (*Proofer, error) {
+	if m == nil || m.Alg() == method.SigningMethodNone.Alg() {
+		return nil, errors.New("DPoP proofs require an asymmetric signing method")
+	}
+
+	b, err := method.MarshalPublicKeyToJWK(key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	var jwk map[string]interface{}
+	if err = json.Unmarshal(b, &jwk); err != nil {
+		return nil, err
+	}
+
+	thumbprint, err := method.JWKThumbprint(key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Proofer{method: m, key: key, jwk: jwk, thumbprint: thumbprint}, nil
+}
+
+// Thumbprint returns the JWK SHA-256 thumbprint of the public key. This is the
+// value an authorization server puts into the `jkt` member of the `cnf` claim
+// of the access tokens it binds to this key.
+func (p *Proofer) Thumbprint() string {
+	return p.thumbprint
+}
+
+// ProofOption is used to implement functional-style options that modify the
+// claims of a proof created by [Proofer.Proof].
+type ProofOption func(*ProofClaims)
+
+// WithAccessToken binds the proof to an access token, by setting the `ath`
+// claim. This is required when the proof is presented to a resource server
+// together with the access token.
+func WithAccessToken(accessToken string) ProofOption {
+	return func(c *ProofClaims) {
+		c.AccessTokenHash = AccessTokenHash(accessToken)
+	}
+}
+
+// WithNonce sets the `nonce` claim to a nonce previously provided by the
+// server in the DPoP-Nonce header.
+func WithNonce(nonce string) ProofOption {
+	return func(c *ProofClaims) {
+		c.Nonce = nonce
+	}
+}
+
+// Proof creates a new proof for a request with the HTTP method htm to the URI
+// htu. Query and fragment of htu should be omitted.
+func (p *Proofer) Proof(htm, htu string, opts ...ProofOption) (string, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+
+	claims := &ProofClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       base64.RawURLEncoding.EncodeToString(jti),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+		HTTPMethod: htm,
+		HTTPURI:    htu,
+	}
+	for _, o := range opts {
+		o(claims)
+	}
+
+	return xwt.NewWithClaims(p.method, claims, xwt.WithHeader("jwk", p.jwk)).SignedString(p.key)
+}
@@ -0,0 +1,113 @@
+package dpop
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Transport is an [http.RoundTripper] that attaches a DPoP proof to every
+// request. If the request carries an access token using the DPoP scheme in the
+// Authorization header, the proof is bound to it.
+//
+// Nonces supplied by servers in the DPoP-Nonce header are remembered per host
+// and included in subsequent proofs. If a server rejects a request because it
+// requires a new nonce, the request is retried once, provided that its body can
+// be replayed.
+type Transport struct {
+	// Proofer creates the proofs.
+	Proofer *Proofer
+
+	// Base is the underlying RoundTripper. If nil, http.DefaultTransport is
+	// used.
+	Base http.RoundTripper
+
+	mu     sync.Mutex
+	nonces map[string]string
+}
+
+// RoundTrip implements the [http.RoundTripper] interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	nonce := t.nonce(req.URL.Host)
+
+	resp, err := t.roundTrip(req, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	newNonce := resp.Header.Get(NonceHeaderName)
+	if newNonce == "" || newNonce == nonce {
+		return resp, nil
+	}
+	t.setNonce(req.URL.Host, newNonce)
+
+	// The server requires a (new) nonce, see RFC 9449 section 8 and 9
+	if resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusBadRequest {
+		return resp, nil
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	resp.Body.Close()
+
+	retry := req
+	if req.Body != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry = req.Clone(req.Context())
+		retry.Body = body
+	}
+
+	return t.roundTrip(retry, newNonce)
+}
+
+// roundTrip sends req with a new proof.
+func (t *Transport) roundTrip(req *http.Request, nonce string) (*http.Response, error) {
+	var opts []ProofOption
+	if accessToken, ok := accessTokenFromRequest(req); ok {
+		opts = append(opts, WithAccessToken(accessToken))
+	}
+	if nonce != "" {
+		opts = append(opts, WithNonce(nonce))
+	}
+
+	htu := *req.URL
+	htu.RawQuery, htu.Fragment, htu.User = "", "", nil
+
+	proof, err := t.Proofer.Proof(req.Method, htu.String(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// A RoundTripper must not modify the request
+	r := req.Clone(req.Context())
+	r.Header.Set(HeaderName, proof)
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return base.RoundTrip(r)
+}
+
+// nonce returns the last nonce supplied by host.
+func (t *Transport) nonce(host string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.nonces[host]
+}
+
+// setNonce remembers the nonce supplied by host.
+func (t *Transport) setNonce(host, nonce string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.nonces == nil {
+		t.nonces = map[string]string{}
+	}
+	t.nonces[host] = nonce
+}
@@ -0,0 +1,304 @@
+package dpop
+
+import (
+	"container/heap"
+	"crypto"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/method"
+)
+
+var (
+	ErrInvalidProof   = errors.New("DPoP proof is invalid")
+	ErrProofReplayed  = errors.New("DPoP proof has already been used")
+	ErrTokenNotBound  = errors.New("access token is not bound to the DPoP key")
+	ErrMissingBinding = errors.New("access token does not contain a DPoP binding")
+)
+
+// DefaultMaxAge is the maximum age of a proof, if not configured otherwise in
+// the [Verifier].
+const DefaultMaxAge = time.Minute
+
+// BoundClaims is implemented by the claims of access tokens, which can be bound
+// to a DPoP key. [jwt.RegisteredClaims], [jwt.MapClaims] and
+// [pwt.RegisteredClaims] implement it.
+type BoundClaims interface {
+	xwt.Claims
+	GetConfirmationJWKThumbprint() string
+}
+
+// ReplayCache remembers the `jti` claims of proofs, in order to detect replayed
+// proofs. It must be safe for concurrent use.
+type ReplayCache interface {
+	// Seen records jti until the given time and reports whether it was
+	// already recorded before.
+	Seen(jti string, until time.Time) bool
+}
+
+// Proof is a verified DPoP proof.
+type Proof struct {
+	Token      *xwt.Token       // Token is the parsed proof
+	Claims     *ProofClaims     // Claims are the claims of the proof
+	Key        crypto.PublicKey // Key is the public key embedded in the proof
+	Thumbprint string           // Thumbprint is the JWK SHA-256 thumbprint of Key
+}
+
+// Verifier verifies DPoP proofs, as described in
+// https://datatracker.ietf.org/doc/html/rfc9449#section-4.3. The zero value is
+// ready to use, but does not detect replayed proofs.
+type Verifier struct {
+	// ValidMethods restricts the accepted signing methods of the proof. If
+	// empty, any asymmetric signing method is accepted.
+	ValidMethods []string
+
+	// MaxAge is the maximum age of a proof, based on its `iat` claim. If zero,
+	// DefaultMaxAge is used.
+	MaxAge time.Duration
+
+	// Leeway accounts for clock skew between client and server.
+	Leeway time.Duration
+
+	// ReplayCache is used to reject proofs, whose `jti` has been seen before.
+	// It is strongly recommended to set it, see
+	// https://datatracker.ietf.org/doc/html/rfc9449#section-11.1.
+	ReplayCache ReplayCache
+
+	// TimeFunc supplies the current time. If nil, time.Now is used.
+	TimeFunc func() time.Time
+}
+
+// Verify parses and verifies the proof of a request with the HTTP method htm to
+// the URI htu. If the proof is presented together with an access token,
+// accessToken must be supplied, so that the `ath` claim is verified, too.
+//
+// Note: Verify does not check whether the access token is bound to the key of
+// the proof, use [Verifier.VerifyBinding] for this.
+func (v *Verifier) Verify(proof, htm, htu, accessToken string) (*Proof, error) {
+	result := &Proof{Claims: &ProofClaims{}}
+
+	opts := []xwt.ParserOption{xwt.WithoutClaimsValidation()}
+	if len(v.ValidMethods) > 0 {
+		opts = append(opts, xwt.WithValidMethods(v.ValidMethods))
+	}
+
+	token, err := xwt.NewParser(opts...).ParseWithClaims(proof, result.Claims, func(t *xwt.Token) (interface{}, error) {
+		if t.Header.Type != TokenType {
+			return nil, fmt.Errorf("typ header parameter must be %s", TokenType)
+		}
+		if t.Method.Alg() == method.SigningMethodNone.Alg() {
+			return nil, errors.New("proof must be signed with an asymmetric signing method")
+		}
+
+		key, thumbprint, err := parseJWK(t.Header.JWK)
+		if err != nil {
+			return nil, err
+		}
+		result.Key, result.Thumbprint = key, thumbprint
+
+		return key, nil
+	})
+	if err != nil {
+		return nil, internal.NewError("could not verify proof", ErrInvalidProof, err)
+	}
+	result.Token = token
+
+	if err = v.validate(result.Claims, htm, htu, accessToken); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// VerifyBinding checks whether the access token with the given claims is bound
+// to the key of a verified proof, using the `jkt` member of its `cnf` claim.
+func (v *Verifier) VerifyBinding(proof *Proof, claims BoundClaims) error {
+	jkt := claims.GetConfirmationJWKThumbprint()
+	if jkt == "" {
+		return ErrMissingBinding
+	}
+
+	if subtle.ConstantTimeCompare([]byte(jkt), []byte(proof.Thumbprint)) != 1 {
+		return ErrTokenNotBound
+	}
+
+	return nil
+}
+
+// validate checks the claims of a proof, whose signature is already verified.
+func (v *Verifier) validate(claims *ProofClaims, htm, htu, accessToken string) error {
+	if claims.ID == "" {
+		return internal.NewError("jti claim is required", ErrInvalidProof, internal.ErrTokenRequiredClaimMissing)
+	}
+
+	if claims.HTTPMethod != htm {
+		return internal.NewError("htm claim does not match the request", ErrInvalidProof)
+	}
+
+	want, err := normalizeURI(htu)
+	if err != nil {
+		return internal.NewError("could not parse the request URI", ErrInvalidProof, err)
+	}
+	got, err := normalizeURI(claims.HTTPURI)
+	if err != nil || got != want {
+		return internal.NewError("htu claim does not match the request", ErrInvalidProof)
+	}
+
+	// The proof must have been issued recently, see RFC 9449 section 11.1
+	now := time.Now()
+	if v.TimeFunc != nil {
+		now = v.TimeFunc()
+	}
+	maxAge := v.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultMaxAge
+	}
+
+	iat := claims.GetIssuedAt()
+	if iat.IsZero() {
+		return internal.NewError("iat claim is required", ErrInvalidProof, internal.ErrTokenRequiredClaimMissing)
+	}
+	if iat.After(now.Add(v.Leeway)) {
+		return internal.NewError("proof is issued in the future", ErrInvalidProof, internal.ErrTokenUsedBeforeIssued)
+	}
+	if iat.Add(maxAge).Add(v.Leeway).Before(now) {
+		return internal.NewError("proof is too old", ErrInvalidProof, internal.ErrTokenExpired)
+	}
+
+	if accessToken != "" {
+		if subtle.ConstantTimeCompare([]byte(claims.AccessTokenHash), []byte(AccessTokenHash(accessToken))) != 1 {
+			return internal.NewError("ath claim does not match the access token", ErrInvalidProof)
+		}
+	}
+
+	// Check the replay cache last, so that invalid proofs do not fill it
+	if v.ReplayCache != nil && v.ReplayCache.Seen(claims.ID, iat.Add(maxAge).Add(v.Leeway)) {
+		return ErrProofReplayed
+	}
+
+	return nil
+}
+
+// parseJWK parses the `jwk` header parameter of a proof and returns the public
+// key and its thumbprint.
+func parseJWK(jwk map[string]interface{}) (crypto.PublicKey, string, error) {
+	if jwk == nil {
+		return nil, "", errors.New("jwk header parameter is missing")
+	}
+
+	// The jwk must not contain a private key, see RFC 9449 section 4.3
+	if _, ok := jwk["d"]; ok {
+		return nil, "", errors.New("jwk header parameter must not contain a private key")
+	}
+
+	b, err := json.Marshal(jwk)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key, err := method.ParsePublicKeyFromJWK(b)
+	if err != nil {
+		return nil, "", err
+	}
+
+	thumbprint, err := method.JWKThumbprint(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key, thumbprint, nil
+}
+
+// normalizeURI normalizes a htu claim or request URI for comparison, as
+// described in https://datatracker.ietf.org/doc/html/rfc9449#section-4.3.
+// Scheme and host are compared case-insensitively, default ports are removed,
+// and query and fragment are ignored.
+func normalizeURI(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return "", errors.New("URI must be absolute")
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Host)
+	if port := u.Port(); (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+		host = strings.TrimSuffix(host, ":"+port)
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	return scheme + "://" + host + path, nil
+}
+
+// MemoryReplayCache is an in-memory [ReplayCache]. Expired entries are removed
+// in the order of their expiration, so each call only pays for the entries
+// that expired since the last one. The [NewMemoryReplayCache] function should
+// be used to create an instance of this struct.
+type MemoryReplayCache struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	expiry  replayHeap
+	now     func() time.Time
+}
+
+// NewMemoryReplayCache creates an empty in-memory replay cache.
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{entries: map[string]time.Time{}, now: time.Now}
+}
+
+// Seen implements the [ReplayCache] interface.
+func (c *MemoryReplayCache) Seen(jti string, until time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for len(c.expiry) > 0 && c.expiry[0].until.Before(now) {
+		delete(c.entries, heap.Pop(&c.expiry).(replayEntry).jti)
+	}
+
+	if _, ok := c.entries[jti]; ok {
+		return true
+	}
+	c.entries[jti] = until
+	heap.Push(&c.expiry, replayEntry{jti: jti, until: until})
+
+	return false
+}
+
+// replayEntry is a jti of the MemoryReplayCache with its expiration.
+type replayEntry struct {
+	jti   string
+	until time.Time
+}
+
+// replayHeap implements heap.Interface, ordering the entries by expiration.
+type replayHeap []replayEntry
+
+func (h replayHeap) Len() int           { return len(h) }
+func (h replayHeap) Less(i, j int) bool { return h[i].until.Before(h[j].until) }
+func (h replayHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *replayHeap) Push(x interface{}) {
+	*h = append(*h, x.(replayEntry))
+}
+
+func (h *replayHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
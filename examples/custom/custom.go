@@ -2,6 +2,7 @@ package custom
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/lkyzhu/xwt/jwt"
 	"google.golang.org/protobuf/proto"
@@ -17,37 +18,35 @@ import (
 //
 // See examples for how to use this with your own claim types.
 
-/*
 // GetExpirationTime implements the Claims interface.
-func (c *CustomClaims) GetExpirationTime() int64 {
-	return c.Claims.ExpiresAt
+func (c *CustomClaims) GetExpirationTime() time.Time {
+	return unixTime(c.Claims.GetExpiresAt())
 }
 
 // GetNotBefore implements the Claims interface.
-func (c *CustomClaims) GetNotBefore() int64 {
-	return c.Claims.NotBefore
+func (c *CustomClaims) GetNotBefore() time.Time {
+	return unixTime(c.Claims.GetNotBefore())
 }
 
 // GetIssuedAt implements the Claims interface.
-func (c *CustomClaims) GetIssuedAt() int64 {
-	return c.Claims.IssuedAt
+func (c *CustomClaims) GetIssuedAt() time.Time {
+	return unixTime(c.Claims.GetIssuedAt())
 }
 
 // GetAudience implements the Claims interface.
 func (c *CustomClaims) GetAudience() []string {
-	return c.Claims.Audience
+	return c.Claims.GetAudience()
 }
 
 // GetIssuer implements the Claims interface.
 func (c *CustomClaims) GetIssuer() string {
-	return c.Claims.Issuer
+	return c.Claims.GetIssuer()
 }
 
 // GetSubject implements the Claims interface.
 func (c *CustomClaims) GetSubject() string {
-	return c.Claims.Subject
+	return c.Claims.GetSubject()
 }
-*/
 
 // Type implements the Claims interface.
 func (c *CustomClaims) Type() string {
@@ -71,18 +70,18 @@ type JwtCustomClaims struct {
 }
 
 // GetExpirationTime implements the Claims interface.
-func (c *JwtCustomClaims) GetExpirationTime() int64 {
-	return c.ExpiresAt
+func (c *JwtCustomClaims) GetExpirationTime() time.Time {
+	return c.RegisteredClaims.GetExpirationTime()
 }
 
 // GetNotBefore implements the Claims interface.
-func (c *JwtCustomClaims) GetNotBefore() int64 {
-	return c.NotBefore
+func (c *JwtCustomClaims) GetNotBefore() time.Time {
+	return c.RegisteredClaims.GetNotBefore()
 }
 
 // GetIssuedAt implements the Claims interface.
-func (c *JwtCustomClaims) GetIssuedAt() int64 {
-	return c.IssuedAt
+func (c *JwtCustomClaims) GetIssuedAt() time.Time {
+	return c.RegisteredClaims.GetIssuedAt()
 }
 
 // GetAudience implements the Claims interface.
@@ -102,7 +101,7 @@ func (c *JwtCustomClaims) GetSubject() string {
 
 // Type implements the Claims interface.
 func (c *JwtCustomClaims) Type() string {
-	return "PWT"
+	return jwt.Type
 }
 
 // Marshal implements the Claims interface.
@@ -114,3 +113,13 @@ func (c *JwtCustomClaims) Marshal() ([]byte, error) {
 func (c *JwtCustomClaims) Unmarshal(data []byte) error {
 	return json.Unmarshal(data, c)
 }
+
+// unixTime returns the time of the seconds since the UNIX epoch, or the zero
+// time if sec is zero.
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(sec, 0)
+}
@@ -0,0 +1,115 @@
+// This example signs tokens with keys held by a (fake, in-process) KMS, which
+// only exposes the public key and a remote signing operation.
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+// fakeKMS keeps private keys by name and never hands them out, like a cloud
+// KMS or an HSM would.
+type fakeKMS struct {
+	mu   sync.Mutex
+	keys map[string]crypto.Signer
+}
+
+// sign simulates the remote signing call.
+func (k *fakeKMS) sign(ctx context.Context, name string, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	k.mu.Lock()
+	key, ok := k.keys[name]
+	k.mu.Unlock()
+	if !ok {
+		return nil, errors.New("kms: key not found")
+	}
+
+	return key.Sign(rand.Reader, digest, opts)
+}
+
+// kmsKey is a handle to a key in the fake KMS. It implements
+// method.SignerWithContext.
+type kmsKey struct {
+	kms  *fakeKMS
+	name string
+	pub  crypto.PublicKey
+}
+
+func (k *kmsKey) Public() crypto.PublicKey {
+	return k.pub
+}
+
+func (k *kmsKey) SignWithContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return k.kms.sign(ctx, k.name, digest, opts)
+}
+
+func main() {
+	kms := &fakeKMS{keys: map[string]crypto.Signer{}}
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	kms.keys["rsa"] = rsaKey
+	kms.keys["ec"] = ecKey
+	kms.keys["ed"] = edKey
+
+	for _, tc := range []struct {
+		method method.SigningMethod
+		name   string
+	}{
+		{method.SigningMethodRS256, "rsa"},
+		{method.SigningMethodPS256, "rsa"},
+		{method.SigningMethodES256, "ec"},
+		{method.SigningMethodEdDSA, "ed"},
+	} {
+		key := &kmsKey{kms: kms, name: tc.name, pub: kms.keys[tc.name].Public()}
+
+		claims := &jwt.RegisteredClaims{
+			Issuer:    "kms",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		str, err := xwt.NewWithClaims(tc.method, claims).SignedStringWithContext(ctx, key)
+		cancel()
+		if err != nil {
+			log.Fatalf("sign %s fail, err:%v\n", tc.method.Alg(), err)
+		}
+
+		_, err = xwt.ParseWithClaims(str, &jwt.RegisteredClaims{}, func(t *xwt.Token) (interface{}, error) {
+			return xwt.Key{Material: key.Public(), Alg: tc.method.Alg()}, nil
+		})
+		if err != nil {
+			log.Fatalf("verify %s fail, err:%v\n", tc.method.Alg(), err)
+		}
+
+		log.Printf("%s signed by kms and verified\n", tc.method.Alg())
+	}
+
+	// A cancelled context aborts the remote signing operation
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	key := &kmsKey{kms: kms, name: "ec", pub: ecKey.Public()}
+	if _, err := xwt.NewWithClaims(method.SigningMethodES256, &jwt.RegisteredClaims{}).SignedStringWithContext(ctx, key); !errors.Is(err, context.Canceled) {
+		log.Fatalf("expected cancellation, err:%v\n", err)
+	}
+	log.Printf("cancelled signing aborted\n")
+}
@@ -39,6 +39,7 @@ func main() {
 	decCmd.Flags().String("cert", "c", "cert for xwt")
 	decCmd.Flags().String("alg", "c", "alg for xwt")
 	decCmd.Flags().String("xwt", "x", "data for xwt")
+	decCmd.Flags().String("roots", "", "trusted CA certificates to verify the x5c header of xwt")
 	cmd.AddCommand(&decCmd)
 
 	cmd.Execute()
@@ -75,9 +76,9 @@ func encRun(cmd *cobra.Command, args []string) {
 				Issuer:    "lkyzhu",
 				Subject:   sType,
 				Audience:  []string{"a1", "a2"},
-				ExpiresAt: time.Now().AddDate(1, 0, 0).Unix(),
-				IssuedAt:  time.Now().Unix(),
-				NotBefore: time.Now().Unix(),
+				ExpiresAt: jwt.NewNumericDate(time.Now().AddDate(1, 0, 0)),
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				NotBefore: jwt.NewNumericDate(time.Now()),
 			},
 			Name: "Custom-JWT",
 			Age:  21,
@@ -96,8 +97,6 @@ func encRun(cmd *cobra.Command, args []string) {
 			Age:  21,
 		}
 	}
-	c := &custom.CustomClaims{}
-
 	token := xwt.NewWithClaims(sMethod, claims)
 	str, err := token.SignedString(key)
 	if err != nil {
@@ -141,8 +140,27 @@ func decRun(cmd *cobra.Command, args []string) {
 		claims = &custom.CustomClaims{}
 	}
 
+	keyFunc := func(t *xwt.Token) (interface{}, error) { return key, nil }
+
+	// If trusted roots are given, the key is taken from the verified
+	// certificate chain in the header instead of the given certificate
+	if roots, _ := cmd.Flags().GetString("roots"); roots != "" {
+		data, err := os.ReadFile(roots)
+		if err != nil {
+			log.Fatalf("read roots fail, err:%s\n", err.Error())
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			log.Fatalf("load roots fail\n")
+		}
+
+		verifier := &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: pool}}
+		keyFunc = verifier.Keyfunc
+	}
+
 	xData, _ := cmd.Flags().GetString("xwt")
-	token, err := xwt.ParseWithClaims(xData, claims, func(t *xwt.Token) (interface{}, error) { return key, nil })
+	token, err := xwt.ParseWithClaims(xData, claims, keyFunc)
 	if err != nil {
 		log.Fatalf("parse token claims fail, err:%v\n", err.Error())
 	}
@@ -1,8 +1,11 @@
 module github.com/lkyzhu/xwt
 
-go 1.21.7
+go 1.22.0
 
 require (
+	github.com/cloudflare/circl v1.6.1
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.1
+	github.com/emmansun/gmsm v0.15.5
 	github.com/spf13/cobra v1.8.1
 	google.golang.org/protobuf v1.35.1
 )
@@ -10,4 +13,6 @@ require (
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/crypto v0.11.1-0.20230711161743-2e82bdd1719d // indirect
+	golang.org/x/sys v0.10.0 // indirect
 )
@@ -0,0 +1,265 @@
+package xwt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lkyzhu/xwt/internal"
+)
+
+// registeredHeaderParameters contains the names of the JOSE header parameters
+// registered in https://datatracker.ietf.org/doc/html/rfc7515#section-4.1 that
+// are represented as dedicated fields of [Header].
+var registeredHeaderParameters = []string{
+	"alg", "typ", "cty", "kid", "jku", "jwk", "x5u", "x5c", "x5t", "x5t#S256", "crit",
+}
+
+// Header is a structured version of the JOSE Header of a *WT, restricted to
+// the Registered Header Parameter Names, as referenced at
+// https://datatracker.ietf.org/doc/html/rfc7515#section-4.1
+//
+// Any other (public or private) header parameter is kept in Extra, so that it
+// survives a round trip through [Parser.ParseUnverified] and
+// [Token.SigningString].
+type Header struct {
+	// the `alg` (Algorithm) parameter. See https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.1
+	Algorithm string `json:"alg"`
+
+	// the `jku` (JWK Set URL) parameter. See https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.2
+	JWKSetURL string `json:"jku,omitempty"`
+
+	// the `jwk` (JSON Web Key) parameter. See https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.3
+	JWK map[string]interface{} `json:"jwk,omitempty"`
+
+	// the `kid` (Key ID) parameter. See https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.4
+	KeyID string `json:"kid,omitempty"`
+
+	// the `x5u` (X.509 URL) parameter. See https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.5
+	X509URL string `json:"x5u,omitempty"`
+
+	// the `x5c` (X.509 Certificate Chain) parameter. See https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.6
+	X509CertChain []string `json:"x5c,omitempty"`
+
+	// the `x5t` (X.509 Certificate SHA-1 Thumbprint) parameter. See https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.7
+	X509CertThumbprint string `json:"x5t,omitempty"`
+
+	// the `x5t#S256` (X.509 Certificate SHA-256 Thumbprint) parameter. See https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.8
+	X509CertThumbprintS256 string `json:"x5t#S256,omitempty"`
+
+	// the `typ` (Type) parameter. See https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.9
+	//
+	// In a *WT, it specifies the serialization of the payload, e.g. "JWT" or
+	// "PWT". If empty, it is populated from [Claims.Type] when signing.
+	Type string `json:"typ,omitempty"`
+
+	// the `cty` (Content Type) parameter. See https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.10
+	ContentType string `json:"cty,omitempty"`
+
+	// the `crit` (Critical) parameter. See https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.11
+	Critical []string `json:"crit,omitempty"`
+
+	// Extra contains all header parameters that are not registered ones.
+	Extra map[string]interface{} `json:"-"`
+}
+
+// registeredHeader is used to (un)marshal the registered parameters of a
+// [Header] without recursing into its custom JSON functions.
+type registeredHeader Header
+
+// Get returns the value of the header parameter with the given name. It
+// handles both registered parameters and the ones contained in Extra. The
+// second return value reports whether the parameter is present.
+func (h *Header) Get(name string) (interface{}, bool) {
+	switch name {
+	case "alg":
+		return h.Algorithm, h.Algorithm != ""
+	case "typ":
+		return h.Type, h.Type != ""
+	case "cty":
+		return h.ContentType, h.ContentType != ""
+	case "kid":
+		return h.KeyID, h.KeyID != ""
+	case "jku":
+		return h.JWKSetURL, h.JWKSetURL != ""
+	case "jwk":
+		return h.JWK, h.JWK != nil
+	case "x5u":
+		return h.X509URL, h.X509URL != ""
+	case "x5c":
+		return h.X509CertChain, h.X509CertChain != nil
+	case "x5t":
+		return h.X509CertThumbprint, h.X509CertThumbprint != ""
+	case "x5t#S256":
+		return h.X509CertThumbprintS256, h.X509CertThumbprintS256 != ""
+	case "crit":
+		return h.Critical, h.Critical != nil
+	}
+
+	v, ok := h.Extra[name]
+	return v, ok
+}
+
+// Set sets the header parameter with the given name to value. Registered
+// parameters are assigned to their dedicated field and therefore need to be
+// supplied in their Go type, i.e. string, []string (x5c, crit) or
+// map[string]interface{} (jwk). For convenience, a decoded JSON array
+// ([]interface{}) of strings is accepted in place of []string. If the value of
+// a registered parameter is of any other type, an error is returned and the
+// parameter keeps its previous value. All other parameters are stored in
+// Extra.
+func (h *Header) Set(name string, value interface{}) error {
+	var ok bool
+
+	switch name {
+	case "alg":
+		ok = setString(&h.Algorithm, value)
+	case "typ":
+		ok = setString(&h.Type, value)
+	case "cty":
+		ok = setString(&h.ContentType, value)
+	case "kid":
+		ok = setString(&h.KeyID, value)
+	case "jku":
+		ok = setString(&h.JWKSetURL, value)
+	case "jwk":
+		var jwk map[string]interface{}
+		if jwk, ok = value.(map[string]interface{}); ok {
+			h.JWK = jwk
+		}
+	case "x5u":
+		ok = setString(&h.X509URL, value)
+	case "x5c":
+		ok = setStringSlice(&h.X509CertChain, value)
+	case "x5t":
+		ok = setString(&h.X509CertThumbprint, value)
+	case "x5t#S256":
+		ok = setString(&h.X509CertThumbprintS256, value)
+	case "crit":
+		ok = setStringSlice(&h.Critical, value)
+	default:
+		if h.Extra == nil {
+			h.Extra = map[string]interface{}{}
+		}
+		h.Extra[name] = value
+		return nil
+	}
+
+	if !ok {
+		return internal.NewError(fmt.Sprintf("header parameter %s cannot be set to a value of type %T", name, value), internal.ErrInvalidType)
+	}
+
+	return nil
+}
+
+// setString assigns value to field, if it is a string.
+func setString(field *string, value interface{}) bool {
+	s, ok := value.(string)
+	if ok {
+		*field = s
+	}
+
+	return ok
+}
+
+// setStringSlice assigns value to field, if it can be converted by
+// toStringSlice.
+func setStringSlice(field *[]string, value interface{}) bool {
+	v, ok := toStringSlice(value)
+	if ok {
+		*field = v
+	}
+
+	return ok
+}
+
+// toStringSlice converts value into a []string, if it is either a []string or
+// a []interface{} only consisting of strings.
+func toStringSlice(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s)
+		}
+		return out, true
+	}
+
+	return nil, false
+}
+
+// isCritical reports whether the header parameter with the given name is
+// listed in the `crit` header parameter.
+func (h *Header) isCritical(name string) bool {
+	for _, c := range h.Critical {
+		if c == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unencodedPayload reports whether the header specifies an unencoded payload
+// using `"b64": false`, as described in
+// https://datatracker.ietf.org/doc/html/rfc7797#section-3.
+func (h *Header) unencodedPayload() bool {
+	b64, ok := h.Extra["b64"].(bool)
+	return ok && !b64
+}
+
+// MarshalJSON implements the [json.Marshaler] interface. The registered
+// parameters and the ones contained in Extra are merged into a single JSON
+// object. Registered parameters take precedence over an entry in Extra with
+// the same name.
+func (h Header) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(registeredHeader(h))
+	if err != nil || len(h.Extra) == 0 {
+		return b, err
+	}
+
+	m := make(map[string]interface{}, len(h.Extra)+len(registeredHeaderParameters))
+	for k, v := range h.Extra {
+		m[k] = v
+	}
+
+	var registered map[string]json.RawMessage
+	if err = json.Unmarshal(b, &registered); err != nil {
+		return nil, err
+	}
+	for k, v := range registered {
+		m[k] = v
+	}
+
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON implements the [json.Unmarshaler] interface. Registered
+// parameters are decoded into their dedicated fields, all others end up in
+// Extra.
+func (h *Header) UnmarshalJSON(data []byte) error {
+	var registered registeredHeader
+	if err := json.Unmarshal(data, &registered); err != nil {
+		return err
+	}
+
+	var extra map[string]interface{}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return err
+	}
+	for _, name := range registeredHeaderParameters {
+		delete(extra, name)
+	}
+	if len(extra) > 0 {
+		registered.Extra = extra
+	}
+
+	*h = Header(registered)
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package xwt_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+func TestHeaderSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"kid", "k2", "k2", false},
+		{"kid", 123, "k1", true},
+		{"crit", []string{"b64"}, []string{"b64"}, false},
+		{"crit", []interface{}{"b64"}, []string{"b64"}, false},
+		{"crit", []interface{}{"b64", 1}, []string{"exp"}, true},
+		{"crit", "b64", []string{"exp"}, true},
+		{"jwk", map[string]interface{}{"kty": "OKP"}, map[string]interface{}{"kty": "OKP"}, false},
+		{"jwk", "key", map[string]interface{}{"kty": "EC"}, true},
+		{"x5c", []interface{}{"MIIB"}, []string{"MIIB"}, false},
+		{"x5c", []interface{}{1}, []string(nil), true},
+		{"tenant", 123, 123, false},
+	}
+
+	for _, tt := range tests {
+		h := xwt.Header{
+			KeyID:    "k1",
+			Critical: []string{"exp"},
+			JWK:      map[string]interface{}{"kty": "EC"},
+		}
+
+		err := h.Set(tt.name, tt.value)
+		if tt.wantErr && !errors.Is(err, internal.ErrInvalidType) {
+			t.Errorf("Set(%s, %v) error = %v, want %v", tt.name, tt.value, err, internal.ErrInvalidType)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("Set(%s, %v) error = %v", tt.name, tt.value, err)
+		}
+
+		// A value of the wrong type keeps the previous value
+		if got, _ := h.Get(tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Get(%s) after Set(%v) = %#v, want %#v", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWithHeader(t *testing.T) {
+	token := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{}, xwt.WithHeader("kid", "k1"), xwt.WithHeader("tenant", "acme"))
+	if token.Header.KeyID != "k1" || token.Header.Extra["tenant"] != "acme" {
+		t.Fatalf("Header = %+v, want kid k1 and tenant acme", token.Header)
+	}
+
+	token = xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{}, xwt.WithHeader("kid", 123))
+	if _, err := token.SignedString(testSecret); !errors.Is(err, internal.ErrInvalidType) {
+		t.Fatalf("SignedString() error = %v, want %v", err, internal.ErrInvalidType)
+	}
+	if _, _, err := token.SignedDetached(testSecret); !errors.Is(err, internal.ErrInvalidType) {
+		t.Fatalf("SignedDetached() error = %v, want %v", err, internal.ErrInvalidType)
+	}
+	if _, err := token.SignedFlattenedJSON(testSecret); !errors.Is(err, internal.ErrInvalidType) {
+		t.Fatalf("SignedFlattenedJSON() error = %v, want %v", err, internal.ErrInvalidType)
+	}
+}
@@ -0,0 +1,123 @@
+package xwt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lkyzhu/xwt/internal"
+)
+
+// ParserHook observes the stages of parsing a token, e.g. to export metrics or
+// to write audit events. Hooks are registered with [WithParserHooks] and are
+// called synchronously, so they should return quickly.
+//
+// Every parse ends with exactly one of the following: a call to OnVerifyResult
+// with an error, if the token could not be parsed or its signature could not be
+// verified, or a call to OnValidateResult otherwise.
+type ParserHook interface {
+	// OnParseStart is called before the token is parsed.
+	OnParseStart(raw string)
+
+	// OnKeyLookup is called after the Keyfunc returned, with the duration of
+	// the lookup and its error.
+	OnKeyLookup(token *Token, duration time.Duration, err error)
+
+	// OnVerifyResult is called after the signature was verified, with the
+	// duration since the parse started. The token is nil, if it could not be
+	// parsed at all. Its claims are not trustworthy, if err is not nil.
+	OnVerifyResult(token *Token, duration time.Duration, err error)
+
+	// OnValidateResult is called after the claims of a token with a valid
+	// signature were validated, with the duration of the validation.
+	OnValidateResult(token *Token, duration time.Duration, err error)
+}
+
+// SignerHook observes the signing of tokens. Hooks are registered with
+// [WithSignerHooks] and are called synchronously, so they should return
+// quickly.
+type SignerHook interface {
+	// OnSign is called after the token was signed, with the duration of the
+	// signing and its error.
+	OnSign(token *Token, duration time.Duration, err error)
+}
+
+// reasons maps the errors of this library to the reasons returned by
+// [ErrorReason]. More specific errors come first, since claims errors are
+// wrapped in ErrTokenInvalidClaims and key errors in ErrTokenSignatureInvalid.
+var reasons = []struct {
+	err    error
+	reason string
+}{
+	{internal.ErrTokenExpired, "expired"},
+	{internal.ErrTokenNotValidYet, "not_valid_yet"},
+	{internal.ErrTokenUsedBeforeIssued, "used_before_issued"},
+	{internal.ErrTokenTooOld, "too_old"},
+	{internal.ErrTokenLifetimeTooLong, "lifetime_too_long"},
+	{internal.ErrTokenInvalidAudience, "invalid_audience"},
+	{internal.ErrTokenInvalidIssuer, "invalid_issuer"},
+	{internal.ErrTokenInvalidSubject, "invalid_subject"},
+	{internal.ErrTokenInvalidId, "invalid_id"},
+	{internal.ErrTokenInvalidConfirmation, "invalid_confirmation"},
+	{internal.ErrTokenInsufficientScope, "insufficient_scope"},
+	{internal.ErrTokenPolicyViolation, "policy_violation"},
+	{internal.ErrTokenRequiredClaimMissing, "missing_claim"},
+	{internal.ErrTokenInvalidClaims, "invalid_claims"},
+	{internal.ErrTokenSignatureInvalid, "signature_invalid"},
+	{internal.ErrTokenUnverifiable, "unverifiable"},
+	{internal.ErrTokenMalformed, "malformed"},
+}
+
+// ErrorReason returns a short, stable reason for an error returned by the
+// parser, e.g. "expired" or "signature_invalid", which is suitable as a
+// metrics label. It returns an empty string for a nil error and "other" for
+// errors not produced by this library.
+func ErrorReason(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	for _, r := range reasons {
+		if errors.Is(err, r.err) {
+			return r.reason
+		}
+	}
+
+	return "other"
+}
+
+// parseStart calls the OnParseStart hooks and returns the start of the parse.
+func (p *Parser) parseStart(raw string) time.Time {
+	for _, h := range p.hooks {
+		h.OnParseStart(raw)
+	}
+
+	return time.Now()
+}
+
+// keyLookup calls the OnKeyLookup hooks.
+func (p *Parser) keyLookup(token *Token, start time.Time, err error) {
+	for _, h := range p.hooks {
+		h.OnKeyLookup(token, time.Since(start), err)
+	}
+}
+
+// verifyResult calls the OnVerifyResult hooks.
+func (p *Parser) verifyResult(token *Token, start time.Time, err error) {
+	for _, h := range p.hooks {
+		h.OnVerifyResult(token, time.Since(start), err)
+	}
+}
+
+// validateResult calls the OnValidateResult hooks.
+func (p *Parser) validateResult(token *Token, start time.Time, err error) {
+	for _, h := range p.hooks {
+		h.OnValidateResult(token, time.Since(start), err)
+	}
+}
+
+// signed calls the OnSign hooks.
+func (t *Token) signed(start time.Time, err error) {
+	for _, h := range t.signerHooks {
+		h.OnSign(t, time.Since(start), err)
+	}
+}
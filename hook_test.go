@@ -0,0 +1,227 @@
+package xwt_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+// recorder records the calls of its hooks, prefixed by its name, in calls.
+type recorder struct {
+	name  string
+	calls *[]string
+}
+
+func (r recorder) record(stage string, token *xwt.Token, err error) {
+	call := r.name + " " + stage
+	if token == nil {
+		call += " nil"
+	}
+	if err != nil {
+		call += " " + xwt.ErrorReason(err)
+	}
+	*r.calls = append(*r.calls, call)
+}
+
+func (r recorder) OnParseStart(raw string) {
+	*r.calls = append(*r.calls, r.name+" start")
+}
+
+func (r recorder) OnKeyLookup(token *xwt.Token, duration time.Duration, err error) {
+	r.record("key", token, err)
+}
+
+func (r recorder) OnVerifyResult(token *xwt.Token, duration time.Duration, err error) {
+	r.record("verify", token, err)
+}
+
+func (r recorder) OnValidateResult(token *xwt.Token, duration time.Duration, err error) {
+	r.record("validate", token, err)
+}
+
+func (r recorder) OnSign(token *xwt.Token, duration time.Duration, err error) {
+	r.record("sign "+token.Method.Alg(), token, err)
+}
+
+func TestParserHooks(t *testing.T) {
+	valid, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"}).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyfunc := func(*xwt.Token) (interface{}, error) { return testSecret, nil }
+	failing := func(*xwt.Token) (interface{}, error) { return nil, errors.New("unknown key") }
+	other := func(*xwt.Token) (interface{}, error) { return []byte("fedcba9876543210fedcba9876543210"), nil }
+
+	tests := []struct {
+		name    string
+		token   string
+		keyfunc xwt.Keyfunc
+		opts    []xwt.ParserOption
+		want    []string
+	}{
+		{"valid", valid, keyfunc, nil, []string{
+			"a start", "b start",
+			"a key", "b key",
+			"a verify", "b verify",
+			"a validate", "b validate",
+		}},
+		{"malformed", "token", keyfunc, nil, []string{
+			"a start", "b start",
+			"a verify nil malformed", "b verify nil malformed",
+		}},
+		{"key lookup failed", valid, failing, nil, []string{
+			"a start", "b start",
+			"a key other", "b key other",
+			"a verify unverifiable", "b verify unverifiable",
+		}},
+		{"no keyfunc", valid, nil, nil, []string{
+			"a start", "b start",
+			"a verify unverifiable", "b verify unverifiable",
+		}},
+		{"invalid signature", valid, other, nil, []string{
+			"a start", "b start",
+			"a key", "b key",
+			"a verify signature_invalid", "b verify signature_invalid",
+		}},
+		{"invalid method", valid, keyfunc, []xwt.ParserOption{xwt.WithValidMethods([]string{"RS256"})}, []string{
+			"a start", "b start",
+			"a verify signature_invalid", "b verify signature_invalid",
+		}},
+		{"expired", expired, keyfunc, nil, []string{
+			"a start", "b start",
+			"a key", "b key",
+			"a verify", "b verify",
+			"a validate expired", "b validate expired",
+		}},
+		{"without claims validation", expired, keyfunc, []xwt.ParserOption{xwt.WithoutClaimsValidation()}, []string{
+			"a start", "b start",
+			"a key", "b key",
+			"a verify", "b verify",
+			"a validate", "b validate",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			opts := append([]xwt.ParserOption{
+				xwt.WithParserHooks(recorder{"a", &calls}),
+				xwt.WithParserHooks(recorder{"b", &calls}),
+			}, tt.opts...)
+
+			_, _ = xwt.NewParser(opts...).ParseWithClaims(tt.token, &jwt.MapClaims{}, tt.keyfunc)
+			if !reflect.DeepEqual(calls, tt.want) {
+				t.Fatalf("calls = %q, want %q", calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestParserHooksSerializations(t *testing.T) {
+	keyfunc := func(*xwt.Token) (interface{}, error) { return testSecret, nil }
+
+	var calls []string
+	p := xwt.NewParser(xwt.WithParserHooks(recorder{"a", &calls}))
+	want := []string{"a start", "a key", "a verify", "a validate"}
+
+	token := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"})
+	detached, payload, err := token.SignedDetached(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.ParseDetached(detached, payload, &jwt.MapClaims{}, keyfunc); err != nil {
+		t.Fatalf("ParseDetached() error = %v", err)
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls of ParseDetached() = %q, want %q", calls, want)
+	}
+
+	calls = nil
+	flattened, err := token.SignedFlattenedJSON(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.ParseJSON(flattened, &jwt.MapClaims{}, keyfunc); err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls of ParseJSON() = %q, want %q", calls, want)
+	}
+
+	calls = nil
+	want = []string{"a start", "a verify nil malformed"}
+	if _, err = p.ParseJSON("{", &jwt.MapClaims{}, keyfunc); err == nil {
+		t.Fatal("ParseJSON() of malformed token succeeded")
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls of ParseJSON() = %q, want %q", calls, want)
+	}
+}
+
+func TestSignerHooks(t *testing.T) {
+	var calls []string
+	hooks := xwt.WithSignerHooks(recorder{"a", &calls}, recorder{"b", &calls})
+
+	token := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"}, hooks)
+	if _, err := token.SignedString(testSecret); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a sign HS256", "b sign HS256"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls of SignedString() = %q, want %q", calls, want)
+	}
+
+	calls = nil
+	if _, err := token.SignedString("secret"); err == nil {
+		t.Fatal("SignedString() with invalid key succeeded")
+	}
+	if want := []string{"a sign HS256 other", "b sign HS256 other"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls of failed SignedString() = %q, want %q", calls, want)
+	}
+
+	// The hooks observe every signature of the general JSON serialization
+	calls = nil
+	_, err := token.SignedJSON(
+		xwt.JSONSigner{Method: method.SigningMethodHS256, Key: testSecret},
+		xwt.JSONSigner{Method: method.SigningMethodHS512, Key: bytes.Repeat(testSecret, 2)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a sign HS256", "b sign HS256", "a sign HS512", "b sign HS512"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls of SignedJSON() = %q, want %q", calls, want)
+	}
+}
+
+func TestErrorReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{internal.NewError("", internal.ErrTokenInvalidClaims, internal.ErrTokenExpired), "expired"},
+		{internal.NewError("", internal.ErrTokenInvalidClaims, internal.ErrTokenRequiredClaimMissing), "missing_claim"},
+		{internal.NewError("", internal.ErrTokenInvalidClaims), "invalid_claims"},
+		{internal.NewError("", internal.ErrTokenSignatureInvalid), "signature_invalid"},
+		{fmt.Errorf("wrapped: %w", internal.ErrTokenMalformed), "malformed"},
+		{errors.New("other"), "other"},
+	}
+
+	for _, tt := range tests {
+		if got := xwt.ErrorReason(tt.err); got != tt.want {
+			t.Errorf("ErrorReason(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
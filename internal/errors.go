@@ -16,11 +16,16 @@ var (
 	ErrTokenInvalidAudience      = errors.New("token has invalid audience")
 	ErrTokenExpired              = errors.New("token is expired")
 	ErrTokenUsedBeforeIssued     = errors.New("token used before issued")
+	ErrTokenTooOld               = errors.New("token is too old")
+	ErrTokenLifetimeTooLong      = errors.New("token lifetime is too long")
 	ErrTokenInvalidIssuer        = errors.New("token has invalid issuer")
 	ErrTokenInvalidSubject       = errors.New("token has invalid subject")
 	ErrTokenNotValidYet          = errors.New("token is not valid yet")
 	ErrTokenInvalidId            = errors.New("token has invalid id")
 	ErrTokenInvalidClaims        = errors.New("token has invalid claims")
+	ErrTokenInvalidConfirmation  = errors.New("token has invalid confirmation")
+	ErrTokenInsufficientScope    = errors.New("token has insufficient scope")
+	ErrTokenPolicyViolation      = errors.New("token violates policy")
 	ErrInvalidType               = errors.New("invalid type for claim")
 )
 
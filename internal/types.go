@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -39,15 +40,52 @@ func NewNumericDate(t time.Time) *NumericDate {
 	return &NumericDate{t.Truncate(TimePrecision)}
 }
 
+// NewNumericDateFromSeconds creates a new *NumericDate out of a float64
+// representing a UNIX epoch with the float fraction representing non-integer
+// seconds. Since a float64 cannot represent nanoseconds of current dates
+// exactly, the time is rounded to microseconds.
 func NewNumericDateFromSeconds(f float64) *NumericDate {
-	return newNumericDateFromSeconds(f)
+	round, frac := math.Modf(f)
+	return &NumericDate{time.Unix(int64(round), int64(frac*1e9)).Round(time.Microsecond)}
 }
 
-// newNumericDateFromSeconds creates a new *NumericDate out of a float64 representing a
-// UNIX epoch with the float fraction representing non-integer seconds.
-func newNumericDateFromSeconds(f float64) *NumericDate {
-	round, frac := math.Modf(f)
-	return NewNumericDate(time.Unix(int64(round), int64(frac*1e9)))
+// ParseNumericDate parses the decimal representation of a UNIX epoch with
+// either integer or non-integer seconds. Unlike [NewNumericDateFromSeconds], it
+// retains all fractional digits up to nanoseconds.
+func ParseNumericDate(s string) (*NumericDate, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+
+	// Fall back to a float for exponents and other unusual representations
+	if strings.ContainsAny(s, "eE+-") || len(frac) > 9 {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		return NewNumericDateFromSeconds(f), nil
+	}
+
+	sec, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var nsec int64
+	if frac != "" {
+		if nsec, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	return &NumericDate{time.Unix(sec, nsec)}, nil
+}
+
+// TimeOf returns the time of date, or the zero time if date is nil.
+func TimeOf(date *NumericDate) time.Time {
+	if date == nil {
+		return time.Time{}
+	}
+
+	return date.Time
 }
 
 // MarshalJSON is an implementation of the json.RawMessage interface and serializes the UNIX epoch
@@ -55,7 +93,7 @@ func newNumericDateFromSeconds(f float64) *NumericDate {
 func (date NumericDate) MarshalJSON() (b []byte, err error) {
 	var prec int
 	if TimePrecision < time.Second {
-		prec = int(math.Log10(float64(time.Second) / float64(TimePrecision)))
+		prec = int(math.Ceil(math.Log10(float64(time.Second) / float64(TimePrecision))))
 	}
 	truncatedDate := date.Truncate(TimePrecision)
 
@@ -70,9 +108,12 @@ func (date NumericDate) MarshalJSON() (b []byte, err error) {
 	//    decimal part of the output
 	// 3. Concatenate them to produce the final result
 	seconds := strconv.FormatInt(truncatedDate.Unix(), 10)
-	nanosecondsOffset := strconv.FormatFloat(float64(truncatedDate.Nanosecond())/float64(time.Second), 'f', prec, 64)
+	nanosecondsOffset := fmt.Sprintf("%09d", truncatedDate.Nanosecond())[:prec]
 
-	output := append([]byte(seconds), []byte(nanosecondsOffset)[1:]...)
+	output := []byte(seconds)
+	if prec > 0 {
+		output = append(append(output, '.'), nanosecondsOffset...)
+	}
 
 	return output, nil
 }
@@ -80,22 +121,19 @@ func (date NumericDate) MarshalJSON() (b []byte, err error) {
 // UnmarshalJSON is an implementation of the json.RawMessage interface and
 // deserializes a [NumericDate] from a JSON representation, i.e. a
 // [json.Number]. This number represents an UNIX epoch with either integer or
-// non-integer seconds.
+// non-integer seconds. The fractional seconds are retained regardless of
+// TimePrecision, so that sub-second expiries of other issuers are honored.
 func (date *NumericDate) UnmarshalJSON(b []byte) (err error) {
-	var (
-		number json.Number
-		f      float64
-	)
+	var number json.Number
 
 	if err = json.Unmarshal(b, &number); err != nil {
 		return fmt.Errorf("could not parse NumericData: %w", err)
 	}
 
-	if f, err = number.Float64(); err != nil {
-		return fmt.Errorf("could not convert json number value to float: %w", err)
+	n, err := ParseNumericDate(number.String())
+	if err != nil {
+		return fmt.Errorf("could not convert json number value to date: %w", err)
 	}
-
-	n := newNumericDateFromSeconds(f)
 	*date = *n
 
 	return nil
@@ -0,0 +1,68 @@
+package internal_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lkyzhu/xwt/internal"
+)
+
+func TestParseNumericDate(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    time.Time
+		wantErr bool
+	}{
+		{"1700000000", time.Unix(1700000000, 0), false},
+		{"1700000000.", time.Unix(1700000000, 0), false},
+		{"1700000000.5", time.Unix(1700000000, 500000000), false},
+		{"1700000000.25", time.Unix(1700000000, 250000000), false},
+		// Fractional digits are retained up to nanoseconds
+		{"1700000000.123456789", time.Unix(1700000000, 123456789), false},
+		{"1700000000.000000001", time.Unix(1700000000, 1), false},
+		// Other representations are parsed as floats, rounded to microseconds
+		{"1700000000.1234567891", time.Unix(1700000000, 123457000), false},
+		{"1.7e9", time.Unix(1700000000, 0), false},
+		{"1.7E+9", time.Unix(1700000000, 0), false},
+		{"-1.5", time.Unix(-1, -500000000), false},
+		{"0", time.Unix(0, 0), false},
+		{"", time.Time{}, true},
+		{"abc", time.Time{}, true},
+		{"1700000000.abc", time.Time{}, true},
+		{"1700000000.-5", time.Time{}, true},
+		{"1e", time.Time{}, true},
+		{"99999999999999999999", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := internal.ParseNumericDate(tt.s)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseNumericDate(%q) = %v, want error", tt.s, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseNumericDate(%q) error = %v", tt.s, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseNumericDate(%q) = %v, want %v", tt.s, got.Time, tt.want)
+		}
+	}
+}
+
+func TestNumericDateUnmarshalJSON(t *testing.T) {
+	var date internal.NumericDate
+	if err := json.Unmarshal([]byte("1700000000.250"), &date); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := time.Unix(1700000000, 250000000); !date.Equal(want) {
+		t.Fatalf("Unmarshal() = %v, want %v", date.Time, want)
+	}
+
+	if err := json.Unmarshal([]byte(`"tomorrow"`), &date); err == nil {
+		t.Fatal("Unmarshal() of invalid date succeeded")
+	}
+}
@@ -0,0 +1,241 @@
+package introspection
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+)
+
+var (
+	ErrInactiveToken = errors.New("token is not active")
+)
+
+const (
+	// DefaultCacheTTL is the time introspection responses are cached, if not
+	// configured otherwise in the [Client].
+	DefaultCacheTTL = time.Minute
+
+	// DefaultMaxCacheEntries is the maximum number of cached responses, if not
+	// configured otherwise in the [Client].
+	DefaultMaxCacheEntries = 10000
+)
+
+// Client verifies tokens by calling an introspection endpoint, instead of
+// verifying their signature using a [xwt.Keyfunc]. Responses are cached, so
+// that a token is only introspected once per CacheTTL.
+//
+//	c := &introspection.Client{
+//	    Endpoint:     "https://auth.example.com/introspect",
+//	    ClientID:     "orders",
+//	    ClientSecret: secret,
+//	    Validator:    xwt.NewValidator(xwt.WithAudience("https://orders.example.com")),
+//	}
+//	response, err := c.Verify(ctx, token)
+type Client struct {
+	// Endpoint is the URL of the introspection endpoint.
+	Endpoint string
+
+	// ClientID and ClientSecret are used to authenticate to the endpoint
+	// using HTTP Basic authentication. If ClientID is empty, the requests are
+	// not authenticated.
+	ClientID     string
+	ClientSecret string
+
+	// HTTPClient performs the requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// Validator optionally validates the responses of active tokens, e.g. to
+	// check audience and issuer. The expiration time is always checked.
+	Validator *xwt.Validator
+
+	// CacheTTL is the time a response is cached. Responses of active tokens
+	// are never cached beyond the expiration time of the token. If zero,
+	// DefaultCacheTTL is used; a negative value disables caching.
+	CacheTTL time.Duration
+
+	// MaxCacheEntries limits the number of cached responses. If zero,
+	// DefaultMaxCacheEntries is used.
+	MaxCacheEntries int
+
+	// TimeFunc supplies the current time. If nil, time.Now is used.
+	TimeFunc func() time.Time
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cacheEntry
+}
+
+type cacheEntry struct {
+	response *Response
+	expires  time.Time
+}
+
+// Verify introspects a token and returns the response, if the token is active
+// and passes the Validator. Otherwise, an error wrapping ErrInactiveToken or
+// the validation error is returned.
+func (c *Client) Verify(ctx context.Context, token string) (*Response, error) {
+	response, err := c.Introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Active {
+		return nil, ErrInactiveToken
+	}
+
+	// The response may have been cached until shortly before the expiration
+	if exp := response.GetExpirationTime(); !exp.IsZero() && !c.now().Before(exp) {
+		return nil, internal.NewError("", ErrInactiveToken, internal.ErrTokenExpired)
+	}
+
+	if c.Validator != nil {
+		if err = c.Validator.Validate(response); err != nil {
+			return nil, internal.NewError("", internal.ErrTokenInvalidClaims, err)
+		}
+	}
+
+	return response, nil
+}
+
+// Introspect returns the introspection response of a token, either from the
+// cache or by calling the endpoint. Unlike [Client.Verify], it does not fail
+// for inactive tokens.
+func (c *Client) Introspect(ctx context.Context, token string) (*Response, error) {
+	key := sha256.Sum256([]byte(token))
+
+	if response, ok := c.cached(key); ok {
+		return response, nil
+	}
+
+	response, err := c.introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(key, response)
+
+	return response, nil
+}
+
+// introspect calls the introspection endpoint.
+func (c *Client) introspect(ctx context.Context, token string) (*Response, error) {
+	form := url.Values{}
+	form.Set("token", token)
+	form.Set("token_type_hint", "access_token")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not introspect token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("could not introspect token: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not introspect token: %s returned %s", c.Endpoint, resp.Status)
+	}
+
+	response := &Response{}
+	if err = response.Unmarshal(body); err != nil {
+		return nil, fmt.Errorf("could not decode introspection response: %w", err)
+	}
+
+	return response, nil
+}
+
+// cached returns the cached response for a token.
+func (c *Client) cached(key [sha256.Size]byte) (*Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.cache[key]
+	if !ok {
+		return nil, false
+	}
+
+	if !c.now().Before(entry.expires) {
+		delete(c.cache, key)
+		return nil, false
+	}
+
+	return entry.response, true
+}
+
+// store caches a response, until the cache TTL passed or the token expires.
+func (c *Client) store(key [sha256.Size]byte, response *Response) {
+	ttl := c.CacheTTL
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	if ttl < 0 {
+		return
+	}
+
+	now := c.now()
+	expires := now.Add(ttl)
+	if exp := response.GetExpirationTime(); response.Active && !exp.IsZero() && exp.Before(expires) {
+		expires = exp
+	}
+
+	max := c.MaxCacheEntries
+	if max == 0 {
+		max = DefaultMaxCacheEntries
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cache == nil {
+		c.cache = map[[sha256.Size]byte]cacheEntry{}
+	}
+
+	// Remove expired entries once the cache is full. If it is still full,
+	// the response is not cached.
+	if len(c.cache) >= max {
+		for k, entry := range c.cache {
+			if !now.Before(entry.expires) {
+				delete(c.cache, k)
+			}
+		}
+		if len(c.cache) >= max {
+			return
+		}
+	}
+
+	c.cache[key] = cacheEntry{response: response, expires: expires}
+}
+
+func (c *Client) now() time.Time {
+	if c.TimeFunc != nil {
+		return c.TimeFunc()
+	}
+
+	return time.Now()
+}
@@ -0,0 +1,155 @@
+package introspection
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/jwt"
+)
+
+// Handler serves an introspection endpoint, see
+// https://datatracker.ietf.org/doc/html/rfc7662#section-2. A token is active,
+// if it is successfully parsed, verified and validated by the Parser.
+//
+//	h := &introspection.Handler{
+//	    Authorize: func(r *http.Request) bool { ... },
+//	    Parser:    xwt.NewParser(xwt.WithIssuer(issuer)),
+//	    Keyfunc:   keyFunc,
+//	    NewClaims: func() xwt.Claims { return &jwt.MapClaims{} },
+//	}
+//	http.Handle("/introspect", h)
+type Handler struct {
+	// Authorize decides whether the caller may introspect tokens, e.g. by
+	// checking client credentials or a bearer token. RFC 7662 requires the
+	// endpoint to be protected, so all requests are rejected, if it is nil.
+	// If the endpoint is protected otherwise, e.g. by a network policy, set
+	// it to a function returning true.
+	Authorize func(r *http.Request) bool
+
+	// Parser parses the tokens. If nil, xwt.NewParser() is used.
+	Parser *xwt.Parser
+
+	// Keyfunc supplies the keys to verify the tokens.
+	Keyfunc xwt.Keyfunc
+
+	// NewClaims returns an empty claims object for each token. If nil,
+	// jwt.MapClaims are used.
+	NewClaims func() xwt.Claims
+
+	// Describe optionally completes the response for an active token, e.g.
+	// by setting Scope or Username from custom claims, or marks the token as
+	// inactive, e.g. if it has been revoked.
+	Describe func(token *xwt.Token, response *Response)
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Authorize == nil || !h.Authorize(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("token") == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response := h.introspect(r.PostForm.Get("token"))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	// An inactive token is not described any further, see RFC 7662 section
+	// 2.2
+	if !response.Active {
+		w.Write([]byte(`{"active":false}`))
+		return
+	}
+
+	data, err := response.Marshal()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(data)
+}
+
+// introspect verifies a token and describes it. Any error results in an
+// inactive token, as the caller must not learn why a token is invalid.
+func (h *Handler) introspect(tokenString string) *Response {
+	parser := h.Parser
+	if parser == nil {
+		parser = xwt.NewParser()
+	}
+
+	var claims xwt.Claims
+	if h.NewClaims != nil {
+		claims = h.NewClaims()
+	} else {
+		claims = &jwt.MapClaims{}
+	}
+
+	token, err := parser.ParseWithClaims(tokenString, claims, h.Keyfunc)
+	if err != nil || !token.Valid {
+		return &Response{}
+	}
+
+	response := describe(claims)
+	if h.Describe != nil {
+		h.Describe(token, response)
+	}
+
+	return response
+}
+
+// describe creates the response for the claims of an active token. The
+// members of JSON claims are included as they are, so that `scope`,
+// `client_id` and application-specific claims are passed on.
+func describe(claims xwt.Claims) *Response {
+	response := &Response{}
+
+	if claims.Type() == jwt.Type {
+		if data, err := claims.Marshal(); err == nil {
+			response.Unmarshal(data)
+		}
+	}
+
+	response.Active = true
+	response.TokenType = "Bearer"
+	response.ExpiresAt = numericDate(claims.GetExpirationTime())
+	response.IssuedAt = numericDate(claims.GetIssuedAt())
+	response.NotBefore = numericDate(claims.GetNotBefore())
+	response.Subject = claims.GetSubject()
+	response.Audience = claims.GetAudience()
+	response.Issuer = claims.GetIssuer()
+
+	// PWT claims carry the ID in the generated protobuf getter
+	if c, ok := claims.(interface{ GetID() string }); ok {
+		response.ID = c.GetID()
+	}
+
+	if c, ok := claims.(xwt.ScopedClaims); ok && response.Scope == "" {
+		response.Scope = strings.Join(c.GetScopes(), " ")
+	}
+
+	return response
+}
+
+// numericDate returns the numeric date of t, or nil if t is the zero time.
+func numericDate(t time.Time) *jwt.NumericDate {
+	if t.IsZero() {
+		return nil
+	}
+
+	return jwt.NewNumericDate(t)
+}
@@ -0,0 +1,199 @@
+package introspection_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/introspection"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func keyfunc(*xwt.Token) (interface{}, error) {
+	return secret, nil
+}
+
+func sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token, err := xwt.NewWithClaims(method.SigningMethodHS256, &claims).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// authorize accepts the client "rs" with the secret "rs-secret".
+func authorize(r *http.Request) bool {
+	id, secret, ok := r.BasicAuth()
+	return ok && id == "rs" && secret == "rs-secret"
+}
+
+func introspect(h http.Handler, token string, setup func(r *http.Request)) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(url.Values{"token": {token}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if setup != nil {
+		setup(r)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func withCredentials(r *http.Request) {
+	r.SetBasicAuth("rs", "rs-secret")
+}
+
+func TestHandler(t *testing.T) {
+	h := &introspection.Handler{
+		Authorize: authorize,
+		Parser:    xwt.NewParser(xwt.WithIssuer("https://auth.example.com")),
+		Keyfunc:   keyfunc,
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	active := sign(t, jwt.MapClaims{
+		"iss":       "https://auth.example.com",
+		"sub":       "alice",
+		"aud":       "https://api.example.com",
+		"exp":       exp,
+		"jti":       "token-1",
+		"scope":     "orders:read",
+		"client_id": "app",
+		"tenant":    "acme",
+	})
+
+	tests := []struct {
+		name   string
+		token  string
+		active bool
+	}{
+		{"active", active, true},
+		{"expired", sign(t, jwt.MapClaims{"iss": "https://auth.example.com", "exp": time.Now().Add(-time.Hour).Unix()}), false},
+		{"other issuer", sign(t, jwt.MapClaims{"iss": "https://other.example.com"}), false},
+		{"invalid signature", active[:len(active)-2] + "AA", false},
+		{"malformed", "token", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := introspect(h, tt.token, withCredentials)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "no-store" {
+				t.Fatalf("Cache-Control = %q, want no-store", got)
+			}
+
+			if !tt.active {
+				// Inactive tokens are not described any further.
+				if body := w.Body.String(); body != `{"active":false}` {
+					t.Fatalf("body = %s, want {\"active\":false}", body)
+				}
+				return
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]interface{}{
+				"active":     true,
+				"token_type": "Bearer",
+				"sub":        "alice",
+				"iss":        "https://auth.example.com",
+				"jti":        "token-1",
+				"scope":      "orders:read",
+				"client_id":  "app",
+				"tenant":     "acme",
+				"exp":        float64(exp),
+			}
+			for name, value := range want {
+				if response[name] != value {
+					t.Errorf("%s = %v, want %v", name, response[name], value)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerRequests(t *testing.T) {
+	token := sign(t, jwt.MapClaims{"sub": "alice"})
+
+	// Without Authorize, all requests are rejected.
+	h := &introspection.Handler{Keyfunc: keyfunc}
+	if w := introspect(h, token, withCredentials); w.Code != http.StatusUnauthorized {
+		t.Fatalf("status without Authorize = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	h.Authorize = authorize
+	if w := introspect(h, token, nil); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("status without credentials = %d, want %d with challenge", w.Code, http.StatusUnauthorized)
+	}
+	if w := introspect(h, token, func(r *http.Request) { r.SetBasicAuth("rs", "wrong") }); w.Code != http.StatusUnauthorized {
+		t.Fatalf("status with wrong credentials = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if w := introspect(h, "", withCredentials); w.Code != http.StatusBadRequest {
+		t.Fatalf("status without token = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/introspect?token="+token, nil)
+	withCredentials(r)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != http.MethodPost {
+		t.Fatalf("status of GET = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+
+	// Describe can mark a token as inactive, e.g. if it has been revoked.
+	h.Describe = func(token *xwt.Token, response *introspection.Response) {
+		response.Active = false
+	}
+	if w := introspect(h, token, withCredentials); w.Body.String() != `{"active":false}` {
+		t.Fatalf("body of revoked token = %s, want {\"active\":false}", w.Body.String())
+	}
+}
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(&introspection.Handler{Authorize: authorize, Keyfunc: keyfunc})
+	defer server.Close()
+
+	c := &introspection.Client{
+		Endpoint:     server.URL,
+		ClientID:     "rs",
+		ClientSecret: "rs-secret",
+		Validator:    xwt.NewValidator(xwt.WithAudience("https://api.example.com")),
+	}
+	ctx := context.Background()
+
+	response, err := c.Verify(ctx, sign(t, jwt.MapClaims{"sub": "alice", "aud": "https://api.example.com", "exp": time.Now().Add(time.Hour).Unix()}))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if response.Subject != "alice" {
+		t.Fatalf("Verify() sub = %s, want alice", response.Subject)
+	}
+
+	if _, err := c.Verify(ctx, "token"); !errors.Is(err, introspection.ErrInactiveToken) {
+		t.Fatalf("Verify() of invalid token error = %v, want %v", err, introspection.ErrInactiveToken)
+	}
+	if _, err := c.Verify(ctx, sign(t, jwt.MapClaims{"sub": "alice", "aud": "https://other.example.com"})); err == nil {
+		t.Fatal("Verify() with other audience succeeded")
+	}
+
+	// Unauthenticated clients are rejected by the endpoint.
+	c = &introspection.Client{Endpoint: server.URL}
+	if _, err := c.Verify(ctx, sign(t, jwt.MapClaims{"sub": "alice"})); err == nil {
+		t.Fatal("Verify() without credentials succeeded")
+	}
+}
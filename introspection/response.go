@@ -0,0 +1,137 @@
+// Package introspection implements OAuth 2.0 Token Introspection, as specified
+// in https://datatracker.ietf.org/doc/html/rfc7662.
+//
+// The [Handler] serves an introspection endpoint, which verifies tokens using a
+// [xwt.Parser] and describes them to the caller. The [Client] is used by
+// services that cannot hold the verification keys: it delegates the
+// verification of a token to an introspection endpoint and caches the result.
+package introspection
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+)
+
+// TokenType is the media type of introspection responses in JWT format, see
+// https://datatracker.ietf.org/doc/html/rfc9701. It is returned by
+// [Response.Type].
+const TokenType = "token-introspection+jwt"
+
+// Response is an introspection response, see
+// https://datatracker.ietf.org/doc/html/rfc7662#section-2.2. An inactive token
+// is only described by Active being false.
+//
+// Response implements the [xwt.Claims] interface, so that it can be validated
+// using a [xwt.Validator].
+type Response struct {
+	Active    bool             `json:"active"`
+	Scope     string           `json:"scope,omitempty"`
+	ClientID  string           `json:"client_id,omitempty"`
+	Username  string           `json:"username,omitempty"`
+	TokenType string           `json:"token_type,omitempty"`
+	ExpiresAt *jwt.NumericDate `json:"exp,omitempty"`
+	IssuedAt  *jwt.NumericDate `json:"iat,omitempty"`
+	NotBefore *jwt.NumericDate `json:"nbf,omitempty"`
+	Subject   string           `json:"sub,omitempty"`
+	Audience  audience         `json:"aud,omitempty"`
+	Issuer    string           `json:"iss,omitempty"`
+	ID        string           `json:"jti,omitempty"`
+
+	// Extra contains all members of the response, including the ones above,
+	// e.g. to retrieve application-specific claims of the token.
+	Extra map[string]interface{} `json:"-"`
+}
+
+// GetExpirationTime implements the Claims interface.
+func (r *Response) GetExpirationTime() time.Time {
+	return internal.TimeOf(r.ExpiresAt)
+}
+
+// GetNotBefore implements the Claims interface.
+func (r *Response) GetNotBefore() time.Time {
+	return internal.TimeOf(r.NotBefore)
+}
+
+// GetIssuedAt implements the Claims interface.
+func (r *Response) GetIssuedAt() time.Time {
+	return internal.TimeOf(r.IssuedAt)
+}
+
+// GetAudience implements the Claims interface.
+func (r *Response) GetAudience() []string {
+	return r.Audience
+}
+
+// GetIssuer implements the Claims interface.
+func (r *Response) GetIssuer() string {
+	return r.Issuer
+}
+
+// GetSubject implements the Claims interface.
+func (r *Response) GetSubject() string {
+	return r.Subject
+}
+
+// Type implements the Claims interface.
+func (r *Response) Type() string {
+	return TokenType
+}
+
+// Marshal implements the Claims interface. Members of Extra are included,
+// unless they are overridden by the fields of the response.
+func (r *Response) Marshal() ([]byte, error) {
+	type plain Response
+
+	data, err := json.Marshal((*plain)(r))
+	if err != nil || len(r.Extra) == 0 {
+		return data, err
+	}
+
+	members := make(map[string]interface{}, len(r.Extra))
+	for k, v := range r.Extra {
+		members[k] = v
+	}
+	if err = json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(members)
+}
+
+// Unmarshal implements the Claims interface.
+func (r *Response) Unmarshal(data []byte) error {
+	type plain Response
+
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &r.Extra)
+}
+
+// audience is the value of the `aud` member, which is either a string or an
+// array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+
+	var v []string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*a = v
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package xwt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AudienceMatch specifies how the audiences configured with [WithAudiences]
+// are matched against the `aud` claim.
+type AudienceMatch int
+
+const (
+	// AudienceMatchAny requires at least one of the audiences.
+	AudienceMatchAny AudienceMatch = iota
+
+	// AudienceMatchAll requires every audience.
+	AudienceMatchAll
+)
+
+// audienceGroup contains the audiences of a single [WithAudiences] option.
+type audienceGroup struct {
+	match AudienceMatch
+	auds  []string
+}
+
+// placeholderRegexp matches the placeholders of an issuer pattern.
+var placeholderRegexp = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// placeholderValue is the expression matching the value of a placeholder: the
+// unreserved characters of RFC 3986, so that values never contain slashes,
+// percent-encoded characters or the delimiters of queries and fragments.
+const placeholderValue = `([A-Za-z0-9._~-]+)`
+
+// IssuerPattern matches the `iss` claim of tokens from multi-tenant issuers,
+// e.g. https://login.example.com/{tenant}/v2, where each tenant has its own
+// issuer. A placeholder in braces matches a non-empty path segment, or a part
+// of it, consisting of the unreserved characters of RFC 3986, i.e. letters,
+// digits, "-", ".", "_" and "~". The segments "." and ".." are never matched.
+// The [ParseIssuerPattern] function should be used to create an instance of
+// this struct.
+type IssuerPattern struct {
+	pattern string
+	re      *regexp.Regexp
+	names   []string
+}
+
+// ParseIssuerPattern parses an issuer pattern. Placeholder names must be
+// unique, non-empty and consist of letters, digits and underscores.
+func ParseIssuerPattern(pattern string) (*IssuerPattern, error) {
+	p := &IssuerPattern{pattern: pattern}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	last := 0
+	for _, m := range placeholderRegexp.FindAllStringSubmatchIndex(pattern, -1) {
+		literal := pattern[last:m[0]]
+		if strings.ContainsAny(literal, "{}") {
+			return nil, fmt.Errorf("issuer pattern %s contains unbalanced braces", pattern)
+		}
+		expr.WriteString(regexp.QuoteMeta(literal))
+
+		name := pattern[m[2]:m[3]]
+		if !isPlaceholderName(name) {
+			return nil, fmt.Errorf("issuer pattern %s contains invalid placeholder {%s}", pattern, name)
+		}
+		for _, n := range p.names {
+			if n == name {
+				return nil, fmt.Errorf("issuer pattern %s contains placeholder {%s} twice", pattern, name)
+			}
+		}
+		p.names = append(p.names, name)
+		expr.WriteString(placeholderValue)
+
+		last = m[1]
+	}
+
+	if strings.ContainsAny(pattern[last:], "{}") {
+		return nil, fmt.Errorf("issuer pattern %s contains unbalanced braces", pattern)
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]))
+	expr.WriteString("$")
+
+	p.re = regexp.MustCompile(expr.String())
+
+	return p, nil
+}
+
+// Match reports whether the issuer matches the pattern, and returns the values
+// of the placeholders, e.g. {"tenant": "contoso"}.
+func (p *IssuerPattern) Match(issuer string) (map[string]string, bool) {
+	m := p.re.FindStringSubmatch(issuer)
+	if m == nil {
+		return nil, false
+	}
+
+	params := make(map[string]string, len(p.names))
+	for i, name := range p.names {
+		// Dot segments could traverse paths if the values are used to build
+		// URLs
+		if m[i+1] == "." || m[i+1] == ".." {
+			return nil, false
+		}
+		params[name] = m[i+1]
+	}
+
+	return params, true
+}
+
+// String returns the pattern.
+func (p *IssuerPattern) String() string {
+	return p.pattern
+}
+
+func isPlaceholderName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		if r != '_' && !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && !('0' <= r && r <= '9') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,157 @@
+package xwt_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+func TestParseIssuerPattern(t *testing.T) {
+	tests := []string{
+		"https://login.example.com/{tenant",
+		"https://login.example.com/tenant}/v2",
+		"https://login.example.com/{}/v2",
+		"https://login.example.com/{ten-ant}/v2",
+		"https://login.example.com/{tenant}/{tenant}",
+		"https://login.example.com/{{tenant}}",
+	}
+
+	for _, pattern := range tests {
+		if _, err := xwt.ParseIssuerPattern(pattern); err == nil {
+			t.Errorf("ParseIssuerPattern(%s) succeeded", pattern)
+		}
+	}
+}
+
+func TestIssuerPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		issuer  string
+		want    map[string]string
+	}{
+		{"https://login.example.com/{tenant}/v2", "https://login.example.com/contoso/v2", map[string]string{"tenant": "contoso"}},
+		{"https://login.example.com/{tenant}/v2", "https://login.example.com/a-b.c_d~e/v2", map[string]string{"tenant": "a-b.c_d~e"}},
+		{"https://login.example.com/{tenant}/v2", "https://login.example.com/contoso/v2/", nil},
+		{"https://login.example.com/{tenant}/v2", "https://login.example.com//v2", nil},
+		{"https://login.example.com/{tenant}/v2", "https://login.example.com/a/b/v2", nil},
+		{"https://login.example.com/{tenant}/v2", "https://login.example.com/a%2Fb/v2", nil},
+		{"https://login.example.com/{tenant}/v2", "https://login.example.com/a?b/v2", nil},
+		{"https://login.example.com/{tenant}/v2", "https://login.example.com/a#b/v2", nil},
+		{"https://login.example.com/{tenant}/v2", "https://login.example.com/a@b/v2", nil},
+		{"https://login.example.com/{tenant}/v2", "https://login.example.com/../v2", nil},
+		{"https://login.example.com/{tenant}/v2", "https://login.example.com/./v2", nil},
+		{"https://login.example.com/{tenant}/v2", "https://login.example.com/contoso/v2?x", nil},
+		{"https://login.example.com/{tenant}/v2", "xhttps://login.example.com/contoso/v2", nil},
+		// Meta characters of regular expressions are literals
+		{"https://login.example.com/{tenant}/v2", "https://loginxexample.com/contoso/v2", nil},
+		{"https://{tenant}.example.com/{region}", "https://contoso.example.com/eu", map[string]string{"tenant": "contoso", "region": "eu"}},
+		{"https://login.example.com/tenant-{id}", "https://login.example.com/tenant-42", map[string]string{"id": "42"}},
+		{"https://login.example.com", "https://login.example.com", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		pattern, err := xwt.ParseIssuerPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("ParseIssuerPattern(%s) error = %v", tt.pattern, err)
+		}
+
+		got, ok := pattern.Match(tt.issuer)
+		if ok != (tt.want != nil) || (ok && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("Match(%s) of %s = %v, %v, want %v", tt.issuer, tt.pattern, got, ok, tt.want)
+		}
+	}
+}
+
+func TestIssuerValidation(t *testing.T) {
+	pattern, err := xwt.ParseIssuerPattern("https://login.example.com/{tenant}/v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := xwt.NewParser(
+		xwt.WithIssuer("https://auth.example.com"),
+		xwt.WithIssuers("https://auth.example.org"),
+		xwt.WithIssuerPatterns(pattern),
+	)
+
+	tests := []struct {
+		issuer string
+		want   map[string]string
+		ok     bool
+	}{
+		{"https://auth.example.com", nil, true},
+		{"https://auth.example.org", nil, true},
+		{"https://login.example.com/contoso/v2", map[string]string{"tenant": "contoso"}, true},
+		{"https://login.example.com/a/b/v2", nil, false},
+		{"https://other.example.com", nil, false},
+		{"", nil, false},
+	}
+
+	for _, tt := range tests {
+		s, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"iss": tt.issuer}).SignedString(testSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := p.ParseWithClaims(s, &jwt.MapClaims{}, func(*xwt.Token) (interface{}, error) { return testSecret, nil })
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("ParseWithClaims() of %q succeeded", tt.issuer)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseWithClaims() of %q error = %v", tt.issuer, err)
+			continue
+		}
+		if !reflect.DeepEqual(token.IssuerParams, tt.want) {
+			t.Errorf("IssuerParams of %q = %v, want %v", tt.issuer, token.IssuerParams, tt.want)
+		}
+	}
+}
+
+func TestAudiencesValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		aud     interface{}
+		opts    []xwt.ParserOption
+		wantErr error
+	}{
+		{"any first", "api", []xwt.ParserOption{xwt.WithAudiences(xwt.AudienceMatchAny, "api", "web")}, nil},
+		{"any last", []string{"other", "web"}, []xwt.ParserOption{xwt.WithAudiences(xwt.AudienceMatchAny, "api", "web")}, nil},
+		{"any missing", []string{"other"}, []xwt.ParserOption{xwt.WithAudiences(xwt.AudienceMatchAny, "api", "web")}, internal.ErrTokenInvalidAudience},
+		{"all", []string{"web", "api", "other"}, []xwt.ParserOption{xwt.WithAudiences(xwt.AudienceMatchAll, "api", "web")}, nil},
+		{"all first missing", []string{"web"}, []xwt.ParserOption{xwt.WithAudiences(xwt.AudienceMatchAll, "api", "web")}, internal.ErrTokenInvalidAudience},
+		{"all last missing", "api", []xwt.ParserOption{xwt.WithAudiences(xwt.AudienceMatchAll, "api", "web")}, internal.ErrTokenInvalidAudience},
+		{"no audience", nil, []xwt.ParserOption{xwt.WithAudiences(xwt.AudienceMatchAny, "api")}, internal.ErrTokenRequiredClaimMissing},
+		{"empty audience", "", []xwt.ParserOption{xwt.WithAudiences(xwt.AudienceMatchAll, "api")}, internal.ErrTokenRequiredClaimMissing},
+		// Each option is matched on its own
+		{"all and any", []string{"api", "mobile"}, []xwt.ParserOption{xwt.WithAudiences(xwt.AudienceMatchAll, "api"), xwt.WithAudiences(xwt.AudienceMatchAny, "web", "mobile")}, nil},
+		{"all and any missing", []string{"api"}, []xwt.ParserOption{xwt.WithAudiences(xwt.AudienceMatchAll, "api"), xwt.WithAudiences(xwt.AudienceMatchAny, "web", "mobile")}, internal.ErrTokenInvalidAudience},
+		{"any and all", []string{"mobile"}, []xwt.ParserOption{xwt.WithAudiences(xwt.AudienceMatchAny, "web", "mobile"), xwt.WithAudiences(xwt.AudienceMatchAll, "api")}, internal.ErrTokenInvalidAudience},
+		{"with audience", []string{"web", "api"}, []xwt.ParserOption{xwt.WithAudience("api"), xwt.WithAudiences(xwt.AudienceMatchAny, "web")}, nil},
+		{"with audience missing", []string{"web"}, []xwt.ParserOption{xwt.WithAudience("api"), xwt.WithAudiences(xwt.AudienceMatchAny, "web")}, internal.ErrTokenInvalidAudience},
+		{"no audiences", "api", []xwt.ParserOption{xwt.WithAudiences(xwt.AudienceMatchAll)}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := jwt.MapClaims{}
+			if tt.aud != nil {
+				claims["aud"] = tt.aud
+			}
+
+			err := xwt.NewValidator(tt.opts...).Validate(&claims)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
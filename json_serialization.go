@@ -0,0 +1,343 @@
+package xwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/method"
+)
+
+// SignaturePolicy specifies which signatures of a token in JWS JSON
+// serialization need to be valid, for the token to be considered valid.
+type SignaturePolicy int
+
+const (
+	// SignaturePolicyAny requires at least one valid signature. This is the
+	// default.
+	SignaturePolicyAny SignaturePolicy = iota
+
+	// SignaturePolicyAll requires every signature to be valid.
+	SignaturePolicyAll
+)
+
+// JSONSigner describes one of the signatures that are created by
+// [Token.SignedJSON].
+type JSONSigner struct {
+	// Method is the signing method used for this signature
+	Method method.SigningMethod
+
+	// Key is the key used for this signature
+	Key interface{}
+
+	// Options are applied to the protected header of this signature, e.g.
+	// [WithKeyID]
+	Options []TokenOption
+
+	// Unprotected contains header parameters that are not integrity protected
+	// by this signature. It must not contain any parameter of the protected
+	// header.
+	Unprotected map[string]interface{}
+}
+
+// JSONSignature represents a single signature of a token in JWS JSON
+// serialization. It is populated when you [Parser.ParseJSON] a token.
+type JSONSignature struct {
+	Header    Header               // Header is the union of the protected and unprotected header of this signature
+	Method    method.SigningMethod // Method is the signing method specified in the protected header
+	Signature []byte               // Signature is the decoded signature
+	Valid     bool                 // Valid specifies if the signature could be verified
+}
+
+// jsonSignature is the JSON representation of a single signature, as
+// described in https://datatracker.ietf.org/doc/html/rfc7515#section-7.2.1.
+type jsonSignature struct {
+	Protected string                 `json:"protected,omitempty"`
+	Header    map[string]interface{} `json:"header,omitempty"`
+	Signature string                 `json:"signature"`
+}
+
+// jsonGeneral is the general JWS JSON serialization, as described in
+// https://datatracker.ietf.org/doc/html/rfc7515#section-7.2.1.
+type jsonGeneral struct {
+	Payload    string          `json:"payload"`
+	Signatures []jsonSignature `json:"signatures"`
+}
+
+// jsonFlattened is the flattened JWS JSON serialization, as described in
+// https://datatracker.ietf.org/doc/html/rfc7515#section-7.2.2.
+type jsonFlattened struct {
+	Payload string `json:"payload"`
+	jsonSignature
+}
+
+// jsonAny is used to decode both the general and the flattened JWS JSON
+// serialization.
+type jsonAny struct {
+	Payload    *string         `json:"payload"`
+	Signatures []jsonSignature `json:"signatures"`
+	jsonSignature
+}
+
+// SignedJSON creates and returns the token in general JWS JSON serialization,
+// as described in https://datatracker.ietf.org/doc/html/rfc7515#section-7.2.1.
+// The payload is signed once per signer, each having its own signing method,
+// key and protected header. This allows a single token to be verified by
+// parties supporting different algorithms. The Method and Header of the token
+// itself are not used.
+func (t *Token) SignedJSON(signers ...JSONSigner) (string, error) {
+	if len(signers) == 0 {
+		return "", errors.New("at least one signer is required")
+	}
+	if t.Claims == nil {
+		return "", errors.New("claims is nil")
+	}
+
+	payload, err := t.Claims.Marshal()
+	if err != nil {
+		return "", err
+	}
+
+	out := jsonGeneral{
+		Payload:    t.EncodeSegment(payload),
+		Signatures: make([]jsonSignature, 0, len(signers)),
+	}
+
+	for _, signer := range signers {
+		if signer.Method == nil {
+			return "", errors.New("signer is missing a signing method")
+		}
+
+		// The hooks of the token observe the signatures of all signers
+		st := NewWithClaims(signer.Method, t.Claims, signer.Options...)
+		st.signerHooks = append(st.signerHooks, t.signerHooks...)
+		sig, err := st.signJSON(out.Payload, signer.Key, signer.Unprotected)
+		if err != nil {
+			return "", err
+		}
+
+		out.Signatures = append(out.Signatures, sig)
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// SignedFlattenedJSON creates and returns the token in flattened JWS JSON
+// serialization, as described in
+// https://datatracker.ietf.org/doc/html/rfc7515#section-7.2.2, using the
+// SigningMethod and Header of the token.
+func (t *Token) SignedFlattenedJSON(key interface{}) (string, error) {
+	if t.Claims == nil {
+		return "", errors.New("claims is nil")
+	}
+
+	payload, err := t.Claims.Marshal()
+	if err != nil {
+		return "", err
+	}
+
+	out := jsonFlattened{
+		Payload: t.EncodeSegment(payload),
+	}
+	if out.jsonSignature, err = t.signJSON(out.Payload, key, nil); err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// signJSON signs the encoded payload using the method and header of the token
+// and returns the resulting signature in its JSON representation.
+func (t *Token) signJSON(payload string, key interface{}, unprotected map[string]interface{}) (jsonSignature, error) {
+	start := time.Now()
+
+	sig, err := t.signJSONWithKey(payload, key, unprotected)
+	t.signed(start, err)
+
+	return sig, err
+}
+
+// signJSONWithKey implements signJSON without calling the signer hooks.
+func (t *Token) signJSONWithKey(payload string, key interface{}, unprotected map[string]interface{}) (jsonSignature, error) {
+	if t.err != nil {
+		return jsonSignature{}, t.err
+	}
+
+	if t.Header.Type == "" {
+		t.Header.Type = t.Claims.Type()
+	}
+
+	if t.Header.unencodedPayload() {
+		return jsonSignature{}, errors.New("unencoded payload is not supported in JWS JSON serialization")
+	}
+
+	if err := t.deriveKeyID(key); err != nil {
+		return jsonSignature{}, err
+	}
+
+	for name := range unprotected {
+		if _, ok := t.Header.Get(name); ok {
+			return jsonSignature{}, fmt.Errorf("header parameter %s is both protected and unprotected", name)
+		}
+	}
+
+	h, err := json.Marshal(t.Header)
+	if err != nil {
+		return jsonSignature{}, err
+	}
+
+	protected := t.EncodeSegment(h)
+	sig, err := t.Method.Sign(protected+"."+payload, key)
+	if err != nil {
+		return jsonSignature{}, err
+	}
+
+	return jsonSignature{
+		Protected: protected,
+		Header:    unprotected,
+		Signature: t.EncodeSegment(sig),
+	}, nil
+}
+
+// ParseJSON parses, validates, and verifies a token in general or flattened
+// JWS JSON serialization, as described in
+// https://datatracker.ietf.org/doc/html/rfc7515#section-7.2.
+//
+// keyFunc is called once per signature, with a token containing the header of
+// that signature. Whether the token is valid depends on the [SignaturePolicy]
+// configured with [WithSignaturePolicy]. The returned token contains the header
+// of the first valid signature; all signatures are available in
+// Token.Signatures.
+//
+// Note: The `alg` header parameter must be part of the protected header.
+func (p *Parser) ParseJSON(data string, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	start := p.parseStart(data)
+
+	token, err := p.verifyJSON(data, claims, keyFunc)
+	p.verifyResult(token, start, err)
+	if err != nil {
+		return token, err
+	}
+
+	return p.validate(token, claims, nil)
+}
+
+// verifyJSON parses a token in JWS JSON serialization and verifies its
+// signatures according to the signature policy. It returns the token with the
+// header of the first valid signature.
+func (p *Parser) verifyJSON(data string, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	var in jsonAny
+	if err := json.Unmarshal([]byte(data), &in); err != nil {
+		return nil, internal.NewError("could not JSON decode token", internal.ErrTokenMalformed, err)
+	}
+
+	if in.Payload == nil {
+		return nil, internal.NewError("token is missing the payload", internal.ErrTokenMalformed)
+	}
+
+	// Without a signatures member, the token is in flattened serialization
+	signatures := in.Signatures
+	if signatures == nil {
+		if in.Signature == "" {
+			return nil, internal.NewError("token does not contain any signature", internal.ErrTokenMalformed)
+		}
+		signatures = []jsonSignature{in.jsonSignature}
+	} else if in.Signature != "" || in.Protected != "" || in.Header != nil {
+		return nil, internal.NewError("token mixes general and flattened serialization", internal.ErrTokenMalformed)
+	} else if len(signatures) == 0 {
+		return nil, internal.NewError("token does not contain any signature", internal.ErrTokenMalformed)
+	}
+
+	claimBytes, err := p.DecodeSegment(*in.Payload)
+	if err != nil {
+		return nil, internal.NewError("could not base64 decode claim", internal.ErrTokenMalformed, err)
+	}
+
+	// The claims are shared by all signatures, so they are available to
+	// keyFunc just like when parsing the compact serialization
+	if err = p.parseClaims(&Token{}, claims, claimBytes); err != nil {
+		return nil, err
+	}
+
+	var (
+		verified *Token
+		results  = make([]JSONSignature, 0, len(signatures))
+		errs     []error
+	)
+
+	for _, sig := range signatures {
+		token := &Token{Raw: data, Claims: claims}
+
+		err = p.parseJSONHeader(token, sig)
+		if err == nil {
+			err = p.verifySignature(token, []string{sig.Protected, *in.Payload, sig.Signature}, keyFunc)
+		}
+
+		results = append(results, JSONSignature{
+			Header:    token.Header,
+			Method:    token.Method,
+			Signature: token.Signature,
+			Valid:     err == nil,
+		})
+
+		if err != nil {
+			if p.signaturePolicy == SignaturePolicyAll {
+				return token, err
+			}
+			errs = append(errs, err)
+		} else if verified == nil {
+			verified = token
+		}
+	}
+
+	if verified == nil {
+		return nil, internal.NewError("no signature could be verified", internal.ErrTokenSignatureInvalid, internal.JoinErrors(errs...))
+	}
+
+	verified.Signatures = results
+
+	return verified, nil
+}
+
+// parseJSONHeader decodes the protected header of a signature into token and
+// merges the unprotected header into it.
+func (p *Parser) parseJSONHeader(token *Token, sig jsonSignature) error {
+	if sig.Protected == "" {
+		return internal.NewError("signature is missing the protected header", internal.ErrTokenMalformed)
+	}
+
+	if err := p.parseHeader(token, sig.Protected); err != nil {
+		return err
+	}
+
+	if token.Header.unencodedPayload() {
+		return internal.NewError("unencoded payload is not supported in JWS JSON serialization", internal.ErrTokenUnverifiable)
+	}
+
+	for name, value := range sig.Header {
+		if name == "crit" {
+			return internal.NewError("crit header parameter must be protected", internal.ErrTokenMalformed)
+		}
+		if _, ok := token.Header.Get(name); ok {
+			return internal.NewError(fmt.Sprintf("header parameter %s is both protected and unprotected", name), internal.ErrTokenMalformed)
+		}
+
+		if err := token.Header.Set(name, value); err != nil {
+			return internal.NewError("", internal.ErrTokenMalformed, err)
+		}
+	}
+
+	return nil
+}
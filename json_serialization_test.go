@@ -0,0 +1,192 @@
+package xwt_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+// modifyJSON decodes a token in JWS JSON serialization, applies f and encodes
+// it again.
+func modifyJSON(t *testing.T, token string, f func(m map[string]interface{})) string {
+	t.Helper()
+
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(token), &m); err != nil {
+		t.Fatal(err)
+	}
+	f(m)
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func TestParseJSON(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyfunc := func(token *xwt.Token) (interface{}, error) {
+		switch token.Header.KeyID {
+		case "rsa":
+			return &rsaKey.PublicKey, nil
+		case "ed":
+			return edPub, nil
+		}
+		return nil, errors.New("unknown key")
+	}
+
+	general, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"}).SignedJSON(
+		xwt.JSONSigner{Method: method.SigningMethodRS256, Key: rsaKey, Options: []xwt.TokenOption{xwt.WithKeyID("rsa")}},
+		xwt.JSONSigner{Method: method.SigningMethodEdDSA, Key: edKey, Options: []xwt.TokenOption{xwt.WithKeyID("ed")}, Unprotected: map[string]interface{}{"tenant": "acme"}},
+	)
+	if err != nil {
+		t.Fatalf("SignedJSON() error = %v", err)
+	}
+
+	// The EdDSA signature is replaced by the one of another payload
+	other, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "bob"}).SignedJSON(
+		xwt.JSONSigner{Method: method.SigningMethodEdDSA, Key: edKey, Options: []xwt.TokenOption{xwt.WithKeyID("ed")}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var otherSig string
+	modifyJSON(t, other, func(m map[string]interface{}) {
+		otherSig = m["signatures"].([]interface{})[0].(map[string]interface{})["signature"].(string)
+	})
+	badEdDSA := modifyJSON(t, general, func(m map[string]interface{}) {
+		m["signatures"].([]interface{})[1].(map[string]interface{})["signature"] = otherSig
+	})
+	badBoth := modifyJSON(t, badEdDSA, func(m map[string]interface{}) {
+		m["signatures"].([]interface{})[0].(map[string]interface{})["signature"] = otherSig
+	})
+
+	tests := []struct {
+		name      string
+		token     string
+		policy    xwt.SignaturePolicy
+		wantValid []bool
+		wantErr   error
+	}{
+		{"any", general, xwt.SignaturePolicyAny, []bool{true, true}, nil},
+		{"all", general, xwt.SignaturePolicyAll, []bool{true, true}, nil},
+		{"any with bad signature", badEdDSA, xwt.SignaturePolicyAny, []bool{true, false}, nil},
+		{"all with bad signature", badEdDSA, xwt.SignaturePolicyAll, nil, internal.ErrTokenSignatureInvalid},
+		{"any with bad signatures", badBoth, xwt.SignaturePolicyAny, nil, internal.ErrTokenSignatureInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := jwt.MapClaims{}
+			token, err := xwt.NewParser(xwt.WithSignaturePolicy(tt.policy)).ParseJSON(tt.token, &claims, keyfunc)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseJSON() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseJSON() error = %v", err)
+			}
+
+			if !token.Valid || claims["sub"] != "alice" || token.Header.KeyID != "rsa" {
+				t.Fatalf("ParseJSON() = %+v, claims %v", token.Header, claims)
+			}
+			if len(token.Signatures) != len(tt.wantValid) {
+				t.Fatalf("ParseJSON() signatures = %d, want %d", len(token.Signatures), len(tt.wantValid))
+			}
+			for i, sig := range token.Signatures {
+				if sig.Valid != tt.wantValid[i] {
+					t.Errorf("signature %d valid = %v, want %v", i, sig.Valid, tt.wantValid[i])
+				}
+			}
+			if got := token.Signatures[1]; got.Method.Alg() != "EdDSA" || got.Header.Extra["tenant"] != "acme" {
+				t.Errorf("signature 1 = %s %+v, want EdDSA with unprotected tenant", got.Method.Alg(), got.Header)
+			}
+		})
+	}
+}
+
+func TestParseJSONMalformed(t *testing.T) {
+	flattened, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"}).SignedFlattenedJSON(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	general, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"}).SignedJSON(
+		xwt.JSONSigner{Method: method.SigningMethodHS256, Key: testSecret},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = xwt.NewParser().ParseJSON(flattened, &jwt.MapClaims{}, secretKeyfunc); err != nil {
+		t.Fatalf("ParseJSON() of flattened token error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"mixed", modifyJSON(t, general, func(m map[string]interface{}) {
+			m["signature"] = m["signatures"].([]interface{})[0].(map[string]interface{})["signature"]
+		}), internal.ErrTokenMalformed},
+		{"mixed header", modifyJSON(t, general, func(m map[string]interface{}) {
+			m["header"] = map[string]interface{}{"kid": "k1"}
+		}), internal.ErrTokenMalformed},
+		{"no signatures", modifyJSON(t, general, func(m map[string]interface{}) {
+			m["signatures"] = []interface{}{}
+		}), internal.ErrTokenMalformed},
+		{"no payload", modifyJSON(t, flattened, func(m map[string]interface{}) {
+			delete(m, "payload")
+		}), internal.ErrTokenMalformed},
+		{"no protected header", modifyJSON(t, flattened, func(m map[string]interface{}) {
+			delete(m, "protected")
+		}), internal.ErrTokenMalformed},
+		{"unprotected crit", modifyJSON(t, flattened, func(m map[string]interface{}) {
+			m["header"] = map[string]interface{}{"crit": []interface{}{"tenant"}, "tenant": "acme"}
+		}), internal.ErrTokenMalformed},
+		{"protected and unprotected", modifyJSON(t, flattened, func(m map[string]interface{}) {
+			m["header"] = map[string]interface{}{"alg": "none"}
+		}), internal.ErrTokenMalformed},
+		{"unprotected of wrong type", modifyJSON(t, flattened, func(m map[string]interface{}) {
+			m["header"] = map[string]interface{}{"kid": 1}
+		}), internal.ErrTokenMalformed},
+		{"not JSON", "{", internal.ErrTokenMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := xwt.NewParser().ParseJSON(tt.token, &jwt.MapClaims{}, secretKeyfunc); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseJSON() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignedJSONHeaderClash(t *testing.T) {
+	_, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{"sub": "alice"}).SignedJSON(
+		xwt.JSONSigner{Method: method.SigningMethodHS256, Key: testSecret, Options: []xwt.TokenOption{xwt.WithKeyID("k1")}, Unprotected: map[string]interface{}{"kid": "k2"}},
+	)
+	if err == nil {
+		t.Fatal("SignedJSON() with kid in protected and unprotected header succeeded")
+	}
+}
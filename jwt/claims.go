@@ -1,5 +1,35 @@
 package jwt
 
+import (
+	"time"
+
+	"github.com/lkyzhu/xwt/internal"
+)
+
 const (
 	Type = "JWT"
 )
+
+// NumericDate represents a JSON numeric date value, as referenced at
+// https://datatracker.ietf.org/doc/html/rfc7519#section-2. It may carry
+// fractional seconds.
+type NumericDate = internal.NumericDate
+
+// NewNumericDate constructs a new *NumericDate from a standard library
+// time.Time struct. It will truncate the timestamp according to the precision
+// set with [SetTimePrecision].
+func NewNumericDate(t time.Time) *NumericDate {
+	return internal.NewNumericDate(t)
+}
+
+// SetTimePrecision sets the precision of the times of new and serialized
+// claims, e.g. time.Millisecond to issue tokens with sub-second expiries. The
+// default precision is seconds, so that no fractional timestamps are
+// generated. Fractional timestamps are always honored during validation,
+// regardless of the precision.
+//
+// It should only be called during initialization, since it affects all
+// claims.
+func SetTimePrecision(precision time.Duration) {
+	internal.TimePrecision = precision
+}
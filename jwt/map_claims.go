@@ -2,7 +2,8 @@ package jwt
 
 import (
 	"encoding/json"
-	"fmt"
+	"strings"
+	"time"
 
 	"github.com/lkyzhu/xwt/internal"
 )
@@ -12,18 +13,18 @@ import (
 type MapClaims map[string]interface{}
 
 // GetExpirationTime implements the Claims interface.
-func (m *MapClaims) GetExpirationTime() int64 {
-	return m.parseInt64("exp")
+func (m *MapClaims) GetExpirationTime() time.Time {
+	return m.parseTime("exp")
 }
 
 // GetNotBefore implements the Claims interface.
-func (m *MapClaims) GetNotBefore() int64 {
-	return m.parseInt64("nbf")
+func (m *MapClaims) GetNotBefore() time.Time {
+	return m.parseTime("nbf")
 }
 
 // GetIssuedAt implements the Claims interface.
-func (m *MapClaims) GetIssuedAt() int64 {
-	return m.parseInt64("iat")
+func (m *MapClaims) GetIssuedAt() time.Time {
+	return m.parseTime("iat")
 }
 
 // GetAudience implements the Claims interface.
@@ -41,6 +42,38 @@ func (m *MapClaims) GetSubject() string {
 	return m.parseString("sub")
 }
 
+// GetConfirmationJWKThumbprint returns the JWK thumbprint of the `cnf` claim,
+// which binds the token to a DPoP key.
+func (m *MapClaims) GetConfirmationJWKThumbprint() string {
+	return m.parseConfirmation("jkt")
+}
+
+// GetConfirmationX509Thumbprint returns the certificate thumbprint of the `cnf`
+// claim, which binds the token to a mutual TLS client certificate.
+func (m *MapClaims) GetConfirmationX509Thumbprint() string {
+	return m.parseConfirmation("x5t#S256")
+}
+
+// GetScopes returns the scopes of the `scope` claim, which is usually a
+// space-delimited string, but is also accepted as an array of strings.
+func (m *MapClaims) GetScopes() []string {
+	if scope, ok := (*m)["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+
+	return m.parseClaimsString("scope")
+}
+
+// GetRoles returns the `roles` claim.
+func (m *MapClaims) GetRoles() []string {
+	return m.parseClaimsString("roles")
+}
+
+// GetPermissions returns the `permissions` claim.
+func (m *MapClaims) GetPermissions() []string {
+	return m.parseClaimsString("permissions")
+}
+
 // Type implements the Claims interface.
 func (m *MapClaims) Type() string {
 	return Type
@@ -56,51 +89,34 @@ func (m *MapClaims) Unmarshal(data []byte) error {
 	return json.Unmarshal(data, m)
 }
 
-// parseInt64 tries to parse a key in the map claims type as a int64
-// Tis will succeed, if the underlying type is either a float64 or a json.Number.
-// Otherwise, 0 will be returned.
-func (m *MapClaims) parseInt64(key string) int64 {
-	v, ok := (*m)[key]
-	if !ok {
-		return 0
-	}
-
-	switch exp := v.(type) {
+// parseTime tries to parse a key in the map claims type as a numeric date.
+// This will succeed, if the underlying type is a number, a [json.Number] or a
+// [NumericDate]. Otherwise, the zero time will be returned.
+func (m *MapClaims) parseTime(key string) time.Time {
+	switch v := (*m)[key].(type) {
 	case float64:
-		return int64(exp)
+		if v != 0 {
+			return internal.NewNumericDateFromSeconds(v).Time
+		}
 	case int64:
-		return exp
-	case json.Number:
-		v, _ := exp.Int64()
-		return v
-	}
-
-	return 0
-}
-
-// parseNumericDate tries to parse a key in the map claims type as a number
-// date. This will succeed, if the underlying type is either a [float64] or a
-// [json.Number]. Otherwise, nil will be returned.
-func (m *MapClaims) parseNumericDate(key string) (*internal.NumericDate, error) {
-	v, ok := (*m)[key]
-	if !ok {
-		return nil, nil
-	}
-
-	switch exp := v.(type) {
-	case float64:
-		if exp == 0 {
-			return nil, nil
+		if v != 0 {
+			return time.Unix(v, 0)
+		}
+	case int:
+		if v != 0 {
+			return time.Unix(int64(v), 0)
 		}
-
-		return internal.NewNumericDateFromSeconds(exp), nil
 	case json.Number:
-		v, _ := exp.Float64()
-
-		return internal.NewNumericDateFromSeconds(v), nil
+		if date, err := internal.ParseNumericDate(v.String()); err == nil {
+			return date.Time
+		}
+	case *NumericDate:
+		return internal.TimeOf(v)
+	case NumericDate:
+		return v.Time
 	}
 
-	return nil, internal.NewError(fmt.Sprintf("%s is invalid", key), internal.ErrInvalidType)
+	return time.Time{}
 }
 
 // parseClaimsString tries to parse a key in the map claims type as a
@@ -146,3 +162,17 @@ func (m *MapClaims) parseString(key string) string {
 
 	return iss
 }
+
+// parseConfirmation tries to parse a member of the `cnf` claim as a [string]
+// type. If the claim or member does not exist or has the wrong type, an empty
+// string is returned.
+func (m *MapClaims) parseConfirmation(member string) string {
+	cnf, ok := (*m)["cnf"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	v, _ := cnf[member].(string)
+
+	return v
+}
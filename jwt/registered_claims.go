@@ -1,7 +1,14 @@
 package jwt
 
 import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/lkyzhu/xwt/internal"
 )
 
 // RegisteredClaims are a structured version of the JWT Claims Set,
@@ -24,31 +31,61 @@ type RegisteredClaims struct {
 	Audience []string `json:"aud,omitempty"`
 
 	// the `exp` (Expiration Time) claim. See https://datatracker.ietf.org/doc/html/rfc7519#section-4.1.4
-	ExpiresAt int64 `json:"exp,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
 
 	// the `nbf` (Not Before) claim. See https://datatracker.ietf.org/doc/html/rfc7519#section-4.1.5
-	NotBefore int64 `json:"nbf,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
 
 	// the `iat` (Issued At) claim. See https://datatracker.ietf.org/doc/html/rfc7519#section-4.1.6
-	IssuedAt int64 `json:"iat,omitempty"`
+	IssuedAt *NumericDate `json:"iat,omitempty"`
 
 	// the `jti` (JWT ID) claim. See https://datatracker.ietf.org/doc/html/rfc7519#section-4.1.7
 	ID string `json:"jti,omitempty"`
+
+	// the `cnf` (Confirmation) claim. See https://datatracker.ietf.org/doc/html/rfc7800#section-3.1
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+
+	// the `scope` claim, a space-delimited list of scopes. See https://datatracker.ietf.org/doc/html/rfc8693#section-4.2
+	Scope string `json:"scope,omitempty"`
+
+	// the `roles` claim. See https://datatracker.ietf.org/doc/html/rfc9068#section-2.2.3.1
+	Roles []string `json:"roles,omitempty"`
+
+	// the `permissions` claim, the fine-grained permissions granted to the token
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// Confirmation contains the members of the `cnf` claim, which bind the token to
+// a key of the presenter.
+type Confirmation struct {
+	// the `jkt` (JWK SHA-256 Thumbprint) member. See https://datatracker.ietf.org/doc/html/rfc9449#section-6.1
+	JWKThumbprint string `json:"jkt,omitempty"`
+
+	// the `x5t#S256` (X.509 Certificate SHA-256 Thumbprint) member. See https://datatracker.ietf.org/doc/html/rfc8705#section-3.1
+	X509CertThumbprintS256 string `json:"x5t#S256,omitempty"`
+}
+
+// NewCertificateConfirmation returns a `cnf` claim, which binds the token to the
+// mutual TLS client certificate cert, as described in
+// https://datatracker.ietf.org/doc/html/rfc8705#section-3.1.
+func NewCertificateConfirmation(cert *x509.Certificate) *Confirmation {
+	sum := sha256.Sum256(cert.Raw)
+	return &Confirmation{X509CertThumbprintS256: base64.RawURLEncoding.EncodeToString(sum[:])}
 }
 
 // GetExpirationTime implements the Claims interface.
-func (c *RegisteredClaims) GetExpirationTime() int64 {
-	return c.ExpiresAt
+func (c *RegisteredClaims) GetExpirationTime() time.Time {
+	return internal.TimeOf(c.ExpiresAt)
 }
 
 // GetNotBefore implements the Claims interface.
-func (c *RegisteredClaims) GetNotBefore() int64 {
-	return c.NotBefore
+func (c *RegisteredClaims) GetNotBefore() time.Time {
+	return internal.TimeOf(c.NotBefore)
 }
 
 // GetIssuedAt implements the Claims interface.
-func (c *RegisteredClaims) GetIssuedAt() int64 {
-	return c.IssuedAt
+func (c *RegisteredClaims) GetIssuedAt() time.Time {
+	return internal.TimeOf(c.IssuedAt)
 }
 
 // GetAudience implements the Claims interface.
@@ -66,6 +103,41 @@ func (c *RegisteredClaims) GetSubject() string {
 	return c.Subject
 }
 
+// GetConfirmationJWKThumbprint returns the JWK thumbprint of the `cnf` claim,
+// which binds the token to a DPoP key.
+func (c *RegisteredClaims) GetConfirmationJWKThumbprint() string {
+	if c.Confirmation == nil {
+		return ""
+	}
+
+	return c.Confirmation.JWKThumbprint
+}
+
+// GetConfirmationX509Thumbprint returns the certificate thumbprint of the `cnf`
+// claim, which binds the token to a mutual TLS client certificate.
+func (c *RegisteredClaims) GetConfirmationX509Thumbprint() string {
+	if c.Confirmation == nil {
+		return ""
+	}
+
+	return c.Confirmation.X509CertThumbprintS256
+}
+
+// GetScopes returns the scopes of the space-delimited `scope` claim.
+func (c *RegisteredClaims) GetScopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// GetRoles returns the `roles` claim.
+func (c *RegisteredClaims) GetRoles() []string {
+	return c.Roles
+}
+
+// GetPermissions returns the `permissions` claim.
+func (c *RegisteredClaims) GetPermissions() []string {
+	return c.Permissions
+}
+
 // Type implements the Claims interface.
 func (c *RegisteredClaims) Type() string {
 	return Type
@@ -0,0 +1,98 @@
+package xwt
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/method"
+)
+
+// Key is a verification key, which is bound to exactly one algorithm. It can be
+// returned by a [Keyfunc], either on its own or as part of a
+// [VerificationKeySet].
+//
+// A token is only verified with a Key, if the `alg` header parameter of the
+// token matches the algorithm the key is bound to. This prevents algorithm
+// confusion attacks, e.g. verifying a HS256 token using the bytes of a public
+// key that is meant to be used for RS256.
+//
+// Keys returned without a Key are bound to the family of algorithms their
+// type belongs to: a []byte secret can only verify HMAC tokens, and HMAC
+// tokens can only be verified with a []byte secret that is not public key
+// material, e.g. a PEM encoded public key.
+type Key struct {
+	// Material is the actual key, e.g. an *ecdsa.PublicKey or a []byte
+	// secret.
+	Material VerificationKey
+
+	// Alg is the algorithm the key is bound to, e.g. "ES256".
+	Alg string
+
+	// Kid optionally identifies the key. If both the key and the token specify
+	// a key ID, they need to match.
+	Kid string
+}
+
+// verify verifies the signature of token using the key, if it is bound to the
+// algorithm of the token.
+func (k *Key) verify(token *Token, text string) error {
+	if k.Alg == "" {
+		return internal.NewError("key is not bound to an algorithm", internal.ErrInvalidKey)
+	}
+
+	if alg := token.Method.Alg(); alg != k.Alg {
+		return internal.NewError(fmt.Sprintf("key is bound to algorithm %s, but token uses %s", k.Alg, alg), internal.ErrInvalidKey)
+	}
+
+	if k.Kid != "" && token.Header.KeyID != "" && k.Kid != token.Header.KeyID {
+		return internal.NewError(fmt.Sprintf("key %s does not match kid %s", k.Kid, token.Header.KeyID), internal.ErrInvalidKey)
+	}
+
+	if err := bindKey(token.Method, k.Material); err != nil {
+		return err
+	}
+
+	return token.Method.Verify(text, token.Signature, k.Material)
+}
+
+// bindKey checks that key belongs to the family of the signing method m. The
+// asymmetric methods only accept keys of their own type, so it only needs to
+// separate secrets from the other keys.
+func bindKey(m method.SigningMethod, key interface{}) error {
+	secret, isSecret := key.([]byte)
+	_, isHMAC := m.(*method.SigningMethodHMAC)
+
+	switch {
+	case isSecret && !isHMAC:
+		return internal.NewError(fmt.Sprintf("secret key cannot be used with algorithm %s", m.Alg()), internal.ErrInvalidKeyType)
+	case !isSecret && isHMAC:
+		return internal.NewError(fmt.Sprintf("algorithm %s requires a secret key", m.Alg()), internal.ErrInvalidKeyType)
+	case isSecret && isPublicKeyMaterial(secret):
+		return internal.NewError("secret key contains public key material", internal.ErrInvalidKey)
+	}
+
+	return nil
+}
+
+// isPublicKeyMaterial reports whether secret is a public key or certificate,
+// either PEM or DER encoded, or a JWK. Using such a secret for HMAC is the
+// classic algorithm confusion attack, since the public key is known to anyone.
+func isPublicKeyMaterial(secret []byte) bool {
+	if bytes.Contains(secret, []byte("-----BEGIN")) || bytes.Contains(secret, []byte(`"kty"`)) {
+		return true
+	}
+
+	if _, err := x509.ParsePKIXPublicKey(secret); err == nil {
+		return true
+	}
+	if _, err := x509.ParsePKCS1PublicKey(secret); err == nil {
+		return true
+	}
+	if _, err := x509.ParseCertificate(secret); err == nil {
+		return true
+	}
+
+	return false
+}
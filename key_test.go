@@ -0,0 +1,93 @@
+package xwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestRawKeyBinding(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	tests := []struct {
+		name    string
+		method  method.SigningMethod
+		signKey interface{}
+		keyfunc interface{}
+		wantErr error
+	}{
+		{"secret", method.SigningMethodHS256, testSecret, testSecret, nil},
+		{"public key PEM as secret", method.SigningMethodHS256, publicPEM, publicPEM, internal.ErrInvalidKey},
+		{"public key DER as secret", method.SigningMethodHS256, der, der, internal.ErrInvalidKey},
+		{"public key for HMAC", method.SigningMethodHS256, testSecret, &priv.PublicKey, internal.ErrInvalidKeyType},
+		{"secret for ECDSA", method.SigningMethodES256, priv, testSecret, internal.ErrInvalidKeyType},
+		{"public key", method.SigningMethodES256, priv, &priv.PublicKey, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := xwt.NewWithClaims(tt.method, &jwt.MapClaims{"sub": "alice"}).SignedString(tt.signKey)
+			if err != nil {
+				t.Fatalf("SignedString() error = %v", err)
+			}
+
+			_, err = xwt.NewParser().ParseWithClaims(token, &jwt.MapClaims{}, func(*xwt.Token) (interface{}, error) {
+				return tt.keyfunc, nil
+			})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ParseWithClaims() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseWithClaims() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBoundKey(t *testing.T) {
+	token, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{}, xwt.WithKeyID("k1")).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  xwt.Key
+		ok   bool
+	}{
+		{"matching", xwt.Key{Material: testSecret, Alg: "HS256", Kid: "k1"}, true},
+		{"other algorithm", xwt.Key{Material: testSecret, Alg: "HS384"}, false},
+		{"other kid", xwt.Key{Material: testSecret, Alg: "HS256", Kid: "k2"}, false},
+		{"unbound", xwt.Key{Material: testSecret}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := xwt.NewParser().ParseWithClaims(token, &jwt.MapClaims{}, func(*xwt.Token) (interface{}, error) {
+				return xwt.VerificationKeySet{Keys: []xwt.VerificationKey{tt.key}}, nil
+			})
+			if (err == nil) != tt.ok {
+				t.Fatalf("ParseWithClaims() error = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
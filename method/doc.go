@@ -0,0 +1,10 @@
+// Package method implements the signing methods of tokens, i.e. the values of
+// the "alg" header, and the parsing of their keys from PEM and JSON Web Keys.
+// The signing methods register themselves, so that they can be looked up by
+// [GetSigningMethod].
+//
+// The ML-DSA signing methods, including the composite ones, use crypto/mldsa,
+// which was added in Go 1.27. Since the module only requires Go 1.22, they are
+// only compiled with Go 1.27 or later; with older toolchains, their algorithms
+// are not registered and tokens using them fail to parse as unverifiable.
+package method
@@ -3,8 +3,10 @@ package method
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/lkyzhu/xwt/internal"
@@ -16,39 +18,50 @@ var (
 )
 
 // SigningMethodECDSA implements the ECDSA family of signing methods.
-// Expects *ecdsa.PrivateKey for signing and *ecdsa.PublicKey for verification
+// Expects *ecdsa.PrivateKey (or any other crypto.Signer) for signing and
+// *ecdsa.PublicKey for verification
 type SigningMethodECDSA struct {
 	Name      string
 	Hash      crypto.Hash
 	KeySize   int
 	CurveBits int
+	// Curve is optional. If set, it is compared against the curve of the key,
+	// rather than just its size.
+	Curve elliptic.Curve
 }
 
 // Specific instances for EC256 and company
 var (
-	SigningMethodES256 *SigningMethodECDSA
-	SigningMethodES384 *SigningMethodECDSA
-	SigningMethodES512 *SigningMethodECDSA
+	SigningMethodES256  *SigningMethodECDSA
+	SigningMethodES384  *SigningMethodECDSA
+	SigningMethodES512  *SigningMethodECDSA
+	SigningMethodES256K *SigningMethodECDSA
 )
 
 func init() {
 	// ES256
-	SigningMethodES256 = &SigningMethodECDSA{"ES256", crypto.SHA256, 32, 256}
+	SigningMethodES256 = &SigningMethodECDSA{Name: "ES256", Hash: crypto.SHA256, KeySize: 32, CurveBits: 256, Curve: elliptic.P256()}
 	RegisterSigningMethod(SigningMethodES256.Alg(), func() SigningMethod {
 		return SigningMethodES256
 	})
 
 	// ES384
-	SigningMethodES384 = &SigningMethodECDSA{"ES384", crypto.SHA384, 48, 384}
+	SigningMethodES384 = &SigningMethodECDSA{Name: "ES384", Hash: crypto.SHA384, KeySize: 48, CurveBits: 384, Curve: elliptic.P384()}
 	RegisterSigningMethod(SigningMethodES384.Alg(), func() SigningMethod {
 		return SigningMethodES384
 	})
 
 	// ES512
-	SigningMethodES512 = &SigningMethodECDSA{"ES512", crypto.SHA512, 66, 521}
+	SigningMethodES512 = &SigningMethodECDSA{Name: "ES512", Hash: crypto.SHA512, KeySize: 66, CurveBits: 521, Curve: elliptic.P521()}
 	RegisterSigningMethod(SigningMethodES512.Alg(), func() SigningMethod {
 		return SigningMethodES512
 	})
+
+	// ES256K, see https://datatracker.ietf.org/doc/html/rfc8812#section-3.2
+	SigningMethodES256K = &SigningMethodECDSA{Name: "ES256K", Hash: crypto.SHA256, KeySize: 32, CurveBits: 256, Curve: Secp256k1()}
+	RegisterSigningMethod(SigningMethodES256K.Alg(), func() SigningMethod {
+		return SigningMethodES256K
+	})
 }
 
 func (m *SigningMethodECDSA) Alg() string {
@@ -67,6 +80,10 @@ func (m *SigningMethodECDSA) Verify(signingString string, sig []byte, key interf
 		return internal.NewError("ECDSA verify expects *ecdsa.PublicKey", internal.ErrInvalidKeyType)
 	}
 
+	if err := m.checkCurve(ecdsaKey); err != nil {
+		return err
+	}
+
 	if len(sig) != 2*m.KeySize {
 		return ErrECDSAVerification
 	}
@@ -82,6 +99,13 @@ func (m *SigningMethodECDSA) Verify(signingString string, sig []byte, key interf
 	hasher.Write([]byte(signingString))
 
 	// Verify the signature
+	if isSecp256k1(ecdsaKey.Curve) {
+		if verifySecp256k1(ecdsaKey, hasher.Sum(nil), sig) {
+			return nil
+		}
+		return ErrECDSAVerification
+	}
+
 	if verifystatus := ecdsa.Verify(ecdsaKey, hasher.Sum(nil), r, s); verifystatus {
 		return nil
 	}
@@ -90,15 +114,36 @@ func (m *SigningMethodECDSA) Verify(signingString string, sig []byte, key interf
 }
 
 // Sign implements token signing for the SigningMethod.
-// For this signing method, key must be an ecdsa.PrivateKey struct
+// For this signing method, key must be a crypto.Signer with an
+// *ecdsa.PublicKey, e.g. an *ecdsa.PrivateKey, or a SignerWithContext.
 func (m *SigningMethodECDSA) Sign(signingString string, key interface{}) ([]byte, error) {
+	var signer crypto.Signer
+	var ok bool
+
+	// Private keys on secp256k1 are not signed by crypto/ecdsa, see Secp256k1
+	if k, isECDSA := key.(*ecdsa.PrivateKey); isECDSA && isSecp256k1(k.Curve) {
+		key = secp256k1Signer{key: k}
+	}
+
 	// Get the key
-	var ecdsaKey *ecdsa.PrivateKey
-	switch k := key.(type) {
-	case *ecdsa.PrivateKey:
-		ecdsaKey = k
-	default:
-		return nil, internal.NewError("ECDSA sign expects *ecdsa.PrivateKey", internal.ErrInvalidKeyType)
+	if signer, ok = asSigner(key); !ok {
+		return nil, internal.NewError("ECDSA sign expects crypto.Signer", internal.ErrInvalidKeyType)
+	}
+
+	ecdsaKey, ok := signer.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, internal.ErrInvalidKey
+	}
+
+	if err := m.checkCurve(ecdsaKey); err != nil {
+		return nil, err
+	}
+
+	curveBits := ecdsaKey.Curve.Params().BitSize
+
+	keyBytes := curveBits / 8
+	if curveBits%8 > 0 {
+		keyBytes += 1
 	}
 
 	// Create the hasher
@@ -109,28 +154,27 @@ func (m *SigningMethodECDSA) Sign(signingString string, key interface{}) ([]byte
 	hasher := m.Hash.New()
 	hasher.Write([]byte(signingString))
 
-	// Sign the string and return r, s
-	if r, s, err := ecdsa.Sign(rand.Reader, ecdsaKey, hasher.Sum(nil)); err == nil {
-		curveBits := ecdsaKey.Curve.Params().BitSize
-
-		if m.CurveBits != curveBits {
-			return nil, internal.ErrInvalidKey
-		}
+	// Sign the string and convert the ASN.1 encoded signature into r || s
+	if der, err := signer.Sign(rand.Reader, hasher.Sum(nil), m.Hash); err == nil {
+		return ecdsaASN1ToRaw(der, keyBytes)
+	} else {
+		return nil, err
+	}
+}
 
-		keyBytes := curveBits / 8
-		if curveBits%8 > 0 {
-			keyBytes += 1
-		}
+// checkCurve checks whether the curve of key matches the algorithm, e.g. P-256
+// for ES256. See https://datatracker.ietf.org/doc/html/rfc7518#section-3.4
+func (m *SigningMethodECDSA) checkCurve(key *ecdsa.PublicKey) error {
+	params := key.Curve.Params()
 
-		// We serialize the outputs (r and s) into big-endian byte arrays
-		// padded with zeros on the left to make sure the sizes work out.
-		// Output must be 2*keyBytes long.
-		out := make([]byte, 2*keyBytes)
-		r.FillBytes(out[0:keyBytes]) // r is assigned to the first half of output.
-		s.FillBytes(out[keyBytes:])  // s is assigned to the second half of output.
+	matches := params.BitSize == m.CurveBits
+	if m.Curve != nil {
+		matches = params.Name == m.Curve.Params().Name
+	}
 
-		return out, nil
-	} else {
-		return nil, err
+	if !matches {
+		return &KeyPolicyError{Alg: m.Alg(), Reason: fmt.Sprintf("curve %s does not match the algorithm", params.Name)}
 	}
+
+	return nil
 }
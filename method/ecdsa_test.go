@@ -0,0 +1,62 @@
+package method_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/method"
+)
+
+func TestECDSACurve(t *testing.T) {
+	// The curve is checked regardless of the key policy.
+	policy := method.DefaultKeyPolicy
+	method.DefaultKeyPolicy = nil
+	defer func() { method.DefaultKeyPolicy = policy }()
+
+	keys := map[string]*ecdsa.PrivateKey{}
+	for name, curve := range map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()} {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[name] = key
+	}
+
+	tests := []struct {
+		method *method.SigningMethodECDSA
+		curve  string
+	}{
+		{method.SigningMethodES256, "P-256"},
+		{method.SigningMethodES384, "P-384"},
+		{method.SigningMethodES512, "P-521"},
+	}
+
+	for _, tt := range tests {
+		for name, key := range keys {
+			t.Run(tt.method.Alg()+"/"+name, func(t *testing.T) {
+				sig, err := tt.method.Sign("header.payload", key)
+				if name != tt.curve {
+					if !errors.Is(err, internal.ErrInvalidKey) {
+						t.Fatalf("Sign() error = %v, want %v", err, internal.ErrInvalidKey)
+					}
+					// Verify must reject the key before looking at the signature.
+					if err := tt.method.Verify("header.payload", make([]byte, 2*tt.method.KeySize), &key.PublicKey); !errors.Is(err, internal.ErrInvalidKey) {
+						t.Fatalf("Verify() error = %v, want %v", err, internal.ErrInvalidKey)
+					}
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("Sign() error = %v", err)
+				}
+				if err := tt.method.Verify("header.payload", sig, &key.PublicKey); err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+			})
+		}
+	}
+}
@@ -2,9 +2,17 @@ package method
 
 import (
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 )
 
 var (
@@ -12,6 +20,33 @@ var (
 	ErrNotECPrivateKey = errors.New("key is not a valid ECDSA private key")
 )
 
+var (
+	oidPublicKeyECDSA      = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidNamedCurveSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// pkcs8 reflects an ASN.1, PKCS #8 PrivateKey.
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// ecPrivateKey reflects an ASN.1 Elliptic Curve Private Key Structure, as
+// defined in RFC 5915.
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// pkixPublicKey reflects an ASN.1, PKIX SubjectPublicKeyInfo.
+type pkixPublicKey struct {
+	Algo      pkix.AlgorithmIdentifier
+	BitString asn1.BitString
+}
+
 // ParseECPrivateKeyFromPEM parses a PEM encoded Elliptic Curve Private Key Structure
 func ParseECPrivateKeyFromPEM(key []byte) (*ecdsa.PrivateKey, error) {
 	var err error
@@ -22,10 +57,14 @@ func ParseECPrivateKeyFromPEM(key []byte) (*ecdsa.PrivateKey, error) {
 		return nil, ErrKeyMustBePEMEncoded
 	}
 
-	// Parse the key
+	// Parse the key. The curve secp256k1 is not supported by crypto/x509, so
+	// we fall back to our own parser.
 	var parsedKey interface{}
 	if parsedKey, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
 		if parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			if pkey, serr := parseSecp256k1PrivateKey(block.Bytes); serr == nil {
+				return pkey, nil
+			}
 			return nil, err
 		}
 	}
@@ -49,11 +88,14 @@ func ParseECPublicKeyFromPEM(key []byte) (*ecdsa.PublicKey, error) {
 		return nil, ErrKeyMustBePEMEncoded
 	}
 
-	// Parse the key
+	// Parse the key. The curve secp256k1 is not supported by crypto/x509, so
+	// we fall back to our own parser.
 	var parsedKey interface{}
 	if parsedKey, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
 		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
 			parsedKey = cert.PublicKey
+		} else if pkey, serr := parseSecp256k1PublicKey(block.Bytes); serr == nil {
+			return pkey, nil
 		} else {
 			return nil, err
 		}
@@ -67,3 +109,218 @@ func ParseECPublicKeyFromPEM(key []byte) (*ecdsa.PublicKey, error) {
 
 	return pkey, nil
 }
+
+// jsonWebKeyEC reflects the members of an elliptic curve JSON Web Key, as
+// defined in https://datatracker.ietf.org/doc/html/rfc7518#section-6.2.
+type jsonWebKeyEC struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	D   string `json:"d,omitempty"`
+}
+
+// ParseECPublicKeyFromJWK parses a JSON Web Key of type "EC". The curves P-256,
+// P-384, P-521 and secp256k1 are supported.
+func ParseECPublicKeyFromJWK(key []byte) (*ecdsa.PublicKey, error) {
+	var jwk jsonWebKeyEC
+	if err := json.Unmarshal(key, &jwk); err != nil {
+		return nil, err
+	}
+
+	return jwk.publicKey()
+}
+
+// ParseECPrivateKeyFromJWK parses a JSON Web Key of type "EC" containing the
+// private key in the "d" member. The curves P-256, P-384, P-521 and secp256k1
+// are supported.
+func ParseECPrivateKeyFromJWK(key []byte) (*ecdsa.PrivateKey, error) {
+	var jwk jsonWebKeyEC
+	if err := json.Unmarshal(key, &jwk); err != nil {
+		return nil, err
+	}
+
+	pub, err := jwk.publicKey()
+	if err != nil {
+		return nil, ErrNotECPrivateKey
+	}
+
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	d, err := base64.RawURLEncoding.DecodeString(jwk.D)
+	if err != nil || len(d) != size {
+		return nil, ErrNotECPrivateKey
+	}
+
+	pkey := &ecdsa.PrivateKey{PublicKey: *pub, D: new(big.Int).SetBytes(d)}
+	if pkey.D.Sign() <= 0 || pkey.D.Cmp(pub.Curve.Params().N) >= 0 {
+		return nil, ErrNotECPrivateKey
+	}
+
+	// Make sure the private key belongs to the public key
+	x, y := pub.Curve.ScalarBaseMult(d)
+	if x.Cmp(pub.X) != 0 || y.Cmp(pub.Y) != 0 {
+		return nil, ErrNotECPrivateKey
+	}
+
+	return pkey, nil
+}
+
+// publicKey returns the public key contained in the JSON Web Key.
+func (jwk *jsonWebKeyEC) publicKey() (*ecdsa.PublicKey, error) {
+	if jwk.Kty != "EC" {
+		return nil, ErrNotECPublicKey
+	}
+
+	curve := curveByName(jwk.Crv)
+	if curve == nil {
+		return nil, ErrNotECPublicKey
+	}
+
+	// The coordinates must have the full size of the curve, see RFC 7518
+	// section 6.2.1.2
+	size := (curve.Params().BitSize + 7) / 8
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil || len(x) != size {
+		return nil, ErrNotECPublicKey
+	}
+	y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if err != nil || len(y) != size {
+		return nil, ErrNotECPublicKey
+	}
+
+	pub := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+	if !curve.IsOnCurve(pub.X, pub.Y) {
+		return nil, ErrNotECPublicKey
+	}
+
+	return pub, nil
+}
+
+// curveByName returns the curve with the given JSON Web Key "crv" name, or nil
+// if it is not supported.
+func curveByName(crv string) elliptic.Curve {
+	switch crv {
+	case "P-256":
+		return elliptic.P256()
+	case "P-384":
+		return elliptic.P384()
+	case "P-521":
+		return elliptic.P521()
+	case "secp256k1":
+		return Secp256k1()
+	}
+
+	return nil
+}
+
+// curveName returns the JSON Web Key "crv" name of curve, or an empty string if
+// it is not supported.
+func curveName(curve elliptic.Curve) string {
+	switch curve.Params().Name {
+	case "P-256", "P-384", "P-521", "secp256k1":
+		return curve.Params().Name
+	}
+
+	return ""
+}
+
+// parseSecp256k1PrivateKey parses a PKCS #8 or SEC 1, ASN.1 DER private key on
+// the curve secp256k1.
+func parseSecp256k1PrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
+	var pk pkcs8
+	if rest, err := asn1.Unmarshal(der, &pk); err == nil && len(rest) == 0 {
+		if !isNamedCurveAlgorithm(pk.Algo, oidNamedCurveSecp256k1) {
+			return nil, ErrNotECPrivateKey
+		}
+		der = pk.PrivateKey
+	}
+
+	var ec ecPrivateKey
+	if rest, err := asn1.Unmarshal(der, &ec); err != nil {
+		return nil, err
+	} else if len(rest) != 0 || len(ec.PrivateKey) > 32 {
+		return nil, ErrNotECPrivateKey
+	}
+
+	if ec.NamedCurveOID != nil && !ec.NamedCurveOID.Equal(oidNamedCurveSecp256k1) {
+		return nil, ErrNotECPrivateKey
+	}
+
+	d := new(big.Int).SetBytes(ec.PrivateKey)
+	if d.Sign() <= 0 || d.Cmp(Secp256k1().Params().N) >= 0 {
+		return nil, ErrNotECPrivateKey
+	}
+
+	return secp256k1.PrivKeyFromBytes(ec.PrivateKey).ToECDSA(), nil
+}
+
+// parseSecp256k1PublicKey parses a PKIX, ASN.1 DER public key on the curve
+// secp256k1.
+func parseSecp256k1PublicKey(der []byte) (*ecdsa.PublicKey, error) {
+	var pki pkixPublicKey
+	if rest, err := asn1.Unmarshal(der, &pki); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, ErrNotECPublicKey
+	}
+
+	if !isNamedCurveAlgorithm(pki.Algo, oidNamedCurveSecp256k1) {
+		return nil, ErrNotECPublicKey
+	}
+
+	pub := unmarshalECPoint(Secp256k1(), pki.BitString.RightAlign())
+	if pub == nil {
+		return nil, ErrNotECPublicKey
+	}
+
+	return pub, nil
+}
+
+// isNamedCurveAlgorithm reports whether algo identifies an elliptic curve key
+// on the named curve with the given OID.
+func isNamedCurveAlgorithm(algo pkix.AlgorithmIdentifier, oid asn1.ObjectIdentifier) bool {
+	if !algo.Algorithm.Equal(oidPublicKeyECDSA) {
+		return false
+	}
+
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(algo.Parameters.FullBytes, &curve); err != nil {
+		return false
+	}
+
+	return curve.Equal(oid)
+}
+
+// marshalECPoint converts a point on a 256 bit curve into the uncompressed
+// form specified in SEC 1, Version 2.0, Section 2.3.3.
+func marshalECPoint(pub *ecdsa.PublicKey) []byte {
+	out := make([]byte, 65)
+	out[0] = 4
+	pub.X.FillBytes(out[1:33])
+	pub.Y.FillBytes(out[33:])
+
+	return out
+}
+
+// unmarshalECPoint converts a point in uncompressed form into a public key on
+// the given 256 bit curve. It returns nil, if the point is not on the curve.
+func unmarshalECPoint(curve elliptic.Curve, data []byte) *ecdsa.PublicKey {
+	if len(data) != 65 || data[0] != 4 {
+		return nil
+	}
+
+	pub := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(data[1:33]),
+		Y:     new(big.Int).SetBytes(data[33:]),
+	}
+	if !curve.IsOnCurve(pub.X, pub.Y) {
+		return nil
+	}
+
+	return pub
+}
@@ -6,15 +6,20 @@ import (
 	"crypto/rand"
 	"errors"
 
+	"github.com/cloudflare/circl/sign/ed448"
 	"github.com/lkyzhu/xwt/internal"
 )
 
 var (
 	ErrEd25519Verification = errors.New("ed25519: verification error")
+	ErrEd448Verification   = errors.New("ed448: verification error")
 )
 
 // SigningMethodEd25519 implements the EdDSA family.
-// Expects ed25519.PrivateKey for signing and ed25519.PublicKey for verification
+// Expects ed25519.PrivateKey or ed448.PrivateKey for signing and
+// ed25519.PublicKey or ed448.PublicKey for verification. As specified in
+// https://datatracker.ietf.org/doc/html/rfc8037#section-3.1, both curves share
+// the `alg` "EdDSA"; the curve is determined by the type of the key.
 type SigningMethodEd25519 struct{}
 
 // Specific instance for EdDSA
@@ -34,45 +39,60 @@ func (m *SigningMethodEd25519) Alg() string {
 }
 
 // Verify implements token verification for the SigningMethod.
-// For this verify method, key must be an ed25519.PublicKey
+// For this verify method, key must be an ed25519.PublicKey or ed448.PublicKey
 func (m *SigningMethodEd25519) Verify(signingString string, sig []byte, key interface{}) error {
-	var ed25519Key ed25519.PublicKey
-	var ok bool
-
-	if ed25519Key, ok = key.(ed25519.PublicKey); !ok {
-		return internal.NewError("Ed25519 verify expects ed25519.PublicKey", internal.ErrInvalidKeyType)
-	}
-
-	if len(ed25519Key) != ed25519.PublicKeySize {
-		return internal.ErrInvalidKey
-	}
-
-	// Verify the signature
-	if !ed25519.Verify(ed25519Key, []byte(signingString), sig) {
-		return ErrEd25519Verification
+	switch edKey := key.(type) {
+	case ed25519.PublicKey:
+		if len(edKey) != ed25519.PublicKeySize {
+			return internal.ErrInvalidKey
+		}
+
+		// Verify the signature
+		if !ed25519.Verify(edKey, []byte(signingString), sig) {
+			return ErrEd25519Verification
+		}
+	case ed448.PublicKey:
+		if len(edKey) != ed448.PublicKeySize {
+			return internal.ErrInvalidKey
+		}
+
+		// Verify the signature. JWS uses pure Ed448 with an empty context
+		if !ed448.Verify(edKey, []byte(signingString), sig, "") {
+			return ErrEd448Verification
+		}
+	default:
+		return internal.NewError("Ed25519 verify expects ed25519.PublicKey or ed448.PublicKey", internal.ErrInvalidKeyType)
 	}
 
 	return nil
 }
 
 // Sign implements token signing for the SigningMethod.
-// For this signing method, key must be an ed25519.PrivateKey
+// For this signing method, key must be an ed25519.PrivateKey, ed448.PrivateKey
+// or any other crypto.Signer or SignerWithContext with an ed25519.PublicKey or
+// ed448.PublicKey
 func (m *SigningMethodEd25519) Sign(signingString string, key interface{}) ([]byte, error) {
-	var ed25519Key crypto.Signer
+	var edKey crypto.Signer
 	var ok bool
 
-	if ed25519Key, ok = key.(crypto.Signer); !ok {
+	if edKey, ok = asSigner(key); !ok {
 		return nil, internal.NewError("Ed25519 sign expects crypto.Signer", internal.ErrInvalidKeyType)
 	}
 
-	if _, ok := ed25519Key.Public().(ed25519.PublicKey); !ok {
+	// Sign the string and return the result. EdDSA performs a two-pass hash
+	// as part of its algorithm. Therefore, we need to pass a non-prehashed
+	// message into the Sign function, as indicated by crypto.Hash(0)
+	var opts crypto.SignerOpts = crypto.Hash(0)
+	switch edKey.Public().(type) {
+	case ed25519.PublicKey:
+	case ed448.PublicKey:
+		// Pure Ed448 with an empty context
+		opts = ed448.SignerOptions{Hash: crypto.Hash(0), Scheme: ed448.ED448}
+	default:
 		return nil, internal.ErrInvalidKey
 	}
 
-	// Sign the string and return the result. ed25519 performs a two-pass hash
-	// as part of its algorithm. Therefore, we need to pass a non-prehashed
-	// message into the Sign function, as indicated by crypto.Hash(0)
-	sig, err := ed25519Key.Sign(rand.Reader, []byte(signingString), crypto.Hash(0))
+	sig, err := edKey.Sign(rand.Reader, []byte(signingString), opts)
 	if err != nil {
 		return nil, err
 	}
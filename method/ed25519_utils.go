@@ -4,8 +4,13 @@ import (
 	"crypto"
 	"crypto/ed25519"
 	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
+
+	"github.com/cloudflare/circl/sign/ed448"
 )
 
 var (
@@ -13,7 +18,12 @@ var (
 	ErrNotEdPublicKey  = errors.New("key is not a valid Ed25519 public key")
 )
 
-// ParseEdPrivateKeyFromPEM parses a PEM-encoded Edwards curve private key
+var (
+	oidPublicKeyEd448 = asn1.ObjectIdentifier{1, 3, 101, 113}
+)
+
+// ParseEdPrivateKeyFromPEM parses a PEM-encoded Edwards curve private key. The
+// result is either an ed25519.PrivateKey or an ed448.PrivateKey.
 func ParseEdPrivateKeyFromPEM(key []byte) (crypto.PrivateKey, error) {
 	var err error
 
@@ -23,9 +33,13 @@ func ParseEdPrivateKeyFromPEM(key []byte) (crypto.PrivateKey, error) {
 		return nil, ErrKeyMustBePEMEncoded
 	}
 
-	// Parse the key
+	// Parse the key. Ed448 is not supported by crypto/x509, so we fall back to
+	// our own parser.
 	var parsedKey interface{}
 	if parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		if pkey, err := parseEd448PrivateKey(block.Bytes); err == nil {
+			return pkey, nil
+		}
 		return nil, err
 	}
 
@@ -38,7 +52,8 @@ func ParseEdPrivateKeyFromPEM(key []byte) (crypto.PrivateKey, error) {
 	return pkey, nil
 }
 
-// ParseEdPublicKeyFromPEM parses a PEM-encoded Edwards curve public key
+// ParseEdPublicKeyFromPEM parses a PEM-encoded Edwards curve public key. The
+// result is either an ed25519.PublicKey or an ed448.PublicKey.
 func ParseEdPublicKeyFromPEM(key []byte) (crypto.PublicKey, error) {
 	var err error
 
@@ -48,9 +63,13 @@ func ParseEdPublicKeyFromPEM(key []byte) (crypto.PublicKey, error) {
 		return nil, ErrKeyMustBePEMEncoded
 	}
 
-	// Parse the key
+	// Parse the key. Ed448 is not supported by crypto/x509, so we fall back to
+	// our own parser.
 	var parsedKey interface{}
 	if parsedKey, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+		if pkey, err := parseEd448PublicKey(block.Bytes); err == nil {
+			return pkey, nil
+		}
 		return nil, err
 	}
 
@@ -62,3 +81,121 @@ func ParseEdPublicKeyFromPEM(key []byte) (crypto.PublicKey, error) {
 
 	return pkey, nil
 }
+
+// jsonWebKeyOKP reflects the members of an "OKP" (Octet Key Pair) JSON Web
+// Key, as defined in https://datatracker.ietf.org/doc/html/rfc8037#section-2.
+type jsonWebKeyOKP struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	D   string `json:"d,omitempty"`
+}
+
+// ParseEdPublicKeyFromJWK parses a JSON Web Key of type "OKP" with the curve
+// Ed25519 or Ed448. The result is either an ed25519.PublicKey or an
+// ed448.PublicKey.
+func ParseEdPublicKeyFromJWK(key []byte) (crypto.PublicKey, error) {
+	var jwk jsonWebKeyOKP
+	if err := json.Unmarshal(key, &jwk); err != nil {
+		return nil, err
+	}
+
+	return jwk.publicKey()
+}
+
+// ParseEdPrivateKeyFromJWK parses a JSON Web Key of type "OKP" with the curve
+// Ed25519 or Ed448, containing the private key in the "d" member. The result is
+// either an ed25519.PrivateKey or an ed448.PrivateKey.
+func ParseEdPrivateKeyFromJWK(key []byte) (crypto.PrivateKey, error) {
+	var jwk jsonWebKeyOKP
+	if err := json.Unmarshal(key, &jwk); err != nil {
+		return nil, err
+	}
+
+	pub, err := jwk.publicKey()
+	if err != nil {
+		return nil, ErrNotEdPrivateKey
+	}
+
+	d, err := base64.RawURLEncoding.DecodeString(jwk.D)
+	if err != nil {
+		return nil, ErrNotEdPrivateKey
+	}
+
+	// Derive the private key from the seed and make sure it belongs to the
+	// public key
+	var pkey crypto.Signer
+	switch {
+	case jwk.Crv == "Ed25519" && len(d) == ed25519.SeedSize:
+		pkey = ed25519.NewKeyFromSeed(d)
+	case jwk.Crv == "Ed448" && len(d) == ed448.SeedSize:
+		pkey = ed448.NewKeyFromSeed(d)
+	default:
+		return nil, ErrNotEdPrivateKey
+	}
+
+	if !pkey.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(pub) {
+		return nil, ErrNotEdPrivateKey
+	}
+
+	return pkey, nil
+}
+
+// publicKey returns the public key contained in the JSON Web Key.
+func (jwk *jsonWebKeyOKP) publicKey() (crypto.PublicKey, error) {
+	if jwk.Kty != "OKP" {
+		return nil, ErrNotEdPublicKey
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, ErrNotEdPublicKey
+	}
+
+	switch {
+	case jwk.Crv == "Ed25519" && len(x) == ed25519.PublicKeySize:
+		return ed25519.PublicKey(x), nil
+	case jwk.Crv == "Ed448" && len(x) == ed448.PublicKeySize:
+		return ed448.PublicKey(x), nil
+	}
+
+	return nil, ErrNotEdPublicKey
+}
+
+// parseEd448PrivateKey parses a PKCS #8, ASN.1 DER Ed448 private key, as
+// defined in https://datatracker.ietf.org/doc/html/rfc8410#section-7.
+func parseEd448PrivateKey(der []byte) (ed448.PrivateKey, error) {
+	var pk pkcs8
+	if rest, err := asn1.Unmarshal(der, &pk); err != nil {
+		return nil, err
+	} else if len(rest) != 0 || !pk.Algo.Algorithm.Equal(oidPublicKeyEd448) {
+		return nil, ErrNotEdPrivateKey
+	}
+
+	// The private key is wrapped in another OCTET STRING
+	var seed []byte
+	if rest, err := asn1.Unmarshal(pk.PrivateKey, &seed); err != nil {
+		return nil, err
+	} else if len(rest) != 0 || len(seed) != ed448.SeedSize {
+		return nil, ErrNotEdPrivateKey
+	}
+
+	return ed448.NewKeyFromSeed(seed), nil
+}
+
+// parseEd448PublicKey parses a PKIX, ASN.1 DER Ed448 public key, as defined in
+// https://datatracker.ietf.org/doc/html/rfc8410#section-4.
+func parseEd448PublicKey(der []byte) (ed448.PublicKey, error) {
+	var pki pkixPublicKey
+	if rest, err := asn1.Unmarshal(der, &pki); err != nil {
+		return nil, err
+	} else if len(rest) != 0 || !pki.Algo.Algorithm.Equal(oidPublicKeyEd448) {
+		return nil, ErrNotEdPublicKey
+	}
+
+	if b := pki.BitString.RightAlign(); len(b) == ed448.PublicKeySize {
+		return ed448.PublicKey(b), nil
+	}
+
+	return nil, ErrNotEdPublicKey
+}
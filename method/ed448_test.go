@@ -0,0 +1,179 @@
+package method_test
+
+import (
+	"crypto/ed25519"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cloudflare/circl/sign/ed448"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/method"
+)
+
+// The Ed448 test vectors of RFC 8032, section 7.4.
+var ed448Vectors = []struct {
+	name string
+	seed string
+	pub  string
+	msg  string
+	sig  string
+}{
+	{
+		name: "blank",
+		seed: "6c82a562cb808d10d632be89c8513ebf6c929f34ddfa8c9f63c9960ef6e348a3528c8a3fcc2f044e39a3fc5b94492f8f032e7549a20098f95b",
+		pub:  "5fd7449b59b461fd2ce787ec616ad46a1da1342485a70e1f8a0ea75d80e96778edf124769b46c7061bd6783df1e50f6cd1fa1abeafe8256180",
+		msg:  "",
+		sig:  "533a37f6bbe457251f023c0d88f976ae2dfb504a843e34d2074fd823d41a591f2b233f034f628281f2fd7a22ddd47d7828c59bd0a21bfd3980ff0d2028d4b18a9df63e006c5d1c2d345b925d8dc00b4104852db99ac5c7cdda8530a113a0f4dbb61149f05a7363268c71d95808ff2e652600",
+	},
+	{
+		name: "1 octet",
+		seed: "c4eab05d357007c632f3dbb48489924d552b08fe0c353a0d4a1f00acda2c463afbea67c5e8d2877c5e3bc397a659949ef8021e954e0a12274e",
+		pub:  "43ba28f430cdff456ae531545f7ecd0ac834a55d9358c0372bfa0c6c6798c0866aea01eb00742802b8438ea4cb82169c235160627b4c3a9480",
+		msg:  "03",
+		sig:  "26b8f91727bd62897af15e41eb43c377efb9c610d48f2335cb0bd0087810f4352541b143c4b981b7e18f62de8ccdf633fc1bf037ab7cd779805e0dbcc0aae1cbcee1afb2e027df36bc04dcecbf154336c19f0af7e0a6472905e799f1953d2a0ff3348ab21aa4adafd1d234441cf807c03a00",
+	},
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestEd448(t *testing.T) {
+	for _, tt := range ed448Vectors {
+		t.Run(tt.name, func(t *testing.T) {
+			priv := ed448.NewKeyFromSeed(mustHex(t, tt.seed))
+			pub := ed448.PublicKey(mustHex(t, tt.pub))
+			msg := string(mustHex(t, tt.msg))
+
+			sig, err := method.SigningMethodEdDSA.Sign(msg, priv)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if got := hex.EncodeToString(sig); got != tt.sig {
+				t.Fatalf("Sign() = %s, want %s", got, tt.sig)
+			}
+			if err := method.SigningMethodEdDSA.Verify(msg, sig, pub); err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			sig[0] ^= 1
+			if err := method.SigningMethodEdDSA.Verify(msg, sig, pub); !errors.Is(err, method.ErrEd448Verification) {
+				t.Fatalf("Verify() of modified signature error = %v, want %v", err, method.ErrEd448Verification)
+			}
+		})
+	}
+}
+
+func TestEdDSAKeyTypes(t *testing.T) {
+	vector := ed448Vectors[0]
+	sig := mustHex(t, vector.sig)
+
+	// The curve is determined by the key, so an Ed448 signature does not
+	// verify with an Ed25519 key.
+	if err := method.SigningMethodEdDSA.Verify("", sig, ed25519.PublicKey(make([]byte, ed25519.PublicKeySize))); !errors.Is(err, method.ErrEd25519Verification) {
+		t.Fatalf("Verify() with Ed25519 key error = %v, want %v", err, method.ErrEd25519Verification)
+	}
+
+	if err := method.SigningMethodEdDSA.Verify("", sig, ed448.PublicKey(make([]byte, 32))); !errors.Is(err, internal.ErrInvalidKey) {
+		t.Fatalf("Verify() with short Ed448 key error = %v, want %v", err, internal.ErrInvalidKey)
+	}
+}
+
+func TestEd448JWK(t *testing.T) {
+	vector := ed448Vectors[1]
+	x := base64.RawURLEncoding.EncodeToString(mustHex(t, vector.pub))
+	d := base64.RawURLEncoding.EncodeToString(mustHex(t, vector.seed))
+
+	pub, err := method.ParseEdPublicKeyFromJWK([]byte(fmt.Sprintf(`{"kty":"OKP","crv":"Ed448","x":%q}`, x)))
+	if err != nil {
+		t.Fatalf("ParseEdPublicKeyFromJWK() error = %v", err)
+	}
+	if _, ok := pub.(ed448.PublicKey); !ok {
+		t.Fatalf("ParseEdPublicKeyFromJWK() = %T, want ed448.PublicKey", pub)
+	}
+
+	priv, err := method.ParseEdPrivateKeyFromJWK([]byte(fmt.Sprintf(`{"kty":"OKP","crv":"Ed448","x":%q,"d":%q}`, x, d)))
+	if err != nil {
+		t.Fatalf("ParseEdPrivateKeyFromJWK() error = %v", err)
+	}
+
+	sig, err := method.SigningMethodEdDSA.Sign(string(mustHex(t, vector.msg)), priv)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if got := hex.EncodeToString(sig); got != vector.sig {
+		t.Fatalf("Sign() = %s, want %s", got, vector.sig)
+	}
+
+	// The seed must belong to the public key.
+	other := base64.RawURLEncoding.EncodeToString(mustHex(t, ed448Vectors[0].seed))
+	if _, err := method.ParseEdPrivateKeyFromJWK([]byte(fmt.Sprintf(`{"kty":"OKP","crv":"Ed448","x":%q,"d":%q}`, x, other))); !errors.Is(err, method.ErrNotEdPrivateKey) {
+		t.Fatalf("ParseEdPrivateKeyFromJWK() of mismatching key error = %v, want %v", err, method.ErrNotEdPrivateKey)
+	}
+
+	// The size of the key determines the curve.
+	if _, err := method.ParseEdPublicKeyFromJWK([]byte(fmt.Sprintf(`{"kty":"OKP","crv":"Ed25519","x":%q}`, x))); !errors.Is(err, method.ErrNotEdPublicKey) {
+		t.Fatalf("ParseEdPublicKeyFromJWK() with wrong curve error = %v, want %v", err, method.ErrNotEdPublicKey)
+	}
+}
+
+func TestEd448PEM(t *testing.T) {
+	vector := ed448Vectors[1]
+	algo := pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 3, 101, 113}}
+
+	// RFC 8410, section 7: the seed is wrapped in another OCTET STRING.
+	seed, err := asn1.Marshal(mustHex(t, vector.seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := asn1.Marshal(struct {
+		Version    int
+		Algo       pkix.AlgorithmIdentifier
+		PrivateKey []byte
+	}{0, algo, seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pubBytes := mustHex(t, vector.pub)
+	spki, err := asn1.Marshal(struct {
+		Algo      pkix.AlgorithmIdentifier
+		BitString asn1.BitString
+	}{algo, asn1.BitString{Bytes: pubBytes, BitLength: 8 * len(pubBytes)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priv, err := method.ParseEdPrivateKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+	if err != nil {
+		t.Fatalf("ParseEdPrivateKeyFromPEM() error = %v", err)
+	}
+	pub, err := method.ParseEdPublicKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: spki}))
+	if err != nil {
+		t.Fatalf("ParseEdPublicKeyFromPEM() error = %v", err)
+	}
+
+	msg := string(mustHex(t, vector.msg))
+	sig, err := method.SigningMethodEdDSA.Sign(msg, priv)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if got := hex.EncodeToString(sig); got != vector.sig {
+		t.Fatalf("Sign() = %s, want %s", got, vector.sig)
+	}
+	if err := method.SigningMethodEdDSA.Verify(msg, sig, pub); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+}
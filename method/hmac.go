@@ -13,6 +13,8 @@ import (
 type SigningMethodHMAC struct {
 	Name string
 	Hash crypto.Hash
+	// KeyPolicy is optional. If set overrides DefaultKeyPolicy.
+	KeyPolicy *KeyPolicy
 }
 
 // Specific instances for HS256 and company
@@ -25,19 +27,19 @@ var (
 
 func init() {
 	// HS256
-	SigningMethodHS256 = &SigningMethodHMAC{"HS256", crypto.SHA256}
+	SigningMethodHS256 = &SigningMethodHMAC{Name: "HS256", Hash: crypto.SHA256}
 	RegisterSigningMethod(SigningMethodHS256.Alg(), func() SigningMethod {
 		return SigningMethodHS256
 	})
 
 	// HS384
-	SigningMethodHS384 = &SigningMethodHMAC{"HS384", crypto.SHA384}
+	SigningMethodHS384 = &SigningMethodHMAC{Name: "HS384", Hash: crypto.SHA384}
 	RegisterSigningMethod(SigningMethodHS384.Alg(), func() SigningMethod {
 		return SigningMethodHS384
 	})
 
 	// HS512
-	SigningMethodHS512 = &SigningMethodHMAC{"HS512", crypto.SHA512}
+	SigningMethodHS512 = &SigningMethodHMAC{Name: "HS512", Hash: crypto.SHA512}
 	RegisterSigningMethod(SigningMethodHS512.Alg(), func() SigningMethod {
 		return SigningMethodHS512
 	})
@@ -64,6 +66,11 @@ func (m *SigningMethodHMAC) Verify(signingString string, sig []byte, key interfa
 		return internal.NewError("HMAC verify expects []byte", internal.ErrInvalidKeyType)
 	}
 
+	// Does the key fulfill the policy?
+	if err := keyPolicy(m.KeyPolicy).checkHMAC(m, keyBytes); err != nil {
+		return err
+	}
+
 	// Can we use the specified hashing method?
 	if !m.Hash.Available() {
 		return internal.ErrHashUnavailable
@@ -92,6 +99,10 @@ func (m *SigningMethodHMAC) Verify(signingString string, sig []byte, key interfa
 // be found on our usage guide https://golang-jwt.github.io/jwt/usage/signing_methods/.
 func (m *SigningMethodHMAC) Sign(signingString string, key interface{}) ([]byte, error) {
 	if keyBytes, ok := key.([]byte); ok {
+		if err := keyPolicy(m.KeyPolicy).checkHMAC(m, keyBytes); err != nil {
+			return nil, err
+		}
+
 		if !m.Hash.Available() {
 			return nil, internal.ErrHashUnavailable
 		}
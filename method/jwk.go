@@ -0,0 +1,146 @@
+package method
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/cloudflare/circl/sign/ed448"
+)
+
+var (
+	ErrUnsupportedJWK = errors.New("JSON Web Key type is not supported")
+)
+
+// ParsePublicKeyFromJWK parses a JSON Web Key of type "RSA", "EC" or "OKP" into
+// a *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey or ed448.PublicKey.
+// Private members are ignored.
+func ParsePublicKeyFromJWK(key []byte) (crypto.PublicKey, error) {
+	var jwk struct {
+		Kty string `json:"kty"`
+	}
+	if err := json.Unmarshal(key, &jwk); err != nil {
+		return nil, err
+	}
+
+	switch jwk.Kty {
+	case "RSA":
+		return ParseRSAPublicKeyFromJWK(key)
+	case "EC":
+		return ParseECPublicKeyFromJWK(key)
+	case "OKP":
+		return ParseEdPublicKeyFromJWK(key)
+	}
+
+	return nil, ErrUnsupportedJWK
+}
+
+// MarshalPublicKeyToJWK converts a *rsa.PublicKey, *ecdsa.PublicKey,
+// ed25519.PublicKey or ed448.PublicKey into a JSON Web Key. The result only
+// contains the required members of the key type.
+func MarshalPublicKeyToJWK(key crypto.PublicKey) ([]byte, error) {
+	if _, ok := key.([]byte); ok {
+		return nil, ErrUnsupportedJWK
+	}
+
+	members, err := jwkMembers(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(members)
+}
+
+// JWKThumbprint computes the SHA-256 JSON Web Key Thumbprint of a key, as
+// specified in https://datatracker.ietf.org/doc/html/rfc7638, and returns it
+// base64url encoded. It supports the keys of all signing methods of this
+// package, except ML-DSA:
+//   - the public keys supported by [MarshalPublicKeyToJWK]
+//   - private keys, i.e. any crypto.Signer or SignerWithContext, in which case
+//     the thumbprint of the public key is returned
+//   - HMAC secrets ([]byte), which are represented as JSON Web Key of type
+//     "oct". Note that the thumbprint of a low-entropy secret can be used to
+//     guess the secret.
+//
+// Since the thumbprint of private and public key are equal, it is well suited
+// as `kid` of the key.
+func JWKThumbprint(key interface{}) (string, error) {
+	switch k := key.(type) {
+	case crypto.Signer:
+		key = k.Public()
+	case SignerWithContext:
+		key = k.Public()
+	}
+
+	members, err := jwkMembers(key)
+	if err != nil {
+		return "", err
+	}
+
+	// The thumbprint is computed over the required members in lexicographic
+	// order without any whitespace, which is exactly how encoding/json
+	// marshals a map
+	b, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// jwkMembers returns the required members of the JSON Web Key representing key,
+// see https://datatracker.ietf.org/doc/html/rfc7638#section-3.2.
+func jwkMembers(key crypto.PublicKey) (map[string]string, error) {
+	enc := base64.RawURLEncoding
+
+	switch k := key.(type) {
+	case []byte:
+		return map[string]string{
+			"kty": "oct",
+			"k":   enc.EncodeToString(k),
+		}, nil
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA",
+			"n":   enc.EncodeToString(k.N.Bytes()),
+			"e":   enc.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		crv := curveName(k.Curve)
+		if crv == "" {
+			return nil, ErrUnsupportedJWK
+		}
+
+		// The coordinates must have the full size of the curve, see RFC 7518
+		// section 6.2.1.2
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return map[string]string{
+			"kty": "EC",
+			"crv": crv,
+			"x":   enc.EncodeToString(k.X.FillBytes(make([]byte, size))),
+			"y":   enc.EncodeToString(k.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return map[string]string{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"x":   enc.EncodeToString(k),
+		}, nil
+	case ed448.PublicKey:
+		return map[string]string{
+			"kty": "OKP",
+			"crv": "Ed448",
+			"x":   enc.EncodeToString(k),
+		}, nil
+	}
+
+	return nil, ErrUnsupportedJWK
+}
@@ -0,0 +1,93 @@
+package method
+
+import (
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/lkyzhu/xwt/internal"
+)
+
+// KeyPolicy specifies the minimum strength of keys that are accepted by the
+// signing methods when signing or verifying a token. Signing methods without a
+// policy of their own use [DefaultKeyPolicy], so a zero KeyPolicy has to be
+// set to disable the enforcement for a single method. The curve of ECDSA keys
+// is not part of the policy, since it is determined by the algorithm and always
+// checked, but a mismatch is reported as a [KeyPolicyError] as well.
+type KeyPolicy struct {
+	// MinHMACKeySize is the minimum size of HMAC keys in bytes.
+	MinHMACKeySize int
+
+	// RequireHMACHashSize requires HMAC keys to be at least as large as the
+	// output of the hash function, e.g. 32 bytes for HS256. See
+	// https://datatracker.ietf.org/doc/html/rfc7518#section-3.2
+	RequireHMACHashSize bool
+
+	// MinRSAKeySize is the minimum size of the RSA modulus in bits. See
+	// https://datatracker.ietf.org/doc/html/rfc7518#section-3.3
+	MinRSAKeySize int
+}
+
+// DefaultKeyPolicy is the policy used by signing methods which do not specify
+// their own. It enforces the minimum key sizes required by RFC 7518. Setting it
+// to nil disables the enforcement.
+var DefaultKeyPolicy = &KeyPolicy{
+	RequireHMACHashSize: true,
+	MinRSAKeySize:       2048,
+}
+
+// KeyPolicyError is returned by the signing methods, if a key violates the
+// [KeyPolicy] or, for ECDSA, the curve does not match the algorithm. It wraps
+// the ErrInvalidKey error.
+type KeyPolicyError struct {
+	Alg    string // Alg is the algorithm of the signing method
+	Reason string // Reason describes the violation
+}
+
+func (e *KeyPolicyError) Error() string {
+	return fmt.Sprintf("%s: %s key violates policy: %s", internal.ErrInvalidKey, e.Alg, e.Reason)
+}
+
+// Unwrap returns the ErrInvalidKey error.
+func (e *KeyPolicyError) Unwrap() error {
+	return internal.ErrInvalidKey
+}
+
+// keyPolicy returns policy, or DefaultKeyPolicy if policy is nil.
+func keyPolicy(policy *KeyPolicy) *KeyPolicy {
+	if policy != nil {
+		return policy
+	}
+
+	return DefaultKeyPolicy
+}
+
+// checkHMAC checks the size of a HMAC key.
+func (p *KeyPolicy) checkHMAC(m *SigningMethodHMAC, key []byte) error {
+	if p == nil {
+		return nil
+	}
+
+	min := p.MinHMACKeySize
+	if p.RequireHMACHashSize && m.Hash.Size() > min {
+		min = m.Hash.Size()
+	}
+
+	if len(key) < min {
+		return &KeyPolicyError{Alg: m.Alg(), Reason: fmt.Sprintf("size is %d bytes, at least %d bytes are required", len(key), min)}
+	}
+
+	return nil
+}
+
+// checkRSA checks the size of the modulus of a RSA key.
+func (p *KeyPolicy) checkRSA(alg string, key *rsa.PublicKey) error {
+	if p == nil {
+		return nil
+	}
+
+	if size := key.N.BitLen(); size < p.MinRSAKeySize {
+		return &KeyPolicyError{Alg: alg, Reason: fmt.Sprintf("modulus is %d bits, at least %d bits are required", size, p.MinRSAKeySize)}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package method_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/method"
+)
+
+// wantKeyPolicyError checks that err is a *KeyPolicyError of alg, which wraps
+// ErrInvalidKey.
+func wantKeyPolicyError(t *testing.T, err error, alg string) {
+	t.Helper()
+
+	var policyErr *method.KeyPolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("error = %v, want *KeyPolicyError", err)
+	}
+	if policyErr.Alg != alg || policyErr.Reason == "" {
+		t.Fatalf("KeyPolicyError = %+v, want reason for %s", policyErr, alg)
+	}
+	if !errors.Is(err, internal.ErrInvalidKey) {
+		t.Fatalf("error = %v, want %v", err, internal.ErrInvalidKey)
+	}
+}
+
+func TestKeyPolicyHMAC(t *testing.T) {
+	tests := []struct {
+		method  *method.SigningMethodHMAC
+		size    int
+		wantErr bool
+	}{
+		{method.SigningMethodHS256, 32, false},
+		{method.SigningMethodHS256, 31, true},
+		{method.SigningMethodHS384, 32, true},
+		{method.SigningMethodHS384, 48, false},
+		{method.SigningMethodHS512, 63, true},
+		{method.SigningMethodHS512, 64, false},
+	}
+
+	for _, tt := range tests {
+		key := make([]byte, tt.size)
+		sig, err := tt.method.Sign("header.payload", key)
+		if !tt.wantErr {
+			if err != nil {
+				t.Fatalf("%s Sign() with %d bytes error = %v", tt.method.Alg(), tt.size, err)
+			}
+			if err = tt.method.Verify("header.payload", sig, key); err != nil {
+				t.Fatalf("%s Verify() with %d bytes error = %v", tt.method.Alg(), tt.size, err)
+			}
+			continue
+		}
+
+		wantKeyPolicyError(t, err, tt.method.Alg())
+		// Short keys are rejected before the signature is checked
+		wantKeyPolicyError(t, tt.method.Verify("header.payload", make([]byte, tt.method.Hash.Size()), key), tt.method.Alg())
+	}
+}
+
+func TestKeyPolicyRSA(t *testing.T) {
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strong, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = method.SigningMethodRS256.Sign("header.payload", weak)
+	wantKeyPolicyError(t, err, "RS256")
+	_, err = method.SigningMethodPS256.Sign("header.payload", weak)
+	wantKeyPolicyError(t, err, "PS256")
+
+	// A method with its own policy accepts the weak key, while the default
+	// policy still rejects its signatures
+	legacy := &method.SigningMethodRSA{Name: "RS256", Hash: crypto.SHA256, KeyPolicy: &method.KeyPolicy{MinRSAKeySize: 1024}}
+	sig, err := legacy.Sign("header.payload", weak)
+	if err != nil {
+		t.Fatalf("Sign() with custom policy error = %v", err)
+	}
+	if err = legacy.Verify("header.payload", sig, &weak.PublicKey); err != nil {
+		t.Fatalf("Verify() with custom policy error = %v", err)
+	}
+	wantKeyPolicyError(t, method.SigningMethodRS256.Verify("header.payload", sig, &weak.PublicKey), "RS256")
+
+	// The custom policy is enforced as well
+	strict := &method.SigningMethodRSA{Name: "RS256", Hash: crypto.SHA256, KeyPolicy: &method.KeyPolicy{MinRSAKeySize: 3072}}
+	_, err = strict.Sign("header.payload", strong)
+	wantKeyPolicyError(t, err, "RS256")
+	if _, err = method.SigningMethodRS256.Sign("header.payload", strong); err != nil {
+		t.Fatalf("Sign() with 2048 bits error = %v", err)
+	}
+}
+
+func TestKeyPolicyCustomHMAC(t *testing.T) {
+	m := &method.SigningMethodHMAC{Name: "HS256", Hash: crypto.SHA256, KeyPolicy: &method.KeyPolicy{MinHMACKeySize: 64}}
+	_, err := m.Sign("header.payload", make([]byte, 32))
+	wantKeyPolicyError(t, err, "HS256")
+	if _, err = m.Sign("header.payload", make([]byte, 64)); err != nil {
+		t.Fatalf("Sign() with 64 bytes error = %v", err)
+	}
+
+	// A zero policy disables the enforcement for a single method
+	m = &method.SigningMethodHMAC{Name: "HS256", Hash: crypto.SHA256, KeyPolicy: &method.KeyPolicy{}}
+	if _, err = m.Sign("header.payload", make([]byte, 8)); err != nil {
+		t.Fatalf("Sign() with zero policy error = %v", err)
+	}
+
+	// Without DefaultKeyPolicy, methods without a policy enforce nothing
+	policy := method.DefaultKeyPolicy
+	method.DefaultKeyPolicy = nil
+	defer func() { method.DefaultKeyPolicy = policy }()
+	if _, err = method.SigningMethodHS256.Sign("header.payload", make([]byte, 8)); err != nil {
+		t.Fatalf("Sign() without default policy error = %v", err)
+	}
+}
+
+func TestKeyPolicyECDSACurve(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = method.SigningMethodES256.Sign("header.payload", key)
+	wantKeyPolicyError(t, err, "ES256")
+	wantKeyPolicyError(t, method.SigningMethodES256.Verify("header.payload", make([]byte, 64), &key.PublicKey), "ES256")
+}
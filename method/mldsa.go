@@ -0,0 +1,128 @@
+//go:build go1.27
+// +build go1.27
+
+package method
+
+import (
+	"crypto"
+	"crypto/mldsa"
+	"crypto/rand"
+	"errors"
+
+	"github.com/lkyzhu/xwt/internal"
+)
+
+var (
+	ErrMLDSAVerification = errors.New("mldsa: verification error")
+)
+
+// SigningMethodMLDSA implements the post-quantum ML-DSA family of signing
+// methods, as specified in FIPS 204. The algorithm names follow
+// https://datatracker.ietf.org/doc/draft-ietf-cose-dilithium.
+// Expects *mldsa.PrivateKey (or any other crypto.Signer with a
+// *mldsa.PublicKey) for signing and *mldsa.PublicKey for verification. The
+// parameter set of the key must match the one of the signing method.
+//
+// The signing string is signed directly ("pure" ML-DSA) with an empty context.
+type SigningMethodMLDSA struct {
+	Name   string
+	Params mldsa.Parameters
+}
+
+// Specific instances for ML-DSA-44 and company
+var (
+	SigningMethodMLDSA44 *SigningMethodMLDSA
+	SigningMethodMLDSA65 *SigningMethodMLDSA
+	SigningMethodMLDSA87 *SigningMethodMLDSA
+)
+
+func init() {
+	// ML-DSA-44
+	SigningMethodMLDSA44 = &SigningMethodMLDSA{Name: "ML-DSA-44", Params: mldsa.MLDSA44()}
+	RegisterSigningMethod(SigningMethodMLDSA44.Alg(), func() SigningMethod {
+		return SigningMethodMLDSA44
+	})
+
+	// ML-DSA-65
+	SigningMethodMLDSA65 = &SigningMethodMLDSA{Name: "ML-DSA-65", Params: mldsa.MLDSA65()}
+	RegisterSigningMethod(SigningMethodMLDSA65.Alg(), func() SigningMethod {
+		return SigningMethodMLDSA65
+	})
+
+	// ML-DSA-87
+	SigningMethodMLDSA87 = &SigningMethodMLDSA{Name: "ML-DSA-87", Params: mldsa.MLDSA87()}
+	RegisterSigningMethod(SigningMethodMLDSA87.Alg(), func() SigningMethod {
+		return SigningMethodMLDSA87
+	})
+}
+
+func (m *SigningMethodMLDSA) Alg() string {
+	return m.Name
+}
+
+// GenerateKey generates a new private key for the parameter set of the
+// signing method.
+func (m *SigningMethodMLDSA) GenerateKey() (*mldsa.PrivateKey, error) {
+	return mldsa.GenerateKey(m.Params)
+}
+
+// Verify implements token verification for the SigningMethod.
+// For this verify method, key must be an *mldsa.PublicKey
+func (m *SigningMethodMLDSA) Verify(signingString string, sig []byte, key interface{}) error {
+	var mldsaKey *mldsa.PublicKey
+	var ok bool
+
+	if mldsaKey, ok = key.(*mldsa.PublicKey); !ok {
+		return internal.NewError("ML-DSA verify expects *mldsa.PublicKey", internal.ErrInvalidKeyType)
+	}
+
+	return m.verify(mldsaKey, []byte(signingString), sig, nil)
+}
+
+// Sign implements token signing for the SigningMethod.
+// For this signing method, key must be an *mldsa.PrivateKey or any other
+// crypto.Signer or SignerWithContext with an *mldsa.PublicKey
+func (m *SigningMethodMLDSA) Sign(signingString string, key interface{}) ([]byte, error) {
+	var mldsaKey crypto.Signer
+	var ok bool
+
+	if mldsaKey, ok = asSigner(key); !ok {
+		return nil, internal.NewError("ML-DSA sign expects crypto.Signer", internal.ErrInvalidKeyType)
+	}
+
+	return m.sign(mldsaKey, []byte(signingString), &mldsa.Options{})
+}
+
+// verify verifies sig over message using the parameter set of the signing
+// method.
+func (m *SigningMethodMLDSA) verify(key *mldsa.PublicKey, message, sig []byte, opts *mldsa.Options) error {
+	if key.Parameters() != m.Params {
+		return internal.ErrInvalidKey
+	}
+
+	if len(sig) != m.Params.SignatureSize() {
+		return ErrMLDSAVerification
+	}
+
+	if err := mldsa.Verify(key, message, sig, opts); err != nil {
+		return ErrMLDSAVerification
+	}
+
+	return nil
+}
+
+// sign signs message using the parameter set of the signing method.
+func (m *SigningMethodMLDSA) sign(key crypto.Signer, message []byte, opts *mldsa.Options) ([]byte, error) {
+	if pub, ok := key.Public().(*mldsa.PublicKey); !ok || pub.Parameters() != m.Params {
+		return nil, internal.ErrInvalidKey
+	}
+
+	// ML-DSA signs the message itself rather than a digest, as indicated by
+	// the zero hash function of mldsa.Options
+	sig, err := key.Sign(rand.Reader, message, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return sig, nil
+}
@@ -0,0 +1,189 @@
+//go:build go1.27
+// +build go1.27
+
+package method
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/mldsa"
+	"errors"
+
+	"github.com/lkyzhu/xwt/internal"
+)
+
+var (
+	ErrCompositeVerification = errors.New("composite: verification error")
+)
+
+// SigningMethodComposite implements hybrid signing methods, which sign the
+// token with both a post-quantum ML-DSA key and a classical Ed25519 key. A
+// signature is only valid if both of its components can be verified, so the
+// token stays secure as long as either of the algorithms is not broken.
+// Expects *CompositePrivateKey for signing and *CompositePublicKey for
+// verification.
+//
+// The signature is the concatenation of the ML-DSA signature and the Ed25519
+// signature of the signing string. The ML-DSA signature is created using the
+// algorithm name as context, so that it cannot be stripped off and passed as a
+// stand-alone ML-DSA signature.
+//
+// This construction is specific to this library. It is not interoperable with
+// the composite signatures of
+// https://datatracker.ietf.org/doc/draft-ietf-lamps-pq-composite-sigs and
+// https://datatracker.ietf.org/doc/draft-ietf-jose-pq-composite-sigs, which
+// pre-hash the signing string and sign it with a different prefix and context.
+// The algorithm names are prefixed with "XWT-", so that they do not collide
+// with the names registered by these drafts.
+type SigningMethodComposite struct {
+	Name  string
+	MLDSA *SigningMethodMLDSA
+}
+
+// Specific instances for ML-DSA-44 and ML-DSA-65 with Ed25519
+var (
+	SigningMethodMLDSA44Ed25519 *SigningMethodComposite
+	SigningMethodMLDSA65Ed25519 *SigningMethodComposite
+)
+
+func init() {
+	// The ML-DSA components are created here, rather than referring to
+	// SigningMethodMLDSA44 and company, which might not be initialized yet
+
+	// XWT-ML-DSA-44-Ed25519
+	SigningMethodMLDSA44Ed25519 = &SigningMethodComposite{
+		Name:  "XWT-ML-DSA-44-Ed25519",
+		MLDSA: &SigningMethodMLDSA{Name: "ML-DSA-44", Params: mldsa.MLDSA44()},
+	}
+	RegisterSigningMethod(SigningMethodMLDSA44Ed25519.Alg(), func() SigningMethod {
+		return SigningMethodMLDSA44Ed25519
+	})
+
+	// XWT-ML-DSA-65-Ed25519
+	SigningMethodMLDSA65Ed25519 = &SigningMethodComposite{
+		Name:  "XWT-ML-DSA-65-Ed25519",
+		MLDSA: &SigningMethodMLDSA{Name: "ML-DSA-65", Params: mldsa.MLDSA65()},
+	}
+	RegisterSigningMethod(SigningMethodMLDSA65Ed25519.Alg(), func() SigningMethod {
+		return SigningMethodMLDSA65Ed25519
+	})
+}
+
+// CompositePrivateKey is the private key of a [SigningMethodComposite]. Each
+// component can be any crypto.Signer, e.g. a key held by a KMS.
+type CompositePrivateKey struct {
+	MLDSA   crypto.Signer // MLDSA is a signer with an *mldsa.PublicKey
+	Ed25519 crypto.Signer // Ed25519 is a signer with an ed25519.PublicKey
+}
+
+// Public returns the public key of both components.
+func (k *CompositePrivateKey) Public() *CompositePublicKey {
+	pub := &CompositePublicKey{}
+	pub.MLDSA, _ = k.MLDSA.Public().(*mldsa.PublicKey)
+	pub.Ed25519, _ = k.Ed25519.Public().(ed25519.PublicKey)
+
+	return pub
+}
+
+// CompositePublicKey is the public key of a [SigningMethodComposite].
+type CompositePublicKey struct {
+	MLDSA   *mldsa.PublicKey
+	Ed25519 ed25519.PublicKey
+}
+
+func (m *SigningMethodComposite) Alg() string {
+	return m.Name
+}
+
+// GenerateKey generates a new composite private key for the signing method.
+func (m *SigningMethodComposite) GenerateKey() (*CompositePrivateKey, error) {
+	mldsaKey, err := m.MLDSA.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	_, ed25519Key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompositePrivateKey{MLDSA: mldsaKey, Ed25519: ed25519Key}, nil
+}
+
+// Verify implements token verification for the SigningMethod.
+// For this verify method, key must be a *CompositePublicKey
+func (m *SigningMethodComposite) Verify(signingString string, sig []byte, key interface{}) error {
+	var compositeKey *CompositePublicKey
+	var ok bool
+
+	if compositeKey, ok = key.(*CompositePublicKey); !ok {
+		return internal.NewError("composite verify expects *CompositePublicKey", internal.ErrInvalidKeyType)
+	}
+
+	if compositeKey.MLDSA == nil || len(compositeKey.Ed25519) != ed25519.PublicKeySize {
+		return internal.ErrInvalidKey
+	}
+
+	size := m.MLDSA.Params.SignatureSize()
+	if len(sig) != size+ed25519.SignatureSize {
+		return ErrCompositeVerification
+	}
+
+	// Both signatures need to be valid
+	if err := m.MLDSA.verify(compositeKey.MLDSA, []byte(signingString), sig[:size], m.options()); err != nil {
+		if errors.Is(err, ErrMLDSAVerification) {
+			return ErrCompositeVerification
+		}
+		return err
+	}
+
+	if !ed25519.Verify(compositeKey.Ed25519, []byte(signingString), sig[size:]) {
+		return ErrCompositeVerification
+	}
+
+	return nil
+}
+
+// Sign implements token signing for the SigningMethod.
+// For this signing method, key must be a *CompositePrivateKey, whose
+// components match the parameter set of the signing method and Ed25519.
+func (m *SigningMethodComposite) Sign(signingString string, key interface{}) ([]byte, error) {
+	var compositeKey *CompositePrivateKey
+	var ok bool
+
+	if compositeKey, ok = key.(*CompositePrivateKey); !ok {
+		return nil, internal.NewError("composite sign expects *CompositePrivateKey", internal.ErrInvalidKeyType)
+	}
+
+	if compositeKey.MLDSA == nil || compositeKey.Ed25519 == nil {
+		return nil, internal.ErrInvalidKey
+	}
+
+	// Check the type of both components upfront, so that no signature is
+	// created, if either of them does not match the algorithm
+	if pub, ok := compositeKey.MLDSA.Public().(*mldsa.PublicKey); !ok || pub.Parameters() != m.MLDSA.Params {
+		return nil, internal.NewError("composite sign expects an "+m.MLDSA.Alg()+" signer", internal.ErrInvalidKeyType)
+	}
+
+	if _, ok := compositeKey.Ed25519.Public().(ed25519.PublicKey); !ok {
+		return nil, internal.NewError("composite sign expects an Ed25519 signer", internal.ErrInvalidKeyType)
+	}
+
+	mldsaSig, err := m.MLDSA.sign(compositeKey.MLDSA, []byte(signingString), m.options())
+	if err != nil {
+		return nil, err
+	}
+
+	ed25519Sig, err := SigningMethodEdDSA.Sign(signingString, compositeKey.Ed25519)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(mldsaSig, ed25519Sig...), nil
+}
+
+// options returns the options of the ML-DSA component, which binds it to the
+// composite algorithm.
+func (m *SigningMethodComposite) options() *mldsa.Options {
+	return &mldsa.Options{Context: m.Name}
+}
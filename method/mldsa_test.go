@@ -0,0 +1,369 @@
+//go:build go1.27
+// +build go1.27
+
+package method_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/mldsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/cloudflare/circl/sign"
+	"github.com/cloudflare/circl/sign/ed448"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/method"
+)
+
+const mldsaSigningString = "eyJhbGciOiJNTC1EU0EtNDQifQ.eyJzdWIiOiJhbGljZSJ9"
+
+// mldsaSeed returns the seed 00 01 ... 1f.
+func mldsaSeed() []byte {
+	seed := make([]byte, mldsa.PrivateKeySize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	return seed
+}
+
+// deterministicSigner signs using the deterministic variant of ML-DSA, so that
+// signatures can be compared against known answers.
+type deterministicSigner struct {
+	*mldsa.PrivateKey
+}
+
+func (s deterministicSigner) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.SignDeterministic(message, opts)
+}
+
+// mldsaKeyGenVector is a keyGen test case of the ML-DSA-keyGen-FIPS204 vector
+// set of the NIST ACVP server, see https://github.com/usnistgov/ACVP-Server.
+type mldsaKeyGenVector struct {
+	TcID         int    `json:"tcId"`
+	ParameterSet string `json:"parameterSet"`
+	Seed         string `json:"seed"`
+	PK           string `json:"pk"`
+}
+
+func loadMLDSAKeyGenVectors(t *testing.T) []mldsaKeyGenVector {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/mldsa_keygen.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors []mldsaKeyGenVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	return vectors
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+var mldsaMethods = map[string]*method.SigningMethodMLDSA{
+	"ML-DSA-44": method.SigningMethodMLDSA44,
+	"ML-DSA-65": method.SigningMethodMLDSA65,
+	"ML-DSA-87": method.SigningMethodMLDSA87,
+}
+
+// The ACVP sigGen vectors exercise ML-DSA.Sign_internal, which is not exposed
+// by crypto/mldsa, so the signatures are compared against the deterministic
+// signatures of an independent implementation instead.
+var mldsaReferences = map[string]sign.Scheme{
+	"ML-DSA-44": mldsa44.Scheme(),
+	"ML-DSA-65": mldsa65.Scheme(),
+	"ML-DSA-87": mldsa87.Scheme(),
+}
+
+func TestMLDSAKnownAnswer(t *testing.T) {
+	for _, v := range loadMLDSAKeyGenVectors(t) {
+		t.Run(fmt.Sprintf("%s/%d", v.ParameterSet, v.TcID), func(t *testing.T) {
+			m, scheme := mldsaMethods[v.ParameterSet], mldsaReferences[v.ParameterSet]
+			seed, pk := mustDecodeHex(t, v.Seed), mustDecodeHex(t, v.PK)
+
+			priv, err := mldsa.NewPrivateKey(m.Params, seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := priv.PublicKey().Bytes(); !bytes.Equal(got, pk) {
+				t.Fatalf("public key = %X, want %X", got, pk)
+			}
+
+			refPub, refPriv := scheme.DeriveKey(seed)
+			if got, err := refPub.MarshalBinary(); err != nil || !bytes.Equal(got, pk) {
+				t.Fatalf("public key of the reference = %X, %v, want %X", got, err, pk)
+			}
+
+			sig, err := m.Sign(mldsaSigningString, deterministicSigner{priv})
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if want := scheme.Sign(refPriv, []byte(mldsaSigningString), nil); !bytes.Equal(sig, want) {
+				t.Fatalf("signature = %X, want %X", sig, want)
+			}
+			if err := m.Verify(mldsaSigningString, sig, priv.PublicKey()); err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			// Hedged signatures differ, but are valid as well.
+			sig, err = m.Sign(mldsaSigningString, priv)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if err := m.Verify(mldsaSigningString, sig, priv.PublicKey()); err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			sig[0] ^= 1
+			if err := m.Verify(mldsaSigningString, sig, priv.PublicKey()); !errors.Is(err, method.ErrMLDSAVerification) {
+				t.Fatalf("Verify() of modified signature error = %v, want %v", err, method.ErrMLDSAVerification)
+			}
+		})
+	}
+}
+
+func TestMLDSAParameters(t *testing.T) {
+	priv, err := method.SigningMethodMLDSA65.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := method.SigningMethodMLDSA44.Sign(mldsaSigningString, priv); !errors.Is(err, internal.ErrInvalidKey) {
+		t.Fatalf("Sign() with ML-DSA-65 key error = %v, want %v", err, internal.ErrInvalidKey)
+	}
+
+	sig, err := method.SigningMethodMLDSA65.Sign(mldsaSigningString, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := method.SigningMethodMLDSA44.Verify(mldsaSigningString, sig, priv.PublicKey()); !errors.Is(err, internal.ErrInvalidKey) {
+		t.Fatalf("Verify() with ML-DSA-65 key error = %v, want %v", err, internal.ErrInvalidKey)
+	}
+}
+
+func TestMLDSACompositeKnownAnswer(t *testing.T) {
+	methods := map[string]*method.SigningMethodComposite{
+		"ML-DSA-44": method.SigningMethodMLDSA44Ed25519,
+		"ML-DSA-65": method.SigningMethodMLDSA65Ed25519,
+	}
+
+	for _, v := range loadMLDSAKeyGenVectors(t) {
+		m, ok := methods[v.ParameterSet]
+		if !ok {
+			continue
+		}
+
+		t.Run(fmt.Sprintf("%s/%d", m.Alg(), v.TcID), func(t *testing.T) {
+			scheme := mldsaReferences[v.ParameterSet]
+			seed := mustDecodeHex(t, v.Seed)
+
+			mldsaKey, err := mldsa.NewPrivateKey(m.MLDSA.Params, seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ed25519Key := ed25519.NewKeyFromSeed(seed)
+			priv := &method.CompositePrivateKey{
+				MLDSA:   deterministicSigner{mldsaKey},
+				Ed25519: ed25519Key,
+			}
+
+			sig, err := m.Sign(mldsaSigningString, priv)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+
+			// The ML-DSA component is signed with the algorithm name as
+			// context, followed by the plain Ed25519 signature.
+			_, refPriv := scheme.DeriveKey(seed)
+			want := scheme.Sign(refPriv, []byte(mldsaSigningString), &sign.SignatureOpts{Context: m.Alg()})
+			want = append(want, ed25519.Sign(ed25519Key, []byte(mldsaSigningString))...)
+			if !bytes.Equal(sig, want) {
+				t.Fatalf("signature = %X, want %X", sig, want)
+			}
+			if err := m.Verify(mldsaSigningString, sig, priv.Public()); err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			// Both components need to be valid.
+			size := m.MLDSA.Params.SignatureSize()
+			for _, i := range []int{0, size} {
+				modified := append([]byte{}, sig...)
+				modified[i] ^= 1
+				if err := m.Verify(mldsaSigningString, modified, priv.Public()); !errors.Is(err, method.ErrCompositeVerification) {
+					t.Fatalf("Verify() with modified byte %d error = %v, want %v", i, err, method.ErrCompositeVerification)
+				}
+			}
+
+			// The ML-DSA component is bound to the composite algorithm.
+			if err := m.MLDSA.Verify(mldsaSigningString, sig[:size], mldsaKey.PublicKey()); !errors.Is(err, method.ErrMLDSAVerification) {
+				t.Fatalf("Verify() of stripped signature error = %v, want %v", err, method.ErrMLDSAVerification)
+			}
+		})
+	}
+}
+
+func TestMLDSACompositeKeyType(t *testing.T) {
+	m := method.SigningMethodMLDSA44Ed25519
+
+	mldsa44Key, err := mldsa.NewPrivateKey(mldsa.MLDSA44(), mldsaSeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mldsa65Key, err := mldsa.NewPrivateKey(mldsa.MLDSA65(), mldsaSeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519Key := ed25519.NewKeyFromSeed(mldsaSeed())
+	ed448Key := ed448.NewKeyFromSeed(make([]byte, ed448.SeedSize))
+
+	tests := []struct {
+		name string
+		key  *method.CompositePrivateKey
+	}{
+		{"ML-DSA-65", &method.CompositePrivateKey{MLDSA: mldsa65Key, Ed25519: ed25519Key}},
+		{"Ed25519 as ML-DSA", &method.CompositePrivateKey{MLDSA: ed25519Key, Ed25519: ed25519Key}},
+		{"Ed448", &method.CompositePrivateKey{MLDSA: mldsa44Key, Ed25519: ed448Key}},
+		{"ML-DSA as Ed25519", &method.CompositePrivateKey{MLDSA: mldsa44Key, Ed25519: mldsa44Key}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.Sign(mldsaSigningString, tt.key); !errors.Is(err, internal.ErrInvalidKeyType) {
+				t.Fatalf("Sign() error = %v, want %v", err, internal.ErrInvalidKeyType)
+			}
+		})
+	}
+
+	if _, err := m.Sign(mldsaSigningString, &method.CompositePrivateKey{MLDSA: mldsa44Key}); !errors.Is(err, internal.ErrInvalidKey) {
+		t.Fatalf("Sign() without Ed25519 key error = %v, want %v", err, internal.ErrInvalidKey)
+	}
+}
+
+func TestMLDSAPEM(t *testing.T) {
+	priv, err := mldsa.NewPrivateKey(mldsa.MLDSA44(), mldsaSeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(priv.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedPriv, err := method.ParseMLDSAPrivateKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))
+	if err != nil {
+		t.Fatalf("ParseMLDSAPrivateKeyFromPEM() error = %v", err)
+	}
+	if !parsedPriv.Equal(priv) {
+		t.Fatal("parsed private key differs")
+	}
+
+	parsedPub, err := method.ParseMLDSAPublicKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	if err != nil {
+		t.Fatalf("ParseMLDSAPublicKeyFromPEM() error = %v", err)
+	}
+	if !parsedPub.Equal(priv.PublicKey()) {
+		t.Fatal("parsed public key differs")
+	}
+
+	if _, err := method.ParseMLDSAPublicKeyFromPEM([]byte("not pem")); !errors.Is(err, method.ErrKeyMustBePEMEncoded) {
+		t.Fatalf("ParseMLDSAPublicKeyFromPEM() error = %v, want %v", err, method.ErrKeyMustBePEMEncoded)
+	}
+}
+
+func TestMLDSAJWK(t *testing.T) {
+	for _, m := range []*method.SigningMethodMLDSA{method.SigningMethodMLDSA44, method.SigningMethodMLDSA65, method.SigningMethodMLDSA87} {
+		t.Run(m.Alg(), func(t *testing.T) {
+			priv, err := mldsa.NewPrivateKey(m.Params, mldsaSeed())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			privJWK, err := method.MarshalMLDSAPrivateKeyToJWK(priv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pubJWK, err := method.MarshalMLDSAPublicKeyToJWK(priv.PublicKey())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsedPriv, err := method.ParseMLDSAPrivateKeyFromJWK(privJWK)
+			if err != nil {
+				t.Fatalf("ParseMLDSAPrivateKeyFromJWK() error = %v", err)
+			}
+			if !parsedPriv.Equal(priv) {
+				t.Fatal("parsed private key differs")
+			}
+
+			parsedPub, err := method.ParseMLDSAPublicKeyFromJWK(pubJWK)
+			if err != nil {
+				t.Fatalf("ParseMLDSAPublicKeyFromJWK() error = %v", err)
+			}
+			if !parsedPub.Equal(priv.PublicKey()) {
+				t.Fatal("parsed public key differs")
+			}
+		})
+	}
+
+	// The seed must belong to the public key.
+	pub, err := method.SigningMethodMLDSA44.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := mldsa.NewPrivateKey(mldsa.MLDSA44(), mldsaSeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubJWK, err := method.MarshalMLDSAPublicKeyToJWK(pub.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	privJWK, err := method.MarshalMLDSAPrivateKeyToJWK(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mixed, seed map[string]string
+	if err := json.Unmarshal(pubJWK, &mixed); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(privJWK, &seed); err != nil {
+		t.Fatal(err)
+	}
+	mixed["priv"] = seed["priv"]
+	mixedJWK, err := json.Marshal(mixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := method.ParseMLDSAPrivateKeyFromJWK(mixedJWK); !errors.Is(err, method.ErrNotMLDSAPrivateKey) {
+		t.Fatalf("ParseMLDSAPrivateKeyFromJWK() of mismatching key error = %v, want %v", err, method.ErrNotMLDSAPrivateKey)
+	}
+}
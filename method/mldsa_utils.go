@@ -0,0 +1,182 @@
+//go:build go1.27
+// +build go1.27
+
+package method
+
+import (
+	"crypto/mldsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+)
+
+var (
+	ErrNotMLDSAPrivateKey = errors.New("key is not a valid ML-DSA private key")
+	ErrNotMLDSAPublicKey  = errors.New("key is not a valid ML-DSA public key")
+)
+
+// ParseMLDSAPrivateKeyFromPEM parses a PEM encoded PKCS8 ML-DSA private key, as
+// specified in https://datatracker.ietf.org/doc/html/rfc9881. Only the seed
+// format is supported. Use [x509.MarshalPKCS8PrivateKey] to create one.
+func ParseMLDSAPrivateKeyFromPEM(key []byte) (*mldsa.PrivateKey, error) {
+	var err error
+
+	// Parse PEM block
+	var block *pem.Block
+	if block, _ = pem.Decode(key); block == nil {
+		return nil, ErrKeyMustBePEMEncoded
+	}
+
+	// Parse the key
+	var parsedKey interface{}
+	if parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		return nil, err
+	}
+
+	var pkey *mldsa.PrivateKey
+	var ok bool
+	if pkey, ok = parsedKey.(*mldsa.PrivateKey); !ok {
+		return nil, ErrNotMLDSAPrivateKey
+	}
+
+	return pkey, nil
+}
+
+// ParseMLDSAPublicKeyFromPEM parses a PEM encoded PKIX ML-DSA public key or a
+// certificate containing one. Use [x509.MarshalPKIXPublicKey] to create one.
+func ParseMLDSAPublicKeyFromPEM(key []byte) (*mldsa.PublicKey, error) {
+	var err error
+
+	// Parse PEM block
+	var block *pem.Block
+	if block, _ = pem.Decode(key); block == nil {
+		return nil, ErrKeyMustBePEMEncoded
+	}
+
+	// Parse the key
+	var parsedKey interface{}
+	if parsedKey, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			parsedKey = cert.PublicKey
+		} else {
+			return nil, err
+		}
+	}
+
+	var pkey *mldsa.PublicKey
+	var ok bool
+	if pkey, ok = parsedKey.(*mldsa.PublicKey); !ok {
+		return nil, ErrNotMLDSAPublicKey
+	}
+
+	return pkey, nil
+}
+
+// jsonWebKeyAKP reflects the members of an "AKP" (Algorithm Key Pair) JSON Web
+// Key, as used for ML-DSA in
+// https://datatracker.ietf.org/doc/draft-ietf-cose-dilithium. The private key
+// is represented by its seed.
+type jsonWebKeyAKP struct {
+	Kty  string `json:"kty"`
+	Alg  string `json:"alg"`
+	Pub  string `json:"pub"`
+	Priv string `json:"priv,omitempty"`
+}
+
+// ParseMLDSAPublicKeyFromJWK parses a JSON Web Key of type "AKP" with one of
+// the algorithms ML-DSA-44, ML-DSA-65 or ML-DSA-87.
+func ParseMLDSAPublicKeyFromJWK(key []byte) (*mldsa.PublicKey, error) {
+	var jwk jsonWebKeyAKP
+	if err := json.Unmarshal(key, &jwk); err != nil {
+		return nil, err
+	}
+
+	return jwk.publicKey()
+}
+
+// ParseMLDSAPrivateKeyFromJWK parses a JSON Web Key of type "AKP" containing
+// the seed of the private key in the "priv" member.
+func ParseMLDSAPrivateKeyFromJWK(key []byte) (*mldsa.PrivateKey, error) {
+	var jwk jsonWebKeyAKP
+	if err := json.Unmarshal(key, &jwk); err != nil {
+		return nil, err
+	}
+
+	pub, err := jwk.publicKey()
+	if err != nil {
+		return nil, ErrNotMLDSAPrivateKey
+	}
+
+	seed, err := base64.RawURLEncoding.DecodeString(jwk.Priv)
+	if err != nil || len(seed) != mldsa.PrivateKeySize {
+		return nil, ErrNotMLDSAPrivateKey
+	}
+
+	pkey, err := mldsa.NewPrivateKey(pub.Parameters(), seed)
+	if err != nil {
+		return nil, ErrNotMLDSAPrivateKey
+	}
+
+	// Make sure the private key belongs to the public key
+	if !pkey.PublicKey().Equal(pub) {
+		return nil, ErrNotMLDSAPrivateKey
+	}
+
+	return pkey, nil
+}
+
+// MarshalMLDSAPublicKeyToJWK converts an ML-DSA public key into a JSON Web Key
+// of type "AKP".
+func MarshalMLDSAPublicKeyToJWK(key *mldsa.PublicKey) ([]byte, error) {
+	return json.Marshal(jsonWebKeyAKP{
+		Kty: "AKP",
+		Alg: key.Parameters().String(),
+		Pub: base64.RawURLEncoding.EncodeToString(key.Bytes()),
+	})
+}
+
+// MarshalMLDSAPrivateKeyToJWK converts an ML-DSA private key into a JSON Web
+// Key of type "AKP", containing both the public key and the seed.
+func MarshalMLDSAPrivateKeyToJWK(key *mldsa.PrivateKey) ([]byte, error) {
+	pub := key.PublicKey()
+
+	return json.Marshal(jsonWebKeyAKP{
+		Kty:  "AKP",
+		Alg:  pub.Parameters().String(),
+		Pub:  base64.RawURLEncoding.EncodeToString(pub.Bytes()),
+		Priv: base64.RawURLEncoding.EncodeToString(key.Bytes()),
+	})
+}
+
+// publicKey returns the public key contained in the JSON Web Key.
+func (jwk *jsonWebKeyAKP) publicKey() (*mldsa.PublicKey, error) {
+	if jwk.Kty != "AKP" {
+		return nil, ErrNotMLDSAPublicKey
+	}
+
+	var params mldsa.Parameters
+	switch jwk.Alg {
+	case SigningMethodMLDSA44.Name:
+		params = SigningMethodMLDSA44.Params
+	case SigningMethodMLDSA65.Name:
+		params = SigningMethodMLDSA65.Params
+	case SigningMethodMLDSA87.Name:
+		params = SigningMethodMLDSA87.Params
+	default:
+		return nil, ErrNotMLDSAPublicKey
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(jwk.Pub)
+	if err != nil || len(b) != params.PublicKeySize() {
+		return nil, ErrNotMLDSAPublicKey
+	}
+
+	pub, err := mldsa.NewPublicKey(params, b)
+	if err != nil {
+		return nil, ErrNotMLDSAPublicKey
+	}
+
+	return pub, nil
+}
@@ -9,10 +9,13 @@ import (
 )
 
 // SigningMethodRSA implements the RSA family of signing methods.
-// Expects *rsa.PrivateKey for signing and *rsa.PublicKey for validation
+// Expects *rsa.PrivateKey (or any other crypto.Signer) for signing and
+// *rsa.PublicKey for validation
 type SigningMethodRSA struct {
 	Name string
 	Hash crypto.Hash
+	// KeyPolicy is optional. If set overrides DefaultKeyPolicy.
+	KeyPolicy *KeyPolicy
 }
 
 // Specific instances for RS256 and company
@@ -24,19 +27,19 @@ var (
 
 func init() {
 	// RS256
-	SigningMethodRS256 = &SigningMethodRSA{"RS256", crypto.SHA256}
+	SigningMethodRS256 = &SigningMethodRSA{Name: "RS256", Hash: crypto.SHA256}
 	RegisterSigningMethod(SigningMethodRS256.Alg(), func() SigningMethod {
 		return SigningMethodRS256
 	})
 
 	// RS384
-	SigningMethodRS384 = &SigningMethodRSA{"RS384", crypto.SHA384}
+	SigningMethodRS384 = &SigningMethodRSA{Name: "RS384", Hash: crypto.SHA384}
 	RegisterSigningMethod(SigningMethodRS384.Alg(), func() SigningMethod {
 		return SigningMethodRS384
 	})
 
 	// RS512
-	SigningMethodRS512 = &SigningMethodRSA{"RS512", crypto.SHA512}
+	SigningMethodRS512 = &SigningMethodRSA{Name: "RS512", Hash: crypto.SHA512}
 	RegisterSigningMethod(SigningMethodRS512.Alg(), func() SigningMethod {
 		return SigningMethodRS512
 	})
@@ -56,6 +59,10 @@ func (m *SigningMethodRSA) Verify(signingString string, sig []byte, key interfac
 		return internal.NewError("RSA verify expects *rsa.PublicKey", internal.ErrInvalidKeyType)
 	}
 
+	if err := keyPolicy(m.KeyPolicy).checkRSA(m.Alg(), rsaKey); err != nil {
+		return err
+	}
+
 	// Create hasher
 	if !m.Hash.Available() {
 		return internal.ErrHashUnavailable
@@ -68,14 +75,24 @@ func (m *SigningMethodRSA) Verify(signingString string, sig []byte, key interfac
 }
 
 // Sign implements token signing for the SigningMethod
-// For this signing method, must be an *rsa.PrivateKey structure.
+// For this signing method, key must be a crypto.Signer with an *rsa.PublicKey,
+// e.g. an *rsa.PrivateKey, or a SignerWithContext.
 func (m *SigningMethodRSA) Sign(signingString string, key interface{}) ([]byte, error) {
-	var rsaKey *rsa.PrivateKey
+	var signer crypto.Signer
 	var ok bool
 
 	// Validate type of key
-	if rsaKey, ok = key.(*rsa.PrivateKey); !ok {
-		return nil, internal.NewError("RSA sign expects *rsa.PrivateKey", internal.ErrInvalidKeyType)
+	if signer, ok = asSigner(key); !ok {
+		return nil, internal.NewError("RSA sign expects crypto.Signer", internal.ErrInvalidKeyType)
+	}
+
+	rsaKey, ok := signer.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, internal.ErrInvalidKey
+	}
+
+	if err := keyPolicy(m.KeyPolicy).checkRSA(m.Alg(), rsaKey); err != nil {
+		return nil, err
 	}
 
 	// Create the hasher
@@ -86,8 +103,9 @@ func (m *SigningMethodRSA) Sign(signingString string, key interface{}) ([]byte,
 	hasher := m.Hash.New()
 	hasher.Write([]byte(signingString))
 
-	// Sign the string and return the encoded bytes
-	if sigBytes, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, m.Hash, hasher.Sum(nil)); err == nil {
+	// Sign the string and return the encoded bytes. Passing a crypto.Hash as
+	// options selects PKCS #1 v1.5.
+	if sigBytes, err := signer.Sign(rand.Reader, hasher.Sum(nil), m.Hash); err == nil {
 		return sigBytes, nil
 	} else {
 		return nil, err
@@ -93,6 +93,10 @@ func (m *SigningMethodRSAPSS) Verify(signingString string, sig []byte, key inter
 		return internal.NewError("RSA-PSS verify expects *rsa.PublicKey", internal.ErrInvalidKeyType)
 	}
 
+	if err := keyPolicy(m.KeyPolicy).checkRSA(m.Alg(), rsaKey); err != nil {
+		return err
+	}
+
 	// Create hasher
 	if !m.Hash.Available() {
 		return internal.ErrHashUnavailable
@@ -109,15 +113,23 @@ func (m *SigningMethodRSAPSS) Verify(signingString string, sig []byte, key inter
 }
 
 // Sign implements token signing for the SigningMethod.
-// For this signing method, key must be an rsa.PrivateKey struct
+// For this signing method, key must be a crypto.Signer with an *rsa.PublicKey,
+// e.g. an *rsa.PrivateKey, or a SignerWithContext.
 func (m *SigningMethodRSAPSS) Sign(signingString string, key interface{}) ([]byte, error) {
-	var rsaKey *rsa.PrivateKey
+	var signer crypto.Signer
+	var ok bool
 
-	switch k := key.(type) {
-	case *rsa.PrivateKey:
-		rsaKey = k
-	default:
-		return nil, internal.NewError("RSA-PSS sign expects *rsa.PrivateKey", internal.ErrInvalidKeyType)
+	if signer, ok = asSigner(key); !ok {
+		return nil, internal.NewError("RSA-PSS sign expects crypto.Signer", internal.ErrInvalidKeyType)
+	}
+
+	rsaKey, ok := signer.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, internal.ErrInvalidKey
+	}
+
+	if err := keyPolicy(m.KeyPolicy).checkRSA(m.Alg(), rsaKey); err != nil {
+		return nil, err
 	}
 
 	// Create the hasher
@@ -128,8 +140,17 @@ func (m *SigningMethodRSAPSS) Sign(signingString string, key interface{}) ([]byt
 	hasher := m.Hash.New()
 	hasher.Write([]byte(signingString))
 
+	// The signer needs to know the hash, since it is part of the PSS encoding.
+	// Without Options, the salt length equals the hash size, as required by
+	// https://tools.ietf.org/html/rfc7518#section-3.5
+	opts := rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+	if m.Options != nil {
+		opts = *m.Options
+	}
+	opts.Hash = m.Hash
+
 	// Sign the string and return the encoded bytes
-	if sigBytes, err := rsa.SignPSS(rand.Reader, rsaKey, m.Hash, hasher.Sum(nil), m.Options); err == nil {
+	if sigBytes, err := signer.Sign(rand.Reader, hasher.Sum(nil), &opts); err == nil {
 		return sigBytes, nil
 	} else {
 		return nil, err
@@ -3,8 +3,11 @@ package method
 import (
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
+	"math/big"
 )
 
 var (
@@ -105,3 +108,45 @@ func ParseRSAPublicKeyFromPEM(key []byte) (*rsa.PublicKey, error) {
 
 	return pkey, nil
 }
+
+// jsonWebKeyRSA reflects the public members of a RSA JSON Web Key, as defined
+// in https://datatracker.ietf.org/doc/html/rfc7518#section-6.3.
+type jsonWebKeyRSA struct {
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// ParseRSAPublicKeyFromJWK parses a JSON Web Key of type "RSA". Private members
+// are ignored.
+func ParseRSAPublicKeyFromJWK(key []byte) (*rsa.PublicKey, error) {
+	var jwk jsonWebKeyRSA
+	if err := json.Unmarshal(key, &jwk); err != nil {
+		return nil, err
+	}
+
+	if jwk.Kty != "RSA" {
+		return nil, ErrNotRSAPublicKey
+	}
+
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil || len(n) == 0 || n[0] == 0 {
+		return nil, ErrNotRSAPublicKey
+	}
+
+	// The exponent must fit into an int and be odd
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil || len(e) == 0 || len(e) > 4 || e[0] == 0 {
+		return nil, ErrNotRSAPublicKey
+	}
+
+	pub := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}
+	if pub.E < 3 || pub.E&1 == 0 {
+		return nil, ErrNotRSAPublicKey
+	}
+
+	return pub, nil
+}
@@ -0,0 +1,89 @@
+package method
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secp256k1ecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// Secp256k1 returns the curve secp256k1, which is used by the ES256K signing
+// method specified in https://datatracker.ietf.org/doc/html/rfc8812. The
+// implementation of github.com/decred/dcrd/dcrec/secp256k1 is used.
+//
+// Private keys on this curve are signed by that implementation as well, with
+// deterministic nonces as specified in RFC 6979, rather than by crypto/ecdsa,
+// whose generic implementation of custom curves is not constant time.
+func Secp256k1() elliptic.Curve {
+	return secp256k1.S256()
+}
+
+// GenerateSecp256k1Key generates a new private key on the curve secp256k1,
+// reading randomness from rand, or crypto/rand.Reader if rand is nil.
+func GenerateSecp256k1Key(random io.Reader) (*ecdsa.PrivateKey, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+
+	priv, err := secp256k1.GeneratePrivateKeyFromRand(random)
+	if err != nil {
+		return nil, err
+	}
+
+	return priv.ToECDSA(), nil
+}
+
+// isSecp256k1 reports whether curve is secp256k1. Other implementations of
+// the curve are recognized by their name.
+func isSecp256k1(curve elliptic.Curve) bool {
+	return curve != nil && curve.Params().Name == "secp256k1"
+}
+
+// secp256k1Signer signs with a private key on the curve secp256k1. It
+// implements the crypto.Signer interface and returns ASN.1 DER encoded
+// signatures, just like *ecdsa.PrivateKey.
+type secp256k1Signer struct {
+	key *ecdsa.PrivateKey
+}
+
+// Public implements the crypto.Signer interface.
+func (s secp256k1Signer) Public() crypto.PublicKey {
+	return &s.key.PublicKey
+}
+
+// Sign implements the crypto.Signer interface. The nonce is derived from the
+// key and the digest, so rand is not used.
+func (s secp256k1Signer) Sign(_ io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	priv := secp256k1.PrivKeyFromBytes(s.key.D.FillBytes(make([]byte, secp256k1.PrivKeyBytesLen)))
+	defer priv.Zero()
+
+	return secp256k1ecdsa.Sign(priv, digest).Serialize(), nil
+}
+
+// verifySecp256k1 verifies the signature r || s of digest, each padded to 32
+// bytes.
+func verifySecp256k1(pub *ecdsa.PublicKey, digest, sig []byte) bool {
+	if pub.X.Sign() < 0 || pub.Y.Sign() < 0 || pub.X.BitLen() > 256 || pub.Y.BitLen() > 256 {
+		return false
+	}
+
+	var x, y secp256k1.FieldVal
+	if x.SetByteSlice(pub.X.Bytes()) || y.SetByteSlice(pub.Y.Bytes()) {
+		return false
+	}
+	key := secp256k1.NewPublicKey(&x, &y)
+	if !key.IsOnCurve() {
+		return false
+	}
+
+	var r, s secp256k1.ModNScalar
+	if r.SetByteSlice(sig[:32]) || s.SetByteSlice(sig[32:]) || r.IsZero() || s.IsZero() {
+		return false
+	}
+
+	return secp256k1ecdsa.NewSignature(&r, &s).Verify(digest, key)
+}
@@ -0,0 +1,156 @@
+package method_test
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/lkyzhu/xwt/method"
+)
+
+// The generator of secp256k1, i.e. the public key of the private key 1.
+const (
+	secp256k1Gx = "eb5mfvncu6xVoGKVzocLBwKb_NstzijZWfKBWxb4F5g"
+	secp256k1Gy = "SDradyajxGVdpPv8DhEIqP0XtEimhVQZnEfQj_sQ1Lg"
+	secp256k1D  = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE"
+)
+
+func TestES256K(t *testing.T) {
+	priv, err := method.GenerateSecp256k1Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := method.SigningMethodES256K.Sign("header.payload", priv)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if len(sig) != 64 {
+		t.Fatalf("len(sig) = %d, want 64", len(sig))
+	}
+	if err := method.SigningMethodES256K.Verify("header.payload", sig, &priv.PublicKey); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	// The nonce is derived according to RFC 6979, so signatures are
+	// deterministic.
+	again, err := method.SigningMethodES256K.Sign("header.payload", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, again) {
+		t.Fatal("signatures differ")
+	}
+
+	// RFC 8812 does not require a low s, so the negated s is valid as well.
+	n := method.Secp256k1().Params().N
+	s := new(big.Int).SetBytes(sig[32:])
+	high := append(append([]byte{}, sig[:32]...), new(big.Int).Sub(n, s).FillBytes(make([]byte, 32))...)
+	if err := method.SigningMethodES256K.Verify("header.payload", high, &priv.PublicKey); err != nil {
+		t.Fatalf("Verify() with high s error = %v", err)
+	}
+
+	if err := method.SigningMethodES256K.Verify("header.payload.", sig, &priv.PublicKey); err != method.ErrECDSAVerification {
+		t.Fatalf("Verify() of other content error = %v, want %v", err, method.ErrECDSAVerification)
+	}
+
+	// r and s must be smaller than the order.
+	overflow := append(n.FillBytes(make([]byte, 32)), sig[32:]...)
+	if err := method.SigningMethodES256K.Verify("header.payload", overflow, &priv.PublicKey); err != method.ErrECDSAVerification {
+		t.Fatalf("Verify() with r = n error = %v, want %v", err, method.ErrECDSAVerification)
+	}
+}
+
+func TestSecp256k1JWK(t *testing.T) {
+	pub, err := method.ParseECPublicKeyFromJWK([]byte(fmt.Sprintf(`{"kty":"EC","crv":"secp256k1","x":%q,"y":%q}`, secp256k1Gx, secp256k1Gy)))
+	if err != nil {
+		t.Fatalf("ParseECPublicKeyFromJWK() error = %v", err)
+	}
+	if params := method.Secp256k1().Params(); pub.X.Cmp(params.Gx) != 0 || pub.Y.Cmp(params.Gy) != 0 {
+		t.Fatal("public key is not the generator")
+	}
+
+	priv, err := method.ParseECPrivateKeyFromJWK([]byte(fmt.Sprintf(`{"kty":"EC","crv":"secp256k1","x":%q,"y":%q,"d":%q}`, secp256k1Gx, secp256k1Gy, secp256k1D)))
+	if err != nil {
+		t.Fatalf("ParseECPrivateKeyFromJWK() error = %v", err)
+	}
+
+	sig, err := method.SigningMethodES256K.Sign("header.payload", priv)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if err := method.SigningMethodES256K.Verify("header.payload", sig, pub); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	// The private key must belong to the public key.
+	if _, err := method.ParseECPrivateKeyFromJWK([]byte(fmt.Sprintf(`{"kty":"EC","crv":"secp256k1","x":%q,"y":%q,"d":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAI"}`, secp256k1Gx, secp256k1Gy))); err == nil {
+		t.Fatal("ParseECPrivateKeyFromJWK() of mismatching key succeeded")
+	}
+
+	// The point must be on the curve.
+	if _, err := method.ParseECPublicKeyFromJWK([]byte(fmt.Sprintf(`{"kty":"EC","crv":"secp256k1","x":%q,"y":%q}`, secp256k1Gx, secp256k1Gx))); err == nil {
+		t.Fatal("ParseECPublicKeyFromJWK() of invalid point succeeded")
+	}
+}
+
+func TestSecp256k1PEM(t *testing.T) {
+	oid := asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+	params := method.Secp256k1().Params()
+	point := append([]byte{4}, append(params.Gx.FillBytes(make([]byte, 32)), params.Gy.FillBytes(make([]byte, 32))...)...)
+
+	// SEC 1 private key with the private key 1.
+	d, _ := base64.RawURLEncoding.DecodeString(secp256k1D)
+	sec1, err := asn1.Marshal(struct {
+		Version       int
+		PrivateKey    []byte
+		NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	}{1, d, oid})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	curveParams, err := asn1.Marshal(oid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spki, err := asn1.Marshal(struct {
+		Algo      pkix.AlgorithmIdentifier
+		BitString asn1.BitString
+	}{
+		pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}, Parameters: asn1.RawValue{FullBytes: curveParams}},
+		asn1.BitString{Bytes: point, BitLength: 8 * len(point)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priv, err := method.ParseECPrivateKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}))
+	if err != nil {
+		t.Fatalf("ParseECPrivateKeyFromPEM() error = %v", err)
+	}
+	if priv.X.Cmp(params.Gx) != 0 || priv.Y.Cmp(params.Gy) != 0 {
+		t.Fatal("public key of the private key 1 is not the generator")
+	}
+
+	pub, err := method.ParseECPublicKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: spki}))
+	if err != nil {
+		t.Fatalf("ParseECPublicKeyFromPEM() error = %v", err)
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		t.Fatal("parsed public key differs")
+	}
+
+	sig, err := method.SigningMethodES256K.Sign("header.payload", priv)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if err := method.SigningMethodES256K.Verify("header.payload", sig, pub); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package method
+
+import (
+	"context"
+	"crypto"
+	"encoding/asn1"
+	"io"
+	"math/big"
+)
+
+// SignerWithContext is implemented by keys whose signing operation is not
+// performed in-process, such as keys held by a cloud KMS, an HSM or an agent
+// daemon. In contrast to [crypto.Signer], the signing operation receives a
+// context, which can be used for cancellation, deadlines and request-scoped
+// values of the remote call.
+//
+// The semantics of digest and opts are identical to [crypto.Signer]. In
+// particular, ECDSA signatures are expected to be ASN.1 DER encoded, as
+// returned by [ecdsa.PrivateKey.Sign]; they are converted to the R || S format
+// of RFC 7518 by the signing method.
+type SignerWithContext interface {
+	Public() crypto.PublicKey
+	SignWithContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error)
+}
+
+// ContextSigner returns a [crypto.Signer] that calls SignWithContext of signer
+// with the given ctx. The result can be passed as key to the Sign function of
+// any asymmetric signing method.
+func ContextSigner(ctx context.Context, signer SignerWithContext) crypto.Signer {
+	return &contextSigner{ctx: ctx, signer: signer}
+}
+
+// contextSigner binds a context to a SignerWithContext.
+type contextSigner struct {
+	ctx    context.Context
+	signer SignerWithContext
+}
+
+// Public implements the crypto.Signer interface.
+func (s *contextSigner) Public() crypto.PublicKey {
+	return s.signer.Public()
+}
+
+// Sign implements the crypto.Signer interface.
+func (s *contextSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.signer.SignWithContext(s.ctx, digest, opts)
+}
+
+// asSigner returns key as a crypto.Signer. A SignerWithContext, that is not
+// bound to a context using ContextSigner, is called with context.Background.
+func asSigner(key interface{}) (crypto.Signer, bool) {
+	switch k := key.(type) {
+	case crypto.Signer:
+		return k, true
+	case SignerWithContext:
+		return ContextSigner(context.Background(), k), true
+	}
+
+	return nil, false
+}
+
+// ecdsaSignature is the ASN.1 structure of an ECDSA signature, as returned by
+// a crypto.Signer.
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// ecdsaASN1ToRaw converts an ASN.1 DER encoded ECDSA signature into the R || S
+// format, where R and S are padded to keyBytes each. See
+// https://datatracker.ietf.org/doc/html/rfc7518#section-3.4
+func ecdsaASN1ToRaw(der []byte, keyBytes int) ([]byte, error) {
+	var sig ecdsaSignature
+
+	rest, err := asn1.Unmarshal(der, &sig)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 || sig.R == nil || sig.S == nil || sig.R.Sign() <= 0 || sig.S.Sign() <= 0 {
+		return nil, ErrECDSAVerification
+	}
+	if sig.R.BitLen() > 8*keyBytes || sig.S.BitLen() > 8*keyBytes {
+		return nil, ErrECDSAVerification
+	}
+
+	// We serialize the outputs (r and s) into big-endian byte arrays
+	// padded with zeros on the left to make sure the sizes work out.
+	// Output must be 2*keyBytes long.
+	out := make([]byte, 2*keyBytes)
+	sig.R.FillBytes(out[0:keyBytes]) // r is assigned to the first half of output.
+	sig.S.FillBytes(out[keyBytes:])  // s is assigned to the second half of output.
+
+	return out, nil
+}
@@ -0,0 +1,90 @@
+package method_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+
+	"github.com/lkyzhu/xwt/method"
+)
+
+// fakeKMS is a SignerWithContext, which never hands out its private key and
+// fails, if the context is done.
+type fakeKMS struct {
+	key   crypto.Signer
+	calls int
+}
+
+func (k *fakeKMS) Public() crypto.PublicKey {
+	return k.key.Public()
+}
+
+func (k *fakeKMS) SignWithContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	k.calls++
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return k.key.Sign(rand.Reader, digest, opts)
+}
+
+func TestSignerWithContext(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// PS256 without options must fall back to the salt length of RFC 7518.
+	ps256NoOptions := &method.SigningMethodRSAPSS{SigningMethodRSA: &method.SigningMethodRSA{Name: "PS256", Hash: crypto.SHA256}}
+
+	tests := []struct {
+		method method.SigningMethod
+		key    crypto.Signer
+	}{
+		{method.SigningMethodRS256, rsaKey},
+		{method.SigningMethodPS256, rsaKey},
+		{ps256NoOptions, rsaKey},
+		{method.SigningMethodES256, ecKey},
+		{method.SigningMethodEdDSA, edKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method.Alg(), func(t *testing.T) {
+			kms := &fakeKMS{key: tt.key}
+
+			sig, err := tt.method.Sign("header.payload", kms)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if kms.calls != 1 {
+				t.Fatalf("SignWithContext called %d times, want 1", kms.calls)
+			}
+			if err := tt.method.Verify("header.payload", sig, kms.Public()); err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if err := method.SigningMethodPS256.Verify("header.payload", sig, kms.Public()); tt.method.Alg() == "PS256" && err != nil {
+				t.Fatalf("Verify() with PS256 error = %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if _, err := tt.method.Sign("header.payload", method.ContextSigner(ctx, kms)); !errors.Is(err, context.Canceled) {
+				t.Fatalf("Sign() with cancelled context error = %v, want %v", err, context.Canceled)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package method
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/lkyzhu/xwt/internal"
+)
+
+var (
+	ErrSM2Verification = errors.New("sm2: verification error")
+)
+
+// DefaultSM2UID is the default user ID used to compute the Z value of a SM2
+// signature, as specified in GM/T 0009-2012.
+var DefaultSM2UID = []byte("1234567812345678")
+
+// SigningMethodSM2 implements the SM2 signature algorithm with the SM3 hash
+// function, as specified in GB/T 32918.2-2016 (GM/T 0003.2-2012).
+// Expects *ecdsa.PrivateKey (or any other crypto.Signer) for signing and
+// *ecdsa.PublicKey for verification, both on the curve returned by [SM2P256].
+//
+// The signature is the concatenation of r and s, each padded to 32 bytes, just
+// like the signatures of the ECDSA family.
+type SigningMethodSM2 struct {
+	Name string
+	// UID is the user ID used to compute the Z value. If empty, DefaultSM2UID
+	// is used. It must be shorter than 8192 bytes.
+	UID []byte
+}
+
+// Specific instance for SM2 with SM3
+var (
+	SigningMethodSM2SM3 *SigningMethodSM2
+)
+
+func init() {
+	SigningMethodSM2SM3 = &SigningMethodSM2{Name: "SM2SM3"}
+	RegisterSigningMethod(SigningMethodSM2SM3.Alg(), func() SigningMethod {
+		return SigningMethodSM2SM3
+	})
+}
+
+// SM2P256 returns the curve sm2p256v1, as specified in GM/T 0003.5-2012. The
+// constant time implementation of github.com/emmansun/gmsm is used.
+func SM2P256() elliptic.Curve {
+	return sm2.P256()
+}
+
+// GenerateSM2Key generates a new SM2 private key, reading randomness from
+// rand, or crypto/rand.Reader if rand is nil.
+func GenerateSM2Key(random io.Reader) (*ecdsa.PrivateKey, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+
+	priv, err := sm2.GenerateKey(random)
+	if err != nil {
+		return nil, err
+	}
+
+	return &priv.PrivateKey, nil
+}
+
+func (m *SigningMethodSM2) Alg() string {
+	return m.Name
+}
+
+// Verify implements token verification for the SigningMethod.
+// For this verify method, key must be an *ecdsa.PublicKey on the SM2 curve
+func (m *SigningMethodSM2) Verify(signingString string, sig []byte, key interface{}) error {
+	var sm2Key *ecdsa.PublicKey
+	var ok bool
+
+	if sm2Key, ok = key.(*ecdsa.PublicKey); !ok {
+		return internal.NewError("SM2 verify expects *ecdsa.PublicKey", internal.ErrInvalidKeyType)
+	}
+
+	if !isSM2Key(sm2Key) {
+		return internal.ErrInvalidKey
+	}
+
+	if len(sig) != 64 {
+		return ErrSM2Verification
+	}
+
+	der, err := asn1.Marshal(ecdsaSignature{
+		R: new(big.Int).SetBytes(sig[:32]),
+		S: new(big.Int).SetBytes(sig[32:]),
+	})
+	if err != nil {
+		return ErrSM2Verification
+	}
+
+	digest, err := m.digest(sm2Key, signingString)
+	if err != nil {
+		return err
+	}
+
+	if !sm2.VerifyASN1(sm2Key, digest, der) {
+		return ErrSM2Verification
+	}
+
+	return nil
+}
+
+// Sign implements token signing for the SigningMethod.
+// For this signing method, key must be an *ecdsa.PrivateKey on the SM2 curve,
+// or any other crypto.Signer or SignerWithContext with such a public key, e.g.
+// a key held by a KMS. The signer is passed the digest e = SM3(Z || M) with
+// the hash function 0, and must return an ASN.1 DER encoded signature, as
+// [sm2.PrivateKey] does.
+func (m *SigningMethodSM2) Sign(signingString string, key interface{}) ([]byte, error) {
+	var signer crypto.Signer
+	var ok bool
+
+	// An *ecdsa.PrivateKey would sign using ECDSA rather than SM2
+	if k, isECDSA := key.(*ecdsa.PrivateKey); isECDSA {
+		key = &sm2.PrivateKey{PrivateKey: *k}
+	}
+
+	if signer, ok = asSigner(key); !ok {
+		return nil, internal.NewError("SM2 sign expects crypto.Signer", internal.ErrInvalidKeyType)
+	}
+
+	sm2Key, ok := signer.Public().(*ecdsa.PublicKey)
+	if !ok || !isSM2Key(sm2Key) {
+		return nil, internal.ErrInvalidKey
+	}
+
+	digest, err := m.digest(sm2Key, signingString)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := signer.Sign(rand.Reader, digest, crypto.Hash(0))
+	if err != nil {
+		return nil, err
+	}
+
+	return ecdsaASN1ToRaw(der, 32)
+}
+
+// digest computes e = SM3(Z || M), where Z is derived from the user ID, the
+// curve and the public key.
+func (m *SigningMethodSM2) digest(pub *ecdsa.PublicKey, signingString string) ([]byte, error) {
+	uid := m.UID
+	if len(uid) == 0 {
+		uid = DefaultSM2UID
+	}
+
+	z, err := sm2.CalculateZA(pub, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sm3.New()
+	h.Write(z)
+	h.Write([]byte(signingString))
+
+	return h.Sum(nil), nil
+}
+
+// isSM2Key reports whether pub is a point on the SM2 curve.
+func isSM2Key(pub *ecdsa.PublicKey) bool {
+	if pub.Curve == nil || pub.X == nil || pub.Y == nil {
+		return false
+	}
+
+	if pub.Curve.Params() != SM2P256().Params() {
+		return false
+	}
+
+	return pub.Curve.IsOnCurve(pub.X, pub.Y)
+}
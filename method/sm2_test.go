@@ -0,0 +1,167 @@
+package method_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/method"
+)
+
+const sm2SigningString = "eyJhbGciOiJTTTJTTTMifQ.eyJzdWIiOiJhbGljZSJ9"
+
+func TestSM2SignVerify(t *testing.T) {
+	priv, err := method.GenerateSM2Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := method.SigningMethodSM2SM3.Sign(sm2SigningString, priv)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if len(sig) != 64 {
+		t.Fatalf("len(sig) = %d, want 64", len(sig))
+	}
+	if err := method.SigningMethodSM2SM3.Verify(sm2SigningString, sig, &priv.PublicKey); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	// The signature must be a valid SM2 signature with the default user ID
+	// according to an independent implementation.
+	der, err := asn1.Marshal(struct{ R, S *big.Int }{new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sm2.VerifyASN1WithSM2(&priv.PublicKey, nil, []byte(sm2SigningString), der) {
+		t.Fatal("signature rejected by gmsm")
+	}
+
+	sig[10] ^= 1
+	if err := method.SigningMethodSM2SM3.Verify(sm2SigningString, sig, &priv.PublicKey); !errors.Is(err, method.ErrSM2Verification) {
+		t.Fatalf("Verify() of modified signature error = %v, want %v", err, method.ErrSM2Verification)
+	}
+}
+
+func TestSM2VerifyForeignSignature(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := priv.SignWithSM2(rand.Reader, nil, []byte(sm2SigningString))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		t.Fatal(err)
+	}
+	raw := make([]byte, 64)
+	sig.R.FillBytes(raw[:32])
+	sig.S.FillBytes(raw[32:])
+
+	if err := method.SigningMethodSM2SM3.Verify(sm2SigningString, raw, &priv.PublicKey); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	// Other user IDs change the Z value and thus the signature.
+	other := &method.SigningMethodSM2{Name: "SM2SM3", UID: []byte("alice@example.com")}
+	if err := other.Verify(sm2SigningString, raw, &priv.PublicKey); !errors.Is(err, method.ErrSM2Verification) {
+		t.Fatalf("Verify() with other UID error = %v, want %v", err, method.ErrSM2Verification)
+	}
+
+	// The length of the user ID in bits must fit into 16 bits.
+	long := &method.SigningMethodSM2{Name: "SM2SM3", UID: make([]byte, 8192)}
+	if _, err := long.Sign(sm2SigningString, priv); err == nil {
+		t.Fatal("Sign() with too long UID succeeded")
+	}
+	if err := long.Verify(sm2SigningString, raw, &priv.PublicKey); err == nil {
+		t.Fatal("Verify() with too long UID succeeded")
+	}
+}
+
+func TestSM2Signer(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A crypto.Signer, whose private key is not accessible, e.g. a KMS.
+	kms := &fakeKMS{key: priv}
+	sig, err := method.SigningMethodSM2SM3.Sign(sm2SigningString, kms)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if err := method.SigningMethodSM2SM3.Verify(sm2SigningString, sig, &priv.PublicKey); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+}
+
+func TestSM2Curve(t *testing.T) {
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := method.SigningMethodSM2SM3.Sign(sm2SigningString, p256); !errors.Is(err, internal.ErrInvalidKey) {
+		t.Fatalf("Sign() with P-256 key error = %v, want %v", err, internal.ErrInvalidKey)
+	}
+	if err := method.SigningMethodSM2SM3.Verify(sm2SigningString, make([]byte, 64), &p256.PublicKey); !errors.Is(err, internal.ErrInvalidKey) {
+		t.Fatalf("Verify() with P-256 key error = %v, want %v", err, internal.ErrInvalidKey)
+	}
+
+	// A point, which is not on the curve, must be rejected.
+	priv, err := method.GenerateSM2Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := &ecdsa.PublicKey{Curve: priv.Curve, X: priv.X, Y: new(big.Int).Add(priv.Y, big.NewInt(1))}
+	if err := method.SigningMethodSM2SM3.Verify(sm2SigningString, make([]byte, 64), invalid); !errors.Is(err, internal.ErrInvalidKey) {
+		t.Fatalf("Verify() with invalid point error = %v, want %v", err, internal.ErrInvalidKey)
+	}
+}
+
+func TestSM2PEM(t *testing.T) {
+	priv, err := method.GenerateSM2Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privDER, err := method.MarshalSM2PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := method.MarshalSM2PublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedPriv, err := method.ParseSM2PrivateKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))
+	if err != nil {
+		t.Fatalf("ParseSM2PrivateKeyFromPEM() error = %v", err)
+	}
+	parsedPub, err := method.ParseSM2PublicKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	if err != nil {
+		t.Fatalf("ParseSM2PublicKeyFromPEM() error = %v", err)
+	}
+	if !parsedPub.Equal(&priv.PublicKey) || !parsedPriv.PublicKey.Equal(&priv.PublicKey) {
+		t.Fatal("parsed keys differ from the generated key")
+	}
+
+	sig, err := method.SigningMethodSM2SM3.Sign(sm2SigningString, parsedPriv)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if err := method.SigningMethodSM2SM3.Verify(sm2SigningString, sig, parsedPub); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+}
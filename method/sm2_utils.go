@@ -0,0 +1,165 @@
+package method
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrNotSM2PrivateKey = errors.New("key is not a valid SM2 private key")
+	ErrNotSM2PublicKey  = errors.New("key is not a valid SM2 public key")
+)
+
+var (
+	oidNamedCurveSM2 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301}
+)
+
+// ParseSM2PrivateKeyFromPEM parses a PEM encoded PKCS8 or SEC 1 SM2 private key
+func ParseSM2PrivateKeyFromPEM(key []byte) (*ecdsa.PrivateKey, error) {
+	// Parse PEM block
+	var block *pem.Block
+	if block, _ = pem.Decode(key); block == nil {
+		return nil, ErrKeyMustBePEMEncoded
+	}
+
+	// Parse the key
+	var pk pkcs8
+	if rest, err := asn1.Unmarshal(block.Bytes, &pk); err == nil && len(rest) == 0 {
+		if !isSM2Algorithm(pk.Algo) {
+			return nil, ErrNotSM2PrivateKey
+		}
+		return parseSM2PrivateKey(pk.PrivateKey)
+	}
+
+	return parseSM2PrivateKey(block.Bytes)
+}
+
+// ParseSM2PublicKeyFromPEM parses a PEM encoded PKIX SM2 public key
+func ParseSM2PublicKeyFromPEM(key []byte) (*ecdsa.PublicKey, error) {
+	// Parse PEM block
+	var block *pem.Block
+	if block, _ = pem.Decode(key); block == nil {
+		return nil, ErrKeyMustBePEMEncoded
+	}
+
+	// Parse the key
+	var pki pkixPublicKey
+	if rest, err := asn1.Unmarshal(block.Bytes, &pki); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, ErrNotSM2PublicKey
+	}
+
+	if !isSM2Algorithm(pki.Algo) {
+		return nil, ErrNotSM2PublicKey
+	}
+
+	pub := unmarshalECPoint(SM2P256(), pki.BitString.RightAlign())
+	if pub == nil {
+		return nil, ErrNotSM2PublicKey
+	}
+
+	return pub, nil
+}
+
+// MarshalSM2PrivateKey converts a SM2 private key to PKCS #8, ASN.1 DER form.
+// The result can be PEM encoded using the "PRIVATE KEY" block type.
+func MarshalSM2PrivateKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	if !isSM2Key(&key.PublicKey) {
+		return nil, ErrNotSM2PrivateKey
+	}
+
+	ec, err := asn1.Marshal(ecPrivateKey{
+		Version:       1,
+		PrivateKey:    key.D.FillBytes(make([]byte, 32)),
+		NamedCurveOID: oidNamedCurveSM2,
+		PublicKey:     asn1.BitString{Bytes: marshalECPoint(&key.PublicKey), BitLength: 8 * 65},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	algo, err := sm2Algorithm()
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(pkcs8{
+		Algo:       algo,
+		PrivateKey: ec,
+	})
+}
+
+// MarshalSM2PublicKey converts a SM2 public key to PKIX, ASN.1 DER form. The
+// result can be PEM encoded using the "PUBLIC KEY" block type.
+func MarshalSM2PublicKey(key *ecdsa.PublicKey) ([]byte, error) {
+	if !isSM2Key(key) {
+		return nil, ErrNotSM2PublicKey
+	}
+
+	algo, err := sm2Algorithm()
+	if err != nil {
+		return nil, err
+	}
+
+	point := marshalECPoint(key)
+	return asn1.Marshal(pkixPublicKey{
+		Algo:      algo,
+		BitString: asn1.BitString{Bytes: point, BitLength: 8 * len(point)},
+	})
+}
+
+// parseSM2PrivateKey parses a SEC 1, ASN.1 DER SM2 private key.
+func parseSM2PrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
+	var ec ecPrivateKey
+	if rest, err := asn1.Unmarshal(der, &ec); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, ErrNotSM2PrivateKey
+	}
+
+	if ec.NamedCurveOID != nil && !ec.NamedCurveOID.Equal(oidNamedCurveSM2) {
+		return nil, ErrNotSM2PrivateKey
+	}
+
+	curve := SM2P256()
+	n := curve.Params().N
+
+	d := new(big.Int).SetBytes(ec.PrivateKey)
+	if d.Sign() <= 0 || d.Cmp(new(big.Int).Sub(n, big.NewInt(1))) >= 0 {
+		return nil, ErrNotSM2PrivateKey
+	}
+
+	priv := &ecdsa.PrivateKey{D: d}
+	priv.PublicKey.Curve = curve
+	priv.PublicKey.X, priv.PublicKey.Y = curve.ScalarBaseMult(d.FillBytes(make([]byte, 32)))
+
+	return priv, nil
+}
+
+// isSM2Algorithm reports whether algo identifies an elliptic curve key on the
+// SM2 curve. Some implementations use the curve OID as algorithm, too.
+func isSM2Algorithm(algo pkix.AlgorithmIdentifier) bool {
+	if algo.Algorithm.Equal(oidNamedCurveSM2) {
+		algo.Algorithm = oidPublicKeyECDSA
+	}
+
+	return isNamedCurveAlgorithm(algo, oidNamedCurveSM2)
+}
+
+// sm2Algorithm returns the algorithm identifier of keys on the SM2 curve.
+func sm2Algorithm() (pkix.AlgorithmIdentifier, error) {
+	params, err := asn1.Marshal(oidNamedCurveSM2)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, err
+	}
+
+	return pkix.AlgorithmIdentifier{
+		Algorithm:  oidPublicKeyECDSA,
+		Parameters: asn1.RawValue{FullBytes: params},
+	}, nil
+}
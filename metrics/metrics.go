@@ -0,0 +1,129 @@
+// Package metrics exports counters and latency histograms of parsing and
+// signing tokens in the Prometheus text exposition format, see
+// https://prometheus.io/docs/instrumenting/exposition_formats/.
+//
+// A [Collector] is registered as a hook with the parser and the tokens, and
+// serves the metrics as an [http.Handler]:
+//
+//	m := &metrics.Collector{}
+//	parser := xwt.NewParser(xwt.WithParserHooks(m))
+//	token := xwt.NewWithClaims(method, claims, xwt.WithSignerHooks(m))
+//	http.Handle("/metrics", m)
+//
+// Failed tokens are counted per reason, as returned by [xwt.ErrorReason]. The
+// algorithm label is only set for algorithms of registered signing methods, so
+// that forged tokens cannot inflate the number of series.
+package metrics
+
+import (
+	"time"
+
+	"github.com/lkyzhu/xwt"
+)
+
+// DefaultNamespace is the prefix of the metric names, if none is configured.
+const DefaultNamespace = "xwt"
+
+// DefaultBuckets are the upper bounds of the latency histograms in seconds, if
+// none are configured. They range from 50µs to 1s, since keys held in memory
+// are looked up in microseconds, but remote key sets or a KMS take
+// milliseconds.
+var DefaultBuckets = []float64{.00005, .0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+
+// Collector collects metrics of parsing and signing tokens. It implements
+// [xwt.ParserHook], [xwt.SignerHook] and [http.Handler]. It is safe for
+// concurrent use, and its zero value is ready to use. The fields must not be
+// changed after the first token is observed.
+type Collector struct {
+	// Namespace is the prefix of the metric names. Defaults to
+	// DefaultNamespace.
+	Namespace string
+
+	// Buckets are the upper bounds of the latency histograms in seconds. They
+	// are sorted and duplicates are removed, so they can be given in any
+	// order. Defaults to DefaultBuckets.
+	Buckets []float64
+
+	registry registry
+}
+
+// OnParseStart implements the [xwt.ParserHook] interface.
+func (c *Collector) OnParseStart(raw string) {}
+
+// OnKeyLookup implements the [xwt.ParserHook] interface.
+func (c *Collector) OnKeyLookup(token *xwt.Token, duration time.Duration, err error) {
+	c.observe("key_lookup_duration_seconds", "Duration of key lookups in seconds.", duration, "alg", alg(token))
+	if err != nil {
+		c.inc("key_lookup_failures_total", "Number of failed key lookups.", "alg", alg(token))
+	}
+}
+
+// OnVerifyResult implements the [xwt.ParserHook] interface.
+func (c *Collector) OnVerifyResult(token *xwt.Token, duration time.Duration, err error) {
+	c.observe("verify_duration_seconds", "Duration of parsing tokens and verifying their signatures in seconds.", duration, "alg", alg(token))
+	if err != nil {
+		c.failed(token, err)
+	}
+}
+
+// OnValidateResult implements the [xwt.ParserHook] interface.
+func (c *Collector) OnValidateResult(token *xwt.Token, duration time.Duration, err error) {
+	c.observe("validate_duration_seconds", "Duration of validating the claims of tokens in seconds.", duration, "alg", alg(token))
+	if err != nil {
+		c.failed(token, err)
+		return
+	}
+
+	c.inc("tokens_parsed_total", "Number of parsed tokens.", "alg", alg(token), "result", "valid")
+}
+
+// OnSign implements the [xwt.SignerHook] interface.
+func (c *Collector) OnSign(token *xwt.Token, duration time.Duration, err error) {
+	c.observe("sign_duration_seconds", "Duration of signing tokens in seconds.", duration, "alg", alg(token))
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	c.inc("tokens_signed_total", "Number of signed tokens.", "alg", alg(token), "result", result)
+}
+
+// failed counts a token, which failed to parse, verify or validate.
+func (c *Collector) failed(token *xwt.Token, err error) {
+	c.inc("tokens_parsed_total", "Number of parsed tokens.", "alg", alg(token), "result", "invalid")
+	c.inc("token_failures_total", "Number of invalid tokens per reason.", "alg", alg(token), "reason", xwt.ErrorReason(err))
+}
+
+func (c *Collector) inc(name, help string, labels ...string) {
+	c.registry.counter(c.name(name), help).inc(labels)
+}
+
+func (c *Collector) observe(name, help string, duration time.Duration, labels ...string) {
+	c.registry.histogram(c.name(name), help, c.buckets()).observe(labels, duration.Seconds())
+}
+
+func (c *Collector) name(name string) string {
+	if c.Namespace == "" {
+		return DefaultNamespace + "_" + name
+	}
+
+	return c.Namespace + "_" + name
+}
+
+func (c *Collector) buckets() []float64 {
+	if len(c.Buckets) == 0 {
+		return DefaultBuckets
+	}
+
+	return c.Buckets
+}
+
+// alg returns the algorithm of the signing method of the token. The method is
+// only set, if it is registered, which bounds the values of the label.
+func alg(token *xwt.Token) string {
+	if token == nil || token.Method == nil {
+		return "unknown"
+	}
+
+	return token.Method.Alg()
+}
@@ -0,0 +1,102 @@
+package metrics_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/method"
+	"github.com/lkyzhu/xwt/metrics"
+)
+
+func scrape(t *testing.T, c *metrics.Collector) string {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if got, want := w.Header().Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8"; got != want {
+		t.Fatalf("Content-Type = %q, want %q", got, want)
+	}
+
+	return w.Body.String()
+}
+
+func TestCollector(t *testing.T) {
+	// The buckets are sorted and deduplicated.
+	c := &metrics.Collector{Namespace: "test", Buckets: []float64{1, .125, .5, .125}}
+	token := &xwt.Token{Method: method.SigningMethodES256}
+
+	c.OnValidateResult(token, 62500*time.Microsecond, nil)
+	c.OnValidateResult(token, 250*time.Millisecond, nil)
+	// Observations equal to an upper bound are counted in its bucket
+	c.OnValidateResult(token, 500*time.Millisecond, nil)
+	c.OnValidateResult(token, 2*time.Second, fmt.Errorf("%w: exp is in the past", internal.ErrTokenExpired))
+	c.OnVerifyResult(nil, 0, internal.ErrTokenMalformed)
+
+	want := `# HELP test_token_failures_total Number of invalid tokens per reason.
+# TYPE test_token_failures_total counter
+test_token_failures_total{alg="ES256",reason="expired"} 1
+test_token_failures_total{alg="unknown",reason="malformed"} 1
+# HELP test_tokens_parsed_total Number of parsed tokens.
+# TYPE test_tokens_parsed_total counter
+test_tokens_parsed_total{alg="ES256",result="invalid"} 1
+test_tokens_parsed_total{alg="ES256",result="valid"} 3
+test_tokens_parsed_total{alg="unknown",result="invalid"} 1
+# HELP test_validate_duration_seconds Duration of validating the claims of tokens in seconds.
+# TYPE test_validate_duration_seconds histogram
+test_validate_duration_seconds_bucket{alg="ES256",le="0.125"} 1
+test_validate_duration_seconds_bucket{alg="ES256",le="0.5"} 3
+test_validate_duration_seconds_bucket{alg="ES256",le="1"} 3
+test_validate_duration_seconds_bucket{alg="ES256",le="+Inf"} 4
+test_validate_duration_seconds_sum{alg="ES256"} 2.8125
+test_validate_duration_seconds_count{alg="ES256"} 4
+# HELP test_verify_duration_seconds Duration of parsing tokens and verifying their signatures in seconds.
+# TYPE test_verify_duration_seconds histogram
+test_verify_duration_seconds_bucket{alg="unknown",le="0.125"} 1
+test_verify_duration_seconds_bucket{alg="unknown",le="0.5"} 1
+test_verify_duration_seconds_bucket{alg="unknown",le="1"} 1
+test_verify_duration_seconds_bucket{alg="unknown",le="+Inf"} 1
+test_verify_duration_seconds_sum{alg="unknown"} 0
+test_verify_duration_seconds_count{alg="unknown"} 1
+`
+	if got := scrape(t, c); got != want {
+		t.Fatalf("ServeHTTP() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCollectorDefaults(t *testing.T) {
+	c := &metrics.Collector{}
+	c.OnSign(&xwt.Token{Method: method.SigningMethodHS256}, time.Millisecond, nil)
+	c.OnKeyLookup(&xwt.Token{Method: method.SigningMethodHS256}, 0, internal.ErrTokenUnverifiable)
+
+	got := scrape(t, c)
+	for _, line := range []string{
+		`xwt_tokens_signed_total{alg="HS256",result="success"} 1`,
+		`xwt_key_lookup_failures_total{alg="HS256"} 1`,
+		`xwt_sign_duration_seconds_bucket{alg="HS256",le="5e-05"} 0`,
+		`xwt_sign_duration_seconds_bucket{alg="HS256",le="0.001"} 1`,
+		`xwt_sign_duration_seconds_bucket{alg="HS256",le="1"} 1`,
+		`xwt_sign_duration_seconds_bucket{alg="HS256",le="+Inf"} 1`,
+		`xwt_sign_duration_seconds_count{alg="HS256"} 1`,
+	} {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("ServeHTTP() does not contain %s:\n%s", line, got)
+		}
+	}
+}
+
+func TestCollectorLabelEscaping(t *testing.T) {
+	c := &metrics.Collector{}
+	c.OnSign(&xwt.Token{Method: &method.SigningMethodHMAC{Name: "a\"b\\c\nd"}}, 0, internal.ErrInvalidKey)
+
+	got := scrape(t, c)
+	if line := `xwt_tokens_signed_total{alg="a\"b\\c\nd",result="failure"} 1`; !strings.Contains(got, line+"\n") {
+		t.Fatalf("ServeHTTP() does not contain %s:\n%s", line, got)
+	}
+}
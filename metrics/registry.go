@@ -0,0 +1,223 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// registry contains the metrics of a collector, by name.
+type registry struct {
+	mu         sync.Mutex
+	counters   map[string]*counter
+	histograms map[string]*histogram
+}
+
+// counter is a counter with a series per label set.
+type counter struct {
+	help   string
+	series map[string]float64
+}
+
+// histogram is a histogram with a series per label set.
+type histogram struct {
+	help    string
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+// histogramSeries contains the non-cumulative count of each bucket, the count
+// of observations above the largest bucket, and the sum of all observations.
+type histogramSeries struct {
+	counts []uint64
+	inf    uint64
+	sum    float64
+}
+
+// counterRef and histogramRef lock the registry while a metric is updated.
+type (
+	counterRef struct {
+		r *registry
+		c *counter
+	}
+
+	histogramRef struct {
+		r *registry
+		h *histogram
+	}
+)
+
+func (r *registry) counter(name, help string) counterRef {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.counters == nil {
+		r.counters = map[string]*counter{}
+	}
+	c, ok := r.counters[name]
+	if !ok {
+		c = &counter{help: help, series: map[string]float64{}}
+		r.counters[name] = c
+	}
+
+	return counterRef{r: r, c: c}
+}
+
+func (r *registry) histogram(name, help string, buckets []float64) histogramRef {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.histograms == nil {
+		r.histograms = map[string]*histogram{}
+	}
+	h, ok := r.histograms[name]
+	if !ok {
+		h = &histogram{help: help, buckets: normalizeBuckets(buckets), series: map[string]*histogramSeries{}}
+		r.histograms[name] = h
+	}
+
+	return histogramRef{r: r, h: h}
+}
+
+// normalizeBuckets returns a sorted copy of the upper bounds without
+// duplicates, since observations are assigned to buckets by binary search.
+// NaN and +Inf are dropped, as the +Inf bucket is always written.
+func normalizeBuckets(buckets []float64) []float64 {
+	normalized := make([]float64, 0, len(buckets))
+	for _, upper := range buckets {
+		if !math.IsNaN(upper) && !math.IsInf(upper, 1) {
+			normalized = append(normalized, upper)
+		}
+	}
+	sort.Float64s(normalized)
+
+	return slices.Compact(normalized)
+}
+
+func (ref counterRef) inc(labels []string) {
+	key := formatLabels(labels)
+
+	ref.r.mu.Lock()
+	ref.c.series[key]++
+	ref.r.mu.Unlock()
+}
+
+func (ref histogramRef) observe(labels []string, v float64) {
+	key := formatLabels(labels)
+
+	ref.r.mu.Lock()
+	defer ref.r.mu.Unlock()
+
+	s, ok := ref.h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(ref.h.buckets))}
+		ref.h.series[key] = s
+	}
+
+	s.sum += v
+	i := sort.SearchFloat64s(ref.h.buckets, v)
+	if i < len(ref.h.buckets) {
+		s.counts[i]++
+	} else {
+		s.inf++
+	}
+}
+
+// ServeHTTP implements the [http.Handler] interface and writes all metrics in
+// the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	bw := bufio.NewWriter(w)
+	c.registry.write(bw)
+	bw.Flush()
+}
+
+// write writes the metrics sorted by name and label set, so that the output
+// is stable.
+func (r *registry) write(w *bufio.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, name := range sortedKeys(r.counters) {
+		c := r.counters[name]
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, c.help, name)
+		for _, labels := range sortedKeys(c.series) {
+			fmt.Fprintf(w, "%s%s %s\n", name, braces(labels), formatFloat(c.series[labels]))
+		}
+	}
+
+	for _, name := range sortedKeys(r.histograms) {
+		h := r.histograms[name]
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, h.help, name)
+		for _, labels := range sortedKeys(h.series) {
+			s := h.series[labels]
+
+			var cumulative uint64
+			for i, upper := range h.buckets {
+				cumulative += s.counts[i]
+				fmt.Fprintf(w, "%s_bucket%s %d\n", name, braces(join(labels, `le="`+formatFloat(upper)+`"`)), cumulative)
+			}
+			cumulative += s.inf
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, braces(join(labels, `le="+Inf"`)), cumulative)
+			fmt.Fprintf(w, "%s_sum%s %s\n", name, braces(labels), formatFloat(s.sum))
+			fmt.Fprintf(w, "%s_count%s %d\n", name, braces(labels), cumulative)
+		}
+	}
+}
+
+// formatLabels formats label name and value pairs as name="value",... with
+// the values escaped.
+func formatLabels(labels []string) string {
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+`="`+labelEscaper.Replace(labels[i+1])+`"`)
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func braces(labels string) string {
+	if labels == "" {
+		return ""
+	}
+
+	return "{" + labels + "}"
+}
+
+func join(labels, label string) string {
+	if labels == "" {
+		return label
+	}
+
+	return labels + "," + label
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,102 @@
+package oidc
+
+import (
+	"encoding/json"
+
+	"github.com/lkyzhu/xwt/jwt"
+)
+
+// IDTokenClaims are the claims of an ID token, as described in
+// https://openid.net/specs/openid-connect-core-1_0.html#IDToken.
+//
+// The `aud` claim may either be a single string or an array of strings. Claims
+// not covered by this struct can be retrieved using [IDTokenClaims.Claims].
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+
+	// the `auth_time` claim, the time the end-user authenticated
+	AuthTime int64 `json:"auth_time,omitempty"`
+
+	// the `nonce` claim, the value passed in the authentication request
+	Nonce string `json:"nonce,omitempty"`
+
+	// the `acr` claim, the authentication context class reference
+	ACR string `json:"acr,omitempty"`
+
+	// the `amr` claim, the authentication methods references
+	AMR []string `json:"amr,omitempty"`
+
+	// the `azp` claim, the client the ID token was issued to
+	AuthorizedParty string `json:"azp,omitempty"`
+
+	// the `at_hash` claim, the hash of the access token issued along with the
+	// ID token
+	AccessTokenHash string `json:"at_hash,omitempty"`
+
+	// the `c_hash` claim, the hash of the authorization code issued along
+	// with the ID token
+	CodeHash string `json:"c_hash,omitempty"`
+
+	// the `sid` claim, the session ID, see
+	// https://openid.net/specs/openid-connect-frontchannel-1_0.html#ClaimsContents
+	SessionID string `json:"sid,omitempty"`
+
+	raw []byte
+}
+
+// Claims unmarshals the raw claims of the ID token into v, e.g. to retrieve
+// the standard profile claims such as `email` or custom claims.
+func (c *IDTokenClaims) Claims(v interface{}) error {
+	return json.Unmarshal(c.raw, v)
+}
+
+// Unmarshal implements the Claims interface.
+func (c *IDTokenClaims) Unmarshal(data []byte) error {
+	type plain IDTokenClaims
+
+	// The `aud` claim of an ID token is usually a single string. The Audience
+	// field at the outer level shadows the one of the embedded claims.
+	var claims struct {
+		*plain
+		Audience audience `json:"aud,omitempty"`
+	}
+	claims.plain = (*plain)(c)
+
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return err
+	}
+
+	c.Audience = claims.Audience
+	c.raw = data
+
+	return nil
+}
+
+// Marshal implements the Claims interface.
+func (c *IDTokenClaims) Marshal() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// audience is the value of the `aud` claim, which is either a string or an
+// array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+
+	var v []string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*a = v
+
+	return nil
+}
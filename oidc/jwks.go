@@ -0,0 +1,248 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+const (
+	// DefaultRefreshInterval is the minimum time between two fetches of a
+	// JWKS, which are triggered by tokens with an unknown key ID.
+	DefaultRefreshInterval = time.Minute
+
+	// DefaultFetchTimeout is the maximum duration of a fetch of a JWKS, if not
+	// configured otherwise in the [RemoteKeySet].
+	DefaultFetchTimeout = 10 * time.Second
+)
+
+// RemoteKeySet supplies the keys to verify tokens from a JSON Web Key Set
+// fetched over HTTP, see https://datatracker.ietf.org/doc/html/rfc7517#section-5.
+//
+// The set is fetched on first use. If a token refers to a key ID, which is not
+// part of the set, it is fetched again to pick up rotated keys, but at most
+// once per RefreshInterval. This also applies to failed fetches, so an
+// unavailable endpoint is not hammered by incoming tokens. Concurrent lookups
+// share a single fetch, and tokens with known key IDs are verified while a
+// fetch is in progress. The [NewRemoteKeySet] function should be used to create
+// an instance of this struct.
+type RemoteKeySet struct {
+	// RefreshInterval is the minimum time between two fetches. If zero,
+	// DefaultRefreshInterval is used.
+	RefreshInterval time.Duration
+
+	// FetchTimeout is the maximum duration of a fetch. If zero,
+	// DefaultFetchTimeout is used.
+	FetchTimeout time.Duration
+
+	url    string
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        []xwt.Key
+	fetched     bool         // fetched specifies whether a fetch succeeded yet
+	attemptedAt time.Time    // attemptedAt is the time of the last fetch
+	err         error        // err is the error of the last fetch
+	inflight    *keySetFetch // inflight is the fetch in progress, if any
+}
+
+// keySetFetch is a fetch of a [RemoteKeySet] in progress. err is set before
+// done is closed.
+type keySetFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// NewRemoteKeySet creates a key set fetching the JWKS from url. If client is
+// nil, a client with a timeout of DefaultFetchTimeout is used.
+func NewRemoteKeySet(url string, client *http.Client) *RemoteKeySet {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultFetchTimeout}
+	}
+
+	return &RemoteKeySet{
+		url:    url,
+		client: client,
+	}
+}
+
+// Keyfunc implements the [xwt.Keyfunc] type. It returns the key matching the
+// `kid` header parameter of the token, or all keys of the set, if the token
+// does not have a key ID. Every key is bound to its `alg` member. Keys without
+// `alg` are bound to the algorithm of the token, which is safe, as the parsed
+// keys are never HMAC secrets.
+func (s *RemoteKeySet) Keyfunc(t *xwt.Token) (interface{}, error) {
+	keys, err := s.lookup(t.Header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	set := xwt.VerificationKeySet{}
+	for _, key := range keys {
+		if key.Alg == "" {
+			key.Alg = t.Method.Alg()
+		}
+		set.Keys = append(set.Keys, key)
+	}
+
+	return set, nil
+}
+
+// lookup returns the keys with the given key ID, or all keys if kid is empty.
+func (s *RemoteKeySet) lookup(kid string) ([]xwt.Key, error) {
+	keys, fetched := s.cached(kid)
+	if len(keys) > 0 {
+		return keys, nil
+	}
+
+	// The set is fetched on first use, and again if the provider may have
+	// rotated its keys
+	if !fetched || kid != "" {
+		if err := s.refresh(); err != nil {
+			return nil, err
+		}
+
+		if keys, _ = s.cached(kid); len(keys) > 0 {
+			return keys, nil
+		}
+	}
+
+	if kid != "" {
+		return nil, fmt.Errorf("unknown kid %s", kid)
+	}
+
+	return nil, errors.New("key set does not contain any keys")
+}
+
+// cached returns the keys with the given key ID from the last fetched set, and
+// whether the set was fetched at all.
+func (s *RemoteKeySet) cached(kid string) ([]xwt.Key, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return filterKeys(s.keys, kid), s.fetched
+}
+
+// refresh fetches the key set, unless it was already fetched within the
+// refresh interval, in which case the error of that fetch is returned. If a
+// fetch is in progress, it waits for its result instead.
+func (s *RemoteKeySet) refresh() error {
+	s.mu.Lock()
+
+	if f := s.inflight; f != nil {
+		s.mu.Unlock()
+		<-f.done
+		return f.err
+	}
+
+	if !s.attemptedAt.IsZero() && time.Since(s.attemptedAt) < s.refreshInterval() {
+		err := s.err
+		s.mu.Unlock()
+		return err
+	}
+
+	f := &keySetFetch{done: make(chan struct{})}
+	s.inflight = f
+	s.mu.Unlock()
+
+	// The lock is not held during the request, so that lookups of known keys
+	// are not blocked by a slow endpoint
+	keys, err := s.fetch()
+
+	s.mu.Lock()
+	if err == nil {
+		s.keys, s.fetched = keys, true
+	}
+	s.attemptedAt, s.err = time.Now(), err
+	s.inflight = nil
+	s.mu.Unlock()
+
+	f.err = err
+	close(f.done)
+
+	return err
+}
+
+// fetch fetches and parses the key set.
+func (s *RemoteKeySet) fetch() ([]xwt.Key, error) {
+	var jwks struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.fetchTimeout())
+	defer cancel()
+
+	if err := getJSON(ctx, s.client, s.url, &jwks); err != nil {
+		return nil, fmt.Errorf("could not fetch key set: %w", err)
+	}
+
+	keys := make([]xwt.Key, 0, len(jwks.Keys))
+	for _, raw := range jwks.Keys {
+		var jwk struct {
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+		}
+		if err := json.Unmarshal(raw, &jwk); err != nil {
+			return nil, fmt.Errorf("could not decode key set: %w", err)
+		}
+
+		// Skip encryption keys
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		// Skip keys of unsupported types, which a provider may publish for
+		// other clients
+		material, err := method.ParsePublicKeyFromJWK(raw)
+		if errors.Is(err, method.ErrUnsupportedJWK) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("could not parse key %s: %w", jwk.Kid, err)
+		}
+
+		keys = append(keys, xwt.Key{Material: material, Alg: jwk.Alg, Kid: jwk.Kid})
+	}
+
+	return keys, nil
+}
+
+func (s *RemoteKeySet) refreshInterval() time.Duration {
+	if s.RefreshInterval != 0 {
+		return s.RefreshInterval
+	}
+
+	return DefaultRefreshInterval
+}
+
+func (s *RemoteKeySet) fetchTimeout() time.Duration {
+	if s.FetchTimeout != 0 {
+		return s.FetchTimeout
+	}
+
+	return DefaultFetchTimeout
+}
+
+// filterKeys returns the keys with the given key ID, or all keys if kid is
+// empty.
+func filterKeys(keys []xwt.Key, kid string) []xwt.Key {
+	if kid == "" {
+		return keys
+	}
+
+	var filtered []xwt.Key
+	for _, key := range keys {
+		if key.Kid == kid {
+			filtered = append(filtered, key)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,372 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/circl/sign/ed448"
+	"github.com/cloudflare/circl/xof"
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+	"github.com/lkyzhu/xwt/oidc"
+)
+
+const clientID = "app"
+
+// testProvider is an OpenID provider serving discovery and its JWKS.
+type testProvider struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	keys     map[string]*ecdsa.PrivateKey
+	failing  bool
+	delay    time.Duration
+	requests atomic.Int32 // requests counts the requests to the JWKS endpoint
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+
+	p := &testProvider{keys: map[string]*ecdsa.PrivateKey{}}
+	p.addKey(t, "k1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(oidc.DiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&oidc.ProviderMetadata{
+			Issuer:                           p.URL,
+			JWKSURI:                          p.URL + "/jwks",
+			IDTokenSigningAlgValuesSupported: []string{"ES256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.requests.Add(1)
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		time.Sleep(p.delay)
+		if p.failing {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		keys := []json.RawMessage{}
+		for kid, key := range p.keys {
+			b, err := method.MarshalPublicKeyToJWK(&key.PublicKey)
+			if err != nil {
+				t.Error(err)
+			}
+			var jwk map[string]interface{}
+			json.Unmarshal(b, &jwk)
+			jwk["kid"] = kid
+			b, _ = json.Marshal(jwk)
+			keys = append(keys, b)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+
+	return p
+}
+
+func (p *testProvider) addKey(t *testing.T, kid string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[kid] = key
+}
+
+// idToken issues an ID token for the client, signed with the key kid.
+func (p *testProvider) idToken(t *testing.T, kid string, extra jwt.MapClaims) string {
+	t.Helper()
+
+	p.mu.Lock()
+	key := p.keys[kid]
+	p.mu.Unlock()
+
+	claims := jwt.MapClaims{
+		"iss": p.URL,
+		"sub": "alice",
+		"aud": clientID,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+
+	token, err := xwt.NewWithClaims(method.SigningMethodES256, &claims, xwt.WithKeyID(kid)).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// leftHalf returns the `at_hash` or `c_hash` of value.
+func leftHalf(hash crypto.Hash, value string) string {
+	h := hash.New()
+	h.Write([]byte(value))
+	sum := h.Sum(nil)
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
+
+func TestProvider(t *testing.T) {
+	p := newTestProvider(t)
+
+	provider, err := oidc.NewProvider(context.Background(), p.URL)
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	verifier := provider.Verifier(&oidc.Config{ClientID: clientID})
+
+	tests := []struct {
+		name    string
+		token   string
+		opts    []oidc.VerifyOption
+		wantErr error
+	}{
+		{"valid", p.idToken(t, "k1", nil), nil, nil},
+		{"nonce", p.idToken(t, "k1", jwt.MapClaims{"nonce": "n-0S6"}), []oidc.VerifyOption{oidc.WithNonce("n-0S6")}, nil},
+		{"nonce mismatch", p.idToken(t, "k1", jwt.MapClaims{"nonce": "n-0S6"}), []oidc.VerifyOption{oidc.WithNonce("other")}, oidc.ErrInvalidNonce},
+		{"at_hash", p.idToken(t, "k1", jwt.MapClaims{"at_hash": leftHalf(crypto.SHA256, "access")}), []oidc.VerifyOption{oidc.WithAccessToken("access")}, nil},
+		{"at_hash mismatch", p.idToken(t, "k1", jwt.MapClaims{"at_hash": leftHalf(crypto.SHA256, "access")}), []oidc.VerifyOption{oidc.WithAccessToken("other")}, oidc.ErrInvalidAccessTokenHash},
+		{"c_hash mismatch", p.idToken(t, "k1", jwt.MapClaims{"c_hash": leftHalf(crypto.SHA256, "code")}), []oidc.VerifyOption{oidc.WithCode("other")}, oidc.ErrInvalidCodeHash},
+		{"other audience", p.idToken(t, "k1", jwt.MapClaims{"aud": "other"}), nil, internal.ErrTokenInvalidAudience},
+		{"azp required", p.idToken(t, "k1", jwt.MapClaims{"aud": []string{clientID, "other"}}), nil, oidc.ErrInvalidAuthorizedParty},
+		{"azp", p.idToken(t, "k1", jwt.MapClaims{"aud": []string{clientID, "other"}, "azp": clientID}), nil, nil},
+		{"auth_time too old", p.idToken(t, "k1", jwt.MapClaims{"auth_time": time.Now().Add(-time.Hour).Unix()}), []oidc.VerifyOption{oidc.WithMaxAge(time.Minute)}, oidc.ErrAuthenticationTooOld},
+		{"other issuer", p.idToken(t, "k1", jwt.MapClaims{"iss": "https://other.example.com"}), nil, internal.ErrTokenInvalidIssuer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(tt.token, tt.opts...)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && claims.Subject != "alice" {
+				t.Fatalf("Verify() sub = %s, want alice", claims.Subject)
+			}
+		})
+	}
+
+	// The key set is fetched once.
+	if n := p.requests.Load(); n != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", n)
+	}
+}
+
+func TestNewVerifierClientID(t *testing.T) {
+	keyfunc := func(*xwt.Token) (interface{}, error) { return nil, nil }
+	provider := &oidc.Provider{Metadata: oidc.ProviderMetadata{Issuer: "https://accounts.example.com"}}
+
+	tests := []struct {
+		name string
+		new  func()
+	}{
+		{"nil config", func() { oidc.NewVerifier("https://accounts.example.com", keyfunc, nil) }},
+		{"empty client", func() { oidc.NewVerifier("https://accounts.example.com", keyfunc, &oidc.Config{}) }},
+		{"provider nil config", func() { provider.Verifier(nil) }},
+		{"provider empty client", func() { provider.Verifier(&oidc.Config{SupportedSigningAlgs: []string{"ES256"}}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("verifier without client was created")
+				}
+			}()
+			tt.new()
+		})
+	}
+}
+
+func TestProviderDiscovery(t *testing.T) {
+	p := newTestProvider(t)
+
+	// The issuer must match exactly.
+	if _, err := oidc.NewProvider(context.Background(), p.URL+"/"); !errors.Is(err, oidc.ErrDiscovery) {
+		t.Fatalf("NewProvider() with other issuer error = %v, want %v", err, oidc.ErrDiscovery)
+	}
+	if _, err := oidc.NewProvider(context.Background(), p.URL+"/unknown"); !errors.Is(err, oidc.ErrDiscovery) {
+		t.Fatalf("NewProvider() of unknown issuer error = %v, want %v", err, oidc.ErrDiscovery)
+	}
+}
+
+func TestRemoteKeySetRotation(t *testing.T) {
+	p := newTestProvider(t)
+	keys := oidc.NewRemoteKeySet(p.URL+"/jwks", nil)
+	keys.RefreshInterval = time.Nanosecond
+	verifier := oidc.NewVerifier(p.URL, keys.Keyfunc, &oidc.Config{ClientID: clientID, SupportedSigningAlgs: []string{"ES256"}})
+
+	if _, err := verifier.Verify(p.idToken(t, "k1", nil)); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	// A token with an unknown key ID triggers a fetch, which picks up the
+	// rotated key.
+	p.addKey(t, "k2")
+	if _, err := verifier.Verify(p.idToken(t, "k2", nil)); err != nil {
+		t.Fatalf("Verify() with rotated key error = %v", err)
+	}
+
+	// Known keys do not trigger a fetch.
+	if _, err := verifier.Verify(p.idToken(t, "k1", nil)); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if n := p.requests.Load(); n != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", n)
+	}
+}
+
+func TestRemoteKeySetRefreshInterval(t *testing.T) {
+	p := newTestProvider(t)
+	keys := oidc.NewRemoteKeySet(p.URL+"/jwks", nil)
+	keys.RefreshInterval = time.Hour
+	verifier := oidc.NewVerifier(p.URL, keys.Keyfunc, &oidc.Config{ClientID: clientID, SupportedSigningAlgs: []string{"ES256"}})
+
+	if _, err := verifier.Verify(p.idToken(t, "k1", nil)); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	// Tokens with unknown key IDs do not trigger fetches within the refresh
+	// interval.
+	p.addKey(t, "k2")
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Verify(p.idToken(t, "k2", nil)); err == nil {
+			t.Fatal("Verify() with key added within the refresh interval succeeded")
+		}
+	}
+	if n := p.requests.Load(); n != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", n)
+	}
+}
+
+func TestRemoteKeySetFailure(t *testing.T) {
+	p := newTestProvider(t)
+	p.failing = true
+
+	keys := oidc.NewRemoteKeySet(p.URL+"/jwks", nil)
+	keys.RefreshInterval = time.Hour
+	verifier := oidc.NewVerifier(p.URL, keys.Keyfunc, &oidc.Config{ClientID: clientID, SupportedSigningAlgs: []string{"ES256"}})
+
+	// Failed fetches are not retried within the refresh interval.
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Verify(p.idToken(t, "k1", nil)); err == nil {
+			t.Fatal("Verify() without key set succeeded")
+		}
+	}
+	if n := p.requests.Load(); n != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", n)
+	}
+}
+
+func TestRemoteKeySetTimeout(t *testing.T) {
+	p := newTestProvider(t)
+	p.delay = time.Second
+
+	keys := oidc.NewRemoteKeySet(p.URL+"/jwks", nil)
+	keys.FetchTimeout = 50 * time.Millisecond
+	verifier := oidc.NewVerifier(p.URL, keys.Keyfunc, &oidc.Config{ClientID: clientID, SupportedSigningAlgs: []string{"ES256"}})
+
+	if _, err := verifier.Verify(p.idToken(t, "k1", nil)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Verify() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRemoteKeySetConcurrent(t *testing.T) {
+	p := newTestProvider(t)
+	p.delay = 50 * time.Millisecond
+
+	keys := oidc.NewRemoteKeySet(p.URL+"/jwks", nil)
+	verifier := oidc.NewVerifier(p.URL, keys.Keyfunc, &oidc.Config{ClientID: clientID, SupportedSigningAlgs: []string{"ES256"}})
+	token := p.idToken(t, "k1", nil)
+
+	// Concurrent lookups share a single fetch.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := verifier.Verify(token); err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := p.requests.Load(); n != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", n)
+	}
+}
+
+func TestEdDSAHash(t *testing.T) {
+	pub, priv, err := ed448.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The hash of Ed448 is SHAKE256 with an output of 114 bytes.
+	sum := make([]byte, 114)
+	h := xof.SHAKE256.New()
+	h.Write([]byte("access"))
+	h.Read(sum)
+	atHash := base64.RawURLEncoding.EncodeToString(sum[:57])
+
+	sha := sha256.Sum256([]byte("access"))
+	wrongHash := base64.RawURLEncoding.EncodeToString(sha[:16])
+
+	verifier := oidc.NewVerifier("https://accounts.example.com", func(*xwt.Token) (interface{}, error) {
+		return pub, nil
+	}, &oidc.Config{ClientID: clientID, SupportedSigningAlgs: []string{"EdDSA"}})
+
+	for _, tt := range []struct {
+		atHash  string
+		wantErr error
+	}{
+		{atHash, nil},
+		{wrongHash, oidc.ErrInvalidAccessTokenHash},
+	} {
+		token, err := xwt.NewWithClaims(method.SigningMethodEdDSA, &jwt.MapClaims{
+			"iss":     "https://accounts.example.com",
+			"sub":     "alice",
+			"aud":     clientID,
+			"iat":     time.Now().Unix(),
+			"exp":     time.Now().Add(time.Hour).Unix(),
+			"at_hash": tt.atHash,
+		}).SignedString(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := verifier.Verify(token, oidc.WithAccessToken("access")); !errors.Is(err, tt.wantErr) {
+			t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package oidc verifies OpenID Connect ID tokens, as specified in
+// https://openid.net/specs/openid-connect-core-1_0.html.
+//
+// A [Provider] is created from the issuer URL using OpenID Connect Discovery.
+// It fetches the signing keys of the issuer from its JWKS endpoint and creates
+// an [IDTokenVerifier] for a client:
+//
+//	provider, err := oidc.NewProvider(ctx, "https://accounts.example.com")
+//	if err != nil {
+//	    return err
+//	}
+//	verifier := provider.Verifier(&oidc.Config{ClientID: clientID})
+//
+//	claims, err := verifier.Verify(rawIDToken, oidc.WithNonce(nonce))
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/lkyzhu/xwt"
+)
+
+// DiscoveryPath is appended to the issuer URL to obtain the provider
+// configuration, see
+// https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderConfig.
+const DiscoveryPath = "/.well-known/openid-configuration"
+
+var (
+	ErrDiscovery = errors.New("could not discover provider configuration")
+)
+
+// ProviderMetadata is the configuration of an OpenID provider, as returned by
+// its discovery endpoint. Only the members relevant to clients are included.
+type ProviderMetadata struct {
+	Issuer                           string   `json:"issuer"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint"`
+	TokenEndpoint                    string   `json:"token_endpoint,omitempty"`
+	UserinfoEndpoint                 string   `json:"userinfo_endpoint,omitempty"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	EndSessionEndpoint               string   `json:"end_session_endpoint,omitempty"`
+	ScopesSupported                  []string `json:"scopes_supported,omitempty"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+	ClaimsSupported                  []string `json:"claims_supported,omitempty"`
+}
+
+// ProviderOption is used to implement functional-style options that modify
+// the behavior of a [Provider].
+type ProviderOption func(*Provider)
+
+// WithHTTPClient configures the HTTP client used for discovery and to fetch
+// the JWKS. By default, a client with a timeout of DefaultFetchTimeout is used.
+func WithHTTPClient(client *http.Client) ProviderOption {
+	return func(p *Provider) {
+		p.client = client
+	}
+}
+
+// Provider is an OpenID provider, whose configuration was obtained using
+// discovery. The [NewProvider] function should be used to create an instance
+// of this struct.
+type Provider struct {
+	// Metadata is the configuration returned by the discovery endpoint.
+	Metadata ProviderMetadata
+
+	client *http.Client
+	keys   *RemoteKeySet
+}
+
+// NewProvider fetches the configuration of the OpenID provider identified by
+// issuer. As required by OpenID Connect Discovery, the issuer of the returned
+// configuration must be identical to issuer.
+func NewProvider(ctx context.Context, issuer string, opts ...ProviderOption) (*Provider, error) {
+	p := &Provider{
+		client: &http.Client{Timeout: DefaultFetchTimeout},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	url := strings.TrimSuffix(issuer, "/") + DiscoveryPath
+	if err := getJSON(ctx, p.client, url, &p.Metadata); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDiscovery, err)
+	}
+
+	if p.Metadata.Issuer != issuer {
+		return nil, fmt.Errorf("%w: issuer %s does not match %s", ErrDiscovery, p.Metadata.Issuer, issuer)
+	}
+	if p.Metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: jwks_uri is missing", ErrDiscovery)
+	}
+
+	p.keys = NewRemoteKeySet(p.Metadata.JWKSURI, p.client)
+
+	return p, nil
+}
+
+// Keyfunc implements the [xwt.Keyfunc] type using the keys of the provider.
+func (p *Provider) Keyfunc(t *xwt.Token) (interface{}, error) {
+	return p.keys.Keyfunc(t)
+}
+
+// Verifier returns a verifier for the ID tokens issued by the provider to the
+// client described by config. If config does not restrict the signing
+// algorithms, the algorithms supported by the provider are accepted. Like
+// [NewVerifier], it panics if config is nil or its ClientID is empty.
+func (p *Provider) Verifier(config *Config) *IDTokenVerifier {
+	if config == nil {
+		return NewVerifier(p.Metadata.Issuer, p.Keyfunc, nil)
+	}
+
+	c := *config
+	if len(c.SupportedSigningAlgs) == 0 {
+		c.SupportedSigningAlgs = p.Metadata.IDTokenSigningAlgValuesSupported
+	}
+
+	return NewVerifier(p.Metadata.Issuer, p.Keyfunc, &c)
+}
+
+// getJSON fetches url and decodes the JSON response into v.
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+
+	if err = json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("could not decode response of %s: %w", url, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,290 @@
+package oidc
+
+import (
+	"crypto"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloudflare/circl/sign/ed448"
+	"github.com/cloudflare/circl/xof"
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/method"
+)
+
+var (
+	ErrInvalidNonce           = errors.New("token has invalid nonce")
+	ErrInvalidAuthorizedParty = errors.New("token has invalid authorized party")
+	ErrAuthenticationTooOld   = errors.New("end-user authentication is too old")
+	ErrInvalidAccessTokenHash = errors.New("token has invalid access token hash")
+	ErrInvalidCodeHash        = errors.New("token has invalid code hash")
+)
+
+// Config configures the verification of ID tokens for a client.
+type Config struct {
+	// ClientID is the client the ID tokens must be issued to. It must be
+	// contained in the `aud` claim.
+	ClientID string
+
+	// SupportedSigningAlgs are the accepted `alg` header parameters. If
+	// empty, only RS256 is accepted, which every provider must support.
+	SupportedSigningAlgs []string
+
+	// Leeway accounts for clock skew when validating the time claims.
+	Leeway time.Duration
+
+	// TimeFunc supplies the current time. If nil, time.Now is used.
+	TimeFunc func() time.Time
+}
+
+// VerifyOption is used to implement functional-style options that supply the
+// values of the authentication request and response an ID token is verified
+// against.
+type VerifyOption func(*verification)
+
+type verification struct {
+	nonce       string
+	accessToken string
+	code        string
+	maxAge      time.Duration
+	hasMaxAge   bool
+}
+
+// WithNonce requires the `nonce` claim to equal the nonce sent in the
+// authentication request.
+func WithNonce(nonce string) VerifyOption {
+	return func(v *verification) {
+		v.nonce = nonce
+	}
+}
+
+// WithAccessToken verifies the `at_hash` claim against the access token
+// returned together with the ID token. As `at_hash` is optional in the
+// authorization code flow, a missing claim is accepted.
+func WithAccessToken(accessToken string) VerifyOption {
+	return func(v *verification) {
+		v.accessToken = accessToken
+	}
+}
+
+// WithCode verifies the `c_hash` claim against the authorization code returned
+// together with the ID token in the hybrid flow. As `c_hash` is optional for
+// some response types, a missing claim is accepted.
+func WithCode(code string) VerifyOption {
+	return func(v *verification) {
+		v.code = code
+	}
+}
+
+// WithMaxAge requires the `auth_time` claim and that the end-user
+// authenticated no longer than maxAge ago, which must match the `max_age`
+// parameter of the authentication request.
+func WithMaxAge(maxAge time.Duration) VerifyOption {
+	return func(v *verification) {
+		v.maxAge = maxAge
+		v.hasMaxAge = true
+	}
+}
+
+// IDTokenVerifier verifies ID tokens, as described in
+// https://openid.net/specs/openid-connect-core-1_0.html#IDTokenValidation.
+// The [NewVerifier] function or [Provider.Verifier] should be used to create
+// an instance of this struct.
+type IDTokenVerifier struct {
+	issuer  string
+	keyfunc xwt.Keyfunc
+	config  Config
+	parser  *xwt.Parser
+}
+
+// NewVerifier creates a verifier for the ID tokens of issuer, whose signing
+// keys are supplied by keyfunc, e.g. [RemoteKeySet.Keyfunc].
+//
+// It panics, if config is nil or its ClientID is empty, as the ID tokens would
+// then be accepted regardless of the client they were issued to.
+func NewVerifier(issuer string, keyfunc xwt.Keyfunc, config *Config) *IDTokenVerifier {
+	if config == nil || config.ClientID == "" {
+		panic("oidc: NewVerifier requires a config with a ClientID")
+	}
+
+	v := &IDTokenVerifier{
+		issuer:  issuer,
+		keyfunc: keyfunc,
+		config:  *config,
+	}
+
+	algs := make([]string, 0, len(config.SupportedSigningAlgs))
+	for _, alg := range config.SupportedSigningAlgs {
+		// ID tokens must be signed, see OpenID Connect Core section 3.1.3.7
+		if alg != "none" {
+			algs = append(algs, alg)
+		}
+	}
+	if len(algs) == 0 {
+		algs = []string{method.SigningMethodRS256.Alg()}
+	}
+
+	opts := []xwt.ParserOption{
+		xwt.WithValidMethods(algs),
+		xwt.WithIssuer(issuer),
+		xwt.WithAudience(config.ClientID),
+		xwt.WithExpirationRequired(),
+		xwt.WithIssuedAt(),
+		xwt.WithLeeway(config.Leeway),
+	}
+	if config.TimeFunc != nil {
+		opts = append(opts, xwt.WithTimeFunc(config.TimeFunc))
+	}
+	v.parser = xwt.NewParser(opts...)
+
+	return v
+}
+
+// Verify verifies the signature and claims of an ID token and returns its
+// claims. Besides the checks performed by the parser for `iss`, `aud`, `exp`
+// and `iat`, it verifies `azp`, and `nonce`, `auth_time`, `at_hash` and
+// `c_hash` as configured by opts.
+func (v *IDTokenVerifier) Verify(rawIDToken string, opts ...VerifyOption) (*IDTokenClaims, error) {
+	var verification verification
+	for _, opt := range opts {
+		opt(&verification)
+	}
+
+	claims := &IDTokenClaims{}
+	token, err := v.parser.ParseWithClaims(rawIDToken, claims, v.keyfunc)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+
+	if claims.IssuedAt == nil {
+		errs = append(errs, internal.NewError("iat claim is required", internal.ErrTokenRequiredClaimMissing))
+	}
+
+	if err = v.verifyAuthorizedParty(claims); err != nil {
+		errs = append(errs, err)
+	}
+
+	if verification.nonce != "" && subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(verification.nonce)) != 1 {
+		errs = append(errs, ErrInvalidNonce)
+	}
+
+	if verification.hasMaxAge {
+		if err = v.verifyAuthTime(claims, verification.maxAge); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if verification.accessToken != "" && claims.AccessTokenHash != "" {
+		if err = verifyHash(token, claims.AccessTokenHash, verification.accessToken, ErrInvalidAccessTokenHash); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if verification.code != "" && claims.CodeHash != "" {
+		if err = verifyHash(token, claims.CodeHash, verification.code, ErrInvalidCodeHash); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, internal.NewError("", internal.ErrTokenInvalidClaims, errs...)
+	}
+
+	return claims, nil
+}
+
+// verifyAuthorizedParty checks the `azp` claim, which must be present for
+// tokens with multiple audiences, and must equal the client ID if present.
+func (v *IDTokenVerifier) verifyAuthorizedParty(claims *IDTokenClaims) error {
+	if claims.AuthorizedParty == "" {
+		if len(claims.Audience) > 1 {
+			return internal.NewError("azp claim is required for multiple audiences", ErrInvalidAuthorizedParty, internal.ErrTokenRequiredClaimMissing)
+		}
+		return nil
+	}
+
+	if claims.AuthorizedParty != v.config.ClientID {
+		return internal.NewError(fmt.Sprintf("token was issued to %s", claims.AuthorizedParty), ErrInvalidAuthorizedParty)
+	}
+
+	return nil
+}
+
+// verifyAuthTime checks that the end-user authenticated no longer than maxAge
+// ago.
+func (v *IDTokenVerifier) verifyAuthTime(claims *IDTokenClaims, maxAge time.Duration) error {
+	if claims.AuthTime == 0 {
+		return internal.NewError("auth_time claim is required", internal.ErrTokenRequiredClaimMissing)
+	}
+
+	now := time.Now()
+	if v.config.TimeFunc != nil {
+		now = v.config.TimeFunc()
+	}
+
+	if now.After(time.Unix(claims.AuthTime, 0).Add(maxAge + v.config.Leeway)) {
+		return ErrAuthenticationTooOld
+	}
+
+	return nil
+}
+
+// verifyHash compares the `at_hash` or `c_hash` claim against value. The hash
+// is the base64url encoded left half of the hash of value, using the hash
+// function of the signing algorithm of the token, see
+// https://openid.net/specs/openid-connect-core-1_0.html#CodeIDToken.
+func verifyHash(token *xwt.Token, claim, value string, sentinel error) error {
+	sum, err := hashOf(token, []byte(value))
+	if err != nil {
+		return internal.NewError("", sentinel, err)
+	}
+
+	expected := base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+	if subtle.ConstantTimeCompare([]byte(claim), []byte(expected)) != 1 {
+		return sentinel
+	}
+
+	return nil
+}
+
+// hashOf hashes value using the hash function of the signing method of a
+// verified token. For EdDSA, it depends on the curve, which is determined by
+// the size of the signature: SHA-512 is used for Ed25519 and SHAKE256 with an
+// output of 114 bytes for Ed448, i.e. the hash functions of the curves.
+func hashOf(token *xwt.Token, value []byte) ([]byte, error) {
+	var hash crypto.Hash
+
+	switch m := token.Method.(type) {
+	case *method.SigningMethodRSA:
+		hash = m.Hash
+	case *method.SigningMethodRSAPSS:
+		hash = m.Hash
+	case *method.SigningMethodECDSA:
+		hash = m.Hash
+	case *method.SigningMethodHMAC:
+		hash = m.Hash
+	case *method.SigningMethodEd25519:
+		if len(token.Signature) == ed448.SignatureSize {
+			sum := make([]byte, 114)
+			h := xof.SHAKE256.New()
+			h.Write(value)
+			h.Read(sum)
+			return sum, nil
+		}
+		hash = crypto.SHA512
+	default:
+		return nil, fmt.Errorf("hash of algorithm %s is unknown", token.Method.Alg())
+	}
+
+	h := hash.New()
+	h.Write(value)
+
+	return h.Sum(nil), nil
+}
@@ -1,11 +1,13 @@
 package xwt
 
 import (
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/lkyzhu/xwt/internal"
 	"github.com/lkyzhu/xwt/method"
@@ -26,6 +28,18 @@ type Parser struct {
 	decodeStrict bool
 
 	decodePaddingAllowed bool
+
+	// criticalHeaderHandlers contains the header parameters that this parser
+	// understands when they are listed in the `crit` header parameter, together
+	// with a function validating their value.
+	criticalHeaderHandlers map[string]func(value interface{}) error
+
+	// signaturePolicy specifies which signatures of a token in JWS JSON
+	// serialization need to be valid.
+	signaturePolicy SignaturePolicy
+
+	// hooks observe the stages of parsing.
+	hooks []ParserHook
 }
 
 // NewParser creates a new Parser with the specified options
@@ -58,11 +72,111 @@ func (p *Parser) Parse(tokenString string, keyFunc Keyfunc) (*Token, error) {
 // make sure that a) you either embed a non-pointer version of the claims or b) if you are using a pointer, allocate the
 // proper memory for it before passing in the overall claims, otherwise you might run into a panic.
 func (p *Parser) ParseWithClaims(tokenString string, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	start := p.parseStart(tokenString)
+
+	token, parts, err := p.ParseUnverified(tokenString, claims)
+	if err != nil {
+		p.verifyResult(token, start, err)
+		return token, err
+	}
+
+	return p.verify(token, parts, claims, keyFunc, start, nil)
+}
+
+// ParseWithConnection parses, validates, and verifies like ParseWithClaims a
+// token that was presented on the TLS connection with the given state. If the
+// token is bound to a client certificate, as described in
+// https://datatracker.ietf.org/doc/html/rfc8705#section-3, it must be the
+// client certificate of the connection. Use [WithCertificateBinding] to require
+// that all tokens are bound.
+//
+// Certificate-bound tokens are rejected by the other parse functions, as well
+// as if state is nil or the connection has no client certificate, since the
+// binding cannot be verified then.
+func (p *Parser) ParseWithConnection(tokenString string, state *tls.ConnectionState, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	start := p.parseStart(tokenString)
+
 	token, parts, err := p.ParseUnverified(tokenString, claims)
 	if err != nil {
+		p.verifyResult(token, start, err)
 		return token, err
 	}
 
+	return p.verify(token, parts, claims, keyFunc, start, state)
+}
+
+// ParseDetached parses, validates, and verifies a token with detached content,
+// i.e. a token of the form header..signature as produced by
+// [Token.SignedDetached], as described in
+// https://datatracker.ietf.org/doc/html/rfc7515#appendix-F. The payload is
+// supplied separately and unmarshalled into claims.
+//
+// If the header contains `"b64": false`, the payload is expected to be the
+// unencoded payload as described in RFC 7797. Otherwise, it is base64url
+// encoded before the signature is verified.
+func (p *Parser) ParseDetached(tokenString string, payload []byte, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	start := p.parseStart(tokenString)
+
+	token, parts, err := p.parseDetached(tokenString, payload, claims)
+	if err != nil {
+		p.verifyResult(token, start, err)
+		return token, err
+	}
+
+	return p.verify(token, parts, claims, keyFunc, start, nil)
+}
+
+// parseDetached parses a token with detached content, but doesn't validate the
+// signature. It returns the segments of the token including the payload.
+func (p *Parser) parseDetached(tokenString string, payload []byte, claims Claims) (*Token, []string, error) {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return nil, parts, internal.NewError("token contains an invalid number of segments", internal.ErrTokenMalformed)
+	}
+	if parts[1] != "" {
+		return nil, parts, internal.NewError("token does not have detached content", internal.ErrTokenMalformed)
+	}
+
+	token := &Token{Raw: tokenString}
+
+	if err := p.parseHeader(token, parts[0]); err != nil {
+		return token, parts, err
+	}
+
+	// Reconstruct the payload segment of the signing input
+	if token.Header.unencodedPayload() {
+		parts[1] = string(payload)
+	} else {
+		parts[1] = token.EncodeSegment(payload)
+	}
+
+	if err := p.parseClaims(token, claims, payload); err != nil {
+		return token, parts, err
+	}
+
+	return token, parts, nil
+}
+
+// verify verifies the signature of an already parsed token and validates its
+// claims. parts contains the segments of the token, where the first two form
+// the signing input. start is the start of the parse and state the TLS
+// connection the token was presented on, if known.
+func (p *Parser) verify(token *Token, parts []string, claims Claims, keyFunc Keyfunc, start time.Time, state *tls.ConnectionState) (*Token, error) {
+	err := p.verifySignature(token, parts, keyFunc)
+	p.verifyResult(token, start, err)
+	if err != nil {
+		return token, err
+	}
+
+	return p.validate(token, claims, state)
+}
+
+// verifySignature verifies the signature of an already parsed token. parts
+// contains the segments of the token, where the first two form the signing
+// input and the last one is the encoded signature.
+func (p *Parser) verifySignature(token *Token, parts []string, keyFunc Keyfunc) error {
+	var err error
+
 	// Verify signing method is in the required set
 	if p.validMethods != nil {
 		var signingMethodValid = false
@@ -75,47 +189,81 @@ func (p *Parser) ParseWithClaims(tokenString string, claims Claims, keyFunc Keyf
 		}
 		if !signingMethodValid {
 			// signing method is not in the listed set
-			return token, internal.NewError(fmt.Sprintf("signing method %v is invalid", alg), internal.ErrTokenSignatureInvalid)
+			return internal.NewError(fmt.Sprintf("signing method %v is invalid", alg), internal.ErrTokenSignatureInvalid)
 		}
 	}
 
+	// Make sure we understand all critical header parameters
+	if err = p.verifyCritical(&token.Header); err != nil {
+		return err
+	}
+
 	// Decode signature
 	token.Signature, err = p.DecodeSegment(parts[2])
 	if err != nil {
-		return token, internal.NewError("could not base64 decode signature", internal.ErrTokenMalformed, err)
+		return internal.NewError("could not base64 decode signature", internal.ErrTokenMalformed, err)
 	}
 	text := strings.Join(parts[0:2], ".")
 
 	// Lookup key(s)
 	if keyFunc == nil {
 		// keyFunc was not provided.  short circuiting validation
-		return token, internal.NewError("no keyfunc was provided", internal.ErrTokenUnverifiable)
+		return internal.NewError("no keyfunc was provided", internal.ErrTokenUnverifiable)
 	}
 
+	lookup := time.Now()
 	got, err := keyFunc(token)
+	p.keyLookup(token, lookup, err)
 	if err != nil {
-		return token, internal.NewError("error while executing keyfunc", internal.ErrTokenUnverifiable, err)
+		return internal.NewError("error while executing keyfunc", internal.ErrTokenUnverifiable, err)
 	}
 
 	switch have := got.(type) {
 	case VerificationKeySet:
 		if len(have.Keys) == 0 {
-			return token, internal.NewError("keyfunc returned empty verification key set", internal.ErrTokenUnverifiable)
+			return internal.NewError("keyfunc returned empty verification key set", internal.ErrTokenUnverifiable)
 		}
 		// Iterate through keys and verify signature, skipping the rest when a match is found.
 		// Return the last error if no match is found.
 		for _, key := range have.Keys {
-			if err = token.Method.Verify(text, token.Signature, key); err == nil {
+			if err = p.verifyWithKey(token, text, key); err == nil {
 				break
 			}
 		}
 	default:
-		err = token.Method.Verify(text, token.Signature, have)
+		err = p.verifyWithKey(token, text, have)
 	}
 	if err != nil {
-		return token, internal.NewError("", internal.ErrTokenSignatureInvalid, err)
+		return internal.NewError("", internal.ErrTokenSignatureInvalid, err)
 	}
 
+	return nil
+}
+
+// verifyWithKey verifies the signature of token using key. If key is a [Key],
+// the algorithm it is bound to must match the one of the token. Otherwise, key
+// must belong to the family of the algorithm of the token.
+func (p *Parser) verifyWithKey(token *Token, text string, key interface{}) error {
+	switch k := key.(type) {
+	case Key:
+		return k.verify(token, text)
+	case *Key:
+		return k.verify(token, text)
+	}
+
+	if err := bindKey(token.Method, key); err != nil {
+		return err
+	}
+
+	return token.Method.Verify(text, token.Signature, key)
+}
+
+// validate validates the claims of a token, whose signature was already
+// verified, and marks it as valid. state is the TLS connection the token was
+// presented on, if known.
+func (p *Parser) validate(token *Token, claims Claims, state *tls.ConnectionState) (*Token, error) {
+	start := time.Now()
+
 	// Validate Claims
 	if !p.skipClaimsValidation {
 		// Make sure we have at least a default validator
@@ -123,11 +271,15 @@ func (p *Parser) ParseWithClaims(tokenString string, claims Claims, keyFunc Keyf
 			p.validator = NewValidator()
 		}
 
-		if err := p.validator.Validate(claims); err != nil {
-			return token, internal.NewError("", internal.ErrTokenInvalidClaims, err)
+		if err := p.validator.validate(claims, token, state); err != nil {
+			err = internal.NewError("", internal.ErrTokenInvalidClaims, err)
+			p.validateResult(token, start, err)
+			return token, err
 		}
 	}
 
+	p.validateResult(token, start, nil)
+
 	// No errors so far, token is valid.
 	token.Valid = true
 
@@ -148,38 +300,124 @@ func (p *Parser) ParseUnverified(tokenString string, claims Claims) (token *Toke
 
 	token = &Token{Raw: tokenString}
 
-	// parse Header
-	var headerBytes []byte
-	if headerBytes, err = p.DecodeSegment(parts[0]); err != nil {
-		return token, parts, internal.NewError("could not base64 decode header", internal.ErrTokenMalformed, err)
+	if err = p.parseHeader(token, parts[0]); err != nil {
+		return token, parts, err
 	}
-	if err = json.Unmarshal(headerBytes, &token.Header); err != nil {
-		return token, parts, internal.NewError("could not JSON decode header", internal.ErrTokenMalformed, err)
+
+	// An unencoded payload (RFC 7797) is contained as-is in the token
+	var claimBytes []byte
+	if token.Header.unencodedPayload() {
+		claimBytes = []byte(parts[1])
+	} else if claimBytes, err = p.DecodeSegment(parts[1]); err != nil {
+		return token, parts, internal.NewError("could not base64 decode claim", internal.ErrTokenMalformed, err)
 	}
 
-	// parse Claims
-	token.Claims = claims
+	if err = p.parseClaims(token, claims, claimBytes); err != nil {
+		return token, parts, err
+	}
 
-	claimBytes, err := p.DecodeSegment(parts[1])
+	return token, parts, nil
+}
+
+// parseHeader decodes the header segment into token and looks up the signing
+// method specified in it.
+func (p *Parser) parseHeader(token *Token, seg string) error {
+	headerBytes, err := p.DecodeSegment(seg)
 	if err != nil {
-		return token, parts, internal.NewError("could not base64 decode claim", internal.ErrTokenMalformed, err)
+		return internal.NewError("could not base64 decode header", internal.ErrTokenMalformed, err)
+	}
+	if err = json.Unmarshal(headerBytes, &token.Header); err != nil {
+		return internal.NewError("could not JSON decode header", internal.ErrTokenMalformed, err)
 	}
 
-	err = token.Claims.Unmarshal(claimBytes)
-	if err != nil {
-		return token, parts, internal.NewError("could not unmarshal claim", err)
+	// The b64 header parameter changes how the payload is processed, so RFC
+	// 7797 requires it to be understood by every recipient
+	if b64, ok := token.Header.Extra["b64"]; ok {
+		if _, ok = b64.(bool); !ok {
+			return internal.NewError("b64 header parameter must be a boolean", internal.ErrTokenMalformed)
+		}
+		if !token.Header.isCritical("b64") {
+			return internal.NewError("b64 header parameter must be listed in crit", internal.ErrTokenMalformed)
+		}
 	}
 
 	// Lookup signature method
-	if alg, ok := token.Header["alg"].(string); ok {
+	if alg := token.Header.Algorithm; alg != "" {
 		if token.Method = method.GetSigningMethod(alg); token.Method == nil {
-			return token, parts, internal.NewError("signing method (alg) is unavailable", internal.ErrTokenUnverifiable)
+			return internal.NewError("signing method (alg) is unavailable", internal.ErrTokenUnverifiable)
 		}
 	} else {
-		return token, parts, internal.NewError("signing method (alg) is unspecified", internal.ErrTokenUnverifiable)
+		return internal.NewError("signing method (alg) is unspecified", internal.ErrTokenUnverifiable)
 	}
 
-	return token, parts, nil
+	return nil
+}
+
+// parseClaims unmarshals the decoded payload into claims and assigns them to
+// token.
+func (p *Parser) parseClaims(token *Token, claims Claims, data []byte) error {
+	token.Claims = claims
+
+	if err := token.Claims.Unmarshal(data); err != nil {
+		return internal.NewError("could not unmarshal claim", err)
+	}
+
+	return nil
+}
+
+// verifyCritical processes the `crit` header parameter as described in
+// https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.11. The token is
+// rejected if `crit` is empty, contains duplicates or registered parameters,
+// lists a parameter that is missing from the header, or one that is not
+// understood by this parser, i.e. for which no handler was registered using
+// [WithCriticalHeaderHandler]. Otherwise, the handler of each listed parameter
+// is called with its value.
+func (p *Parser) verifyCritical(header *Header) error {
+	if header.Critical == nil {
+		return nil
+	}
+
+	if len(header.Critical) == 0 {
+		return internal.NewError("crit header parameter must not be empty", internal.ErrTokenMalformed)
+	}
+
+	for i, name := range header.Critical {
+		for _, previous := range header.Critical[:i] {
+			if name == previous {
+				return internal.NewError(fmt.Sprintf("crit header parameter lists %s twice", name), internal.ErrTokenMalformed)
+			}
+		}
+
+		for _, registered := range registeredHeaderParameters {
+			if name == registered {
+				return internal.NewError(fmt.Sprintf("crit header parameter must not list registered parameter %s", name), internal.ErrTokenMalformed)
+			}
+		}
+
+		value, ok := header.Get(name)
+		if !ok {
+			return internal.NewError(fmt.Sprintf("critical header parameter %s is missing", name), internal.ErrTokenMalformed)
+		}
+
+		handler, ok := p.criticalHeaderHandlers[name]
+		if !ok && name == "b64" {
+			// b64 is understood natively, see parseHeader
+			continue
+		}
+		if !ok {
+			return internal.NewError(fmt.Sprintf("critical header parameter %s is not supported", name), internal.ErrTokenUnverifiable)
+		}
+
+		if handler == nil {
+			continue
+		}
+
+		if err := handler(value); err != nil {
+			return internal.NewError(fmt.Sprintf("critical header parameter %s is invalid", name), internal.ErrTokenUnverifiable, err)
+		}
+	}
+
+	return nil
 }
 
 // DecodeSegment decodes a xwt specific base64url encoding. This function will
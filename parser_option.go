@@ -1,6 +1,8 @@
 package xwt
 
-import "time"
+import (
+	"time"
+)
 
 // ParserOption is used to implement functional-style options that modify the
 // behavior of the parser. To add new options, just create a function (ideally
@@ -58,6 +60,35 @@ func WithIssuedAt() ParserOption {
 	}
 }
 
+// WithMaxFutureIssuedAt returns the ParserOption to enable verification of
+// issued-at with its own tolerance for an iat in the future, which is used
+// instead of the leeway configured with [WithLeeway].
+func WithMaxFutureIssuedAt(tolerance time.Duration) ParserOption {
+	return func(p *Parser) {
+		p.validator.verifyIat = true
+		p.validator.maxFutureIat = tolerance
+		p.validator.hasMaxFutureIat = true
+	}
+}
+
+// WithMaxAge returns the ParserOption to reject tokens, which were issued more
+// than maxAge ago, regardless of their expiration time. This makes the iat
+// claim required.
+func WithMaxAge(maxAge time.Duration) ParserOption {
+	return func(p *Parser) {
+		p.validator.maxAge = maxAge
+	}
+}
+
+// WithMaxLifetime returns the ParserOption to reject tokens, whose lifetime
+// between the iat and exp claims exceeds maxLifetime, e.g. tokens minted for
+// years by a misconfigured issuer. This makes the iat and exp claims required.
+func WithMaxLifetime(maxLifetime time.Duration) ParserOption {
+	return func(p *Parser) {
+		p.validator.maxLifetime = maxLifetime
+	}
+}
+
 // WithExpirationRequired returns the ParserOption to make exp claim required.
 // By default exp claim is optional.
 func WithExpirationRequired() ParserOption {
@@ -94,6 +125,51 @@ func WithIssuer(iss string) ParserOption {
 	}
 }
 
+// WithAudiences configures the validator to require the specified audiences
+// in the `aud` claim. Depending on match, any or all of them must be listed in
+// the token. Validation will fail if the audience claim is missing.
+//
+// The option can be specified multiple times, e.g. with different values of
+// match. Each of the options is matched on its own, and all of them must be
+// satisfied:
+//
+//	// Requires api and either web or mobile
+//	xwt.WithAudiences(xwt.AudienceMatchAll, "api")
+//	xwt.WithAudiences(xwt.AudienceMatchAny, "web", "mobile")
+func WithAudiences(match AudienceMatch, auds ...string) ParserOption {
+	return func(p *Parser) {
+		if len(auds) == 0 {
+			return
+		}
+		p.validator.expectedAuds = append(p.validator.expectedAuds, audienceGroup{match: match, auds: auds})
+	}
+}
+
+// WithIssuers configures the validator to accept tokens of any of the
+// specified issuers in the `iss` claim. It can be combined with [WithIssuer]
+// and [WithIssuerPatterns], in which case the token may be issued by any of
+// the configured issuers. Validation will fail if the `iss` claim is missing.
+func WithIssuers(issuers ...string) ParserOption {
+	return func(p *Parser) {
+		p.validator.expectedIssuers = append(p.validator.expectedIssuers, issuers...)
+	}
+}
+
+// WithIssuerPatterns configures the validator to accept tokens whose `iss`
+// claim matches any of the specified patterns, e.g. of a multi-tenant issuer.
+// The values of the placeholders of the matching pattern are available in
+// Token.IssuerParams after parsing:
+//
+//	pattern, err := xwt.ParseIssuerPattern("https://login.example.com/{tenant}/v2")
+//	...
+//	token, err := xwt.NewParser(xwt.WithIssuerPatterns(pattern)).ParseWithClaims(...)
+//	tenant := token.IssuerParams["tenant"]
+func WithIssuerPatterns(patterns ...*IssuerPattern) ParserOption {
+	return func(p *Parser) {
+		p.validator.issuerPatterns = append(p.validator.issuerPatterns, patterns...)
+	}
+}
+
 // WithSubject configures the validator to require the specified subject in the
 // `sub` claim. Validation will fail if a different subject is specified in the
 // token or the `sub` claim is missing.
@@ -108,6 +184,55 @@ func WithSubject(sub string) ParserOption {
 	}
 }
 
+// WithCertificateBinding configures the validator to require a token that is
+// bound to the client certificate of a mutual TLS connection, as described in
+// https://datatracker.ietf.org/doc/html/rfc8705#section-3. The claims must
+// implement [CertificateBoundClaims]. Validation fails if the `x5t#S256` member
+// of the `cnf` claim is missing or does not match the client certificate.
+//
+// The connection is supplied for each token using [Parser.ParseWithConnection]
+// or [Validator.ValidateWithConnection], so the parser can be shared by all
+// requests:
+//
+//	p := xwt.NewParser(xwt.WithCertificateBinding())
+//	token, err := p.ParseWithConnection(tokenString, r.TLS, &jwt.RegisteredClaims{}, keyFunc)
+func WithCertificateBinding() ParserOption {
+	return func(p *Parser) {
+		p.validator.requireCertBinding = true
+	}
+}
+
+// WithRequiredScopes configures the validator to require all of the specified
+// scopes. A scope is granted by the `scope` or `permissions` claim, either
+// exactly or by a wildcard scope such as "orders:*", see [MatchScope]. The
+// claims must implement [ScopedClaims].
+func WithRequiredScopes(scopes ...string) ParserOption {
+	return func(p *Parser) {
+		p.validator.requiredScopes = append(p.validator.requiredScopes, scopes...)
+	}
+}
+
+// WithAnyScope configures the validator to require at least one of the
+// specified scopes. Scopes are matched like in [WithRequiredScopes].
+func WithAnyScope(scopes ...string) ParserOption {
+	return func(p *Parser) {
+		p.validator.anyScopes = append(p.validator.anyScopes, scopes...)
+	}
+}
+
+// WithPolicy configures the validator to require that tokens satisfy an
+// application-specific policy, e.g. one compiled from an expression by the
+// policy package:
+//
+//	p := xwt.NewParser(xwt.WithPolicy(policy.MustCompile(`"admin" in claims.roles`)))
+//
+// Multiple policies can be supplied, which must all be satisfied.
+func WithPolicy(policy Policy) ParserOption {
+	return func(p *Parser) {
+		p.validator.policies = append(p.validator.policies, policy)
+	}
+}
+
 // WithPaddingAllowed will enable the codec used for decoding xwts to allow
 // padding. Note that the JWS RFC7515 states that the tokens will utilize a
 // Base64url encoding with no padding. Unfortunately, some implementations of
@@ -126,3 +251,39 @@ func WithStrictDecoding() ParserOption {
 		p.decodeStrict = true
 	}
 }
+
+// WithCriticalHeaderHandler registers the header parameter name as understood
+// by the parser, if it is listed in the `crit` header parameter of a token.
+// Tokens listing any other parameter in `crit` are rejected, as required by
+// https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.11.
+//
+// The handler is called with the decoded value of the parameter and can be used
+// to validate it, e.g. against application-specific state. Returning an error
+// rejects the token. A nil handler marks the parameter as understood without
+// any further validation.
+func WithCriticalHeaderHandler(name string, handler func(value interface{}) error) ParserOption {
+	return func(p *Parser) {
+		if p.criticalHeaderHandlers == nil {
+			p.criticalHeaderHandlers = map[string]func(value interface{}) error{}
+		}
+		p.criticalHeaderHandlers[name] = handler
+	}
+}
+
+// WithSignaturePolicy configures which signatures of a token in JWS JSON
+// serialization need to be valid when using [Parser.ParseJSON]. By default,
+// [SignaturePolicyAny] is used.
+func WithSignaturePolicy(policy SignaturePolicy) ParserOption {
+	return func(p *Parser) {
+		p.signaturePolicy = policy
+	}
+}
+
+// WithParserHooks registers hooks, which observe the stages of parsing, e.g.
+// to export metrics or to write audit events. The hooks are called in the
+// order they are registered.
+func WithParserHooks(hooks ...ParserHook) ParserOption {
+	return func(p *Parser) {
+		p.hooks = append(p.hooks, hooks...)
+	}
+}
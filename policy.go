@@ -0,0 +1,12 @@
+package xwt
+
+// Policy is an application-specific rule, which the claims and header of a
+// token must satisfy. It is evaluated by the [Validator], after all other
+// claims were validated, see [WithPolicy]. Package policy implements policies
+// written in an expression language.
+type Policy interface {
+	// Evaluate returns an error, if the token does not satisfy the policy.
+	// The header is nil, if the claims are validated without a token, i.e.
+	// using [Validator.Validate].
+	Evaluate(claims Claims, header *Header) error
+}
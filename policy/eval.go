@@ -0,0 +1,387 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/lkyzhu/xwt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// environment contains the values of the root identifiers.
+type environment struct {
+	claims interface{}
+	header interface{}
+}
+
+// headerValue exposes the parameters of a JOSE header.
+type headerValue struct {
+	header *xwt.Header
+}
+
+func (n *literal) eval(env *environment) (interface{}, error) {
+	return n.value, nil
+}
+
+func (n *list) eval(env *environment) (interface{}, error) {
+	values := make([]interface{}, 0, len(n.elems))
+	for _, elem := range n.elems {
+		v, err := elem.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+func (n *root) eval(env *environment) (interface{}, error) {
+	if n.name == "header" {
+		return env.header, nil
+	}
+
+	return env.claims, nil
+}
+
+func (n *member) eval(env *environment) (interface{}, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := n.index.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	return lookup(x, index)
+}
+
+func (n *unary) eval(env *environment) (interface{}, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "!":
+		b, ok := x.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operand of ! must be a boolean, got %s", typeName(x))
+		}
+		return !b, nil
+	default:
+		f, ok := x.(float64)
+		if !ok {
+			return nil, fmt.Errorf("operand of - must be a number, got %s", typeName(x))
+		}
+		return -f, nil
+	}
+}
+
+func (n *binary) eval(env *environment) (interface{}, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// The logical operators short-circuit
+	if n.op == "&&" || n.op == "||" {
+		b, ok := x.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operand of %s must be a boolean, got %s", n.op, typeName(x))
+		}
+		if b == (n.op == "||") {
+			return b, nil
+		}
+
+		y, err := n.y.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		b, ok = y.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operand of %s must be a boolean, got %s", n.op, typeName(y))
+		}
+		return b, nil
+	}
+
+	y, err := n.y.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(x, y), nil
+	case "!=":
+		return !equal(x, y), nil
+	case "in":
+		return contains(y, x)
+	}
+
+	return compare(n.op, x, y)
+}
+
+// lookup returns the member of x with the given index. Accessing a member of
+// null, or a missing member, results in null, so that optional claims can be
+// compared without checking for their presence first.
+func lookup(x, index interface{}) (interface{}, error) {
+	if x == nil {
+		return nil, nil
+	}
+
+	if i, ok := index.(float64); ok {
+		values, ok := x.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot index %s with a number", typeName(x))
+		}
+		if i != math.Trunc(i) || i < 0 || int(i) >= len(values) {
+			return nil, nil
+		}
+		return values[int(i)], nil
+	}
+
+	name, ok := index.(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot index %s with %s", typeName(x), typeName(index))
+	}
+
+	switch v := x.(type) {
+	case map[string]interface{}:
+		return normalize(v[name]), nil
+	case headerValue:
+		value, ok := v.header.Get(name)
+		if !ok {
+			return nil, nil
+		}
+		return normalize(value), nil
+	case protoreflect.Message:
+		return protoMember(v, name), nil
+	}
+
+	return nil, fmt.Errorf("cannot access member %s of %s", name, typeName(x))
+}
+
+// protoMember returns the field of a protobuf message. The field is looked up
+// by its name, its JSON name, or case-insensitively, so that both
+// claims.Subject and claims.subject refer to the same field.
+func protoMember(m protoreflect.Message, name string) interface{} {
+	fields := m.Descriptor().Fields()
+
+	fd := fields.ByName(protoreflect.Name(name))
+	if fd == nil {
+		fd = fields.ByJSONName(name)
+	}
+	for i := 0; fd == nil && i < fields.Len(); i++ {
+		if strings.EqualFold(string(fields.Get(i).Name()), name) {
+			fd = fields.Get(i)
+		}
+	}
+	if fd == nil {
+		return nil
+	}
+
+	// Unset messages are null, unset scalars have their default value
+	if fd.Message() != nil && !fd.IsList() && !fd.IsMap() && !m.Has(fd) {
+		return nil
+	}
+
+	v := m.Get(fd)
+	switch {
+	case fd.IsList():
+		l := v.List()
+		values := make([]interface{}, 0, l.Len())
+		for i := 0; i < l.Len(); i++ {
+			values = append(values, protoValue(fd, l.Get(i)))
+		}
+		return values
+	case fd.IsMap():
+		values := map[string]interface{}{}
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			values[k.String()] = protoValue(fd.MapValue(), v)
+			return true
+		})
+		return values
+	}
+
+	return protoValue(fd, v)
+}
+
+// protoValue converts a singular protobuf value.
+func protoValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return v.Bool()
+	case protoreflect.StringKind:
+		return v.String()
+	case protoreflect.BytesKind:
+		return string(v.Bytes())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return float64(v.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		// Timestamps are seconds since the UNIX epoch, like the times of JWT
+		// claims
+		if fd.Message().FullName() == "google.protobuf.Timestamp" {
+			m := v.Message()
+			fields := m.Descriptor().Fields()
+			return float64(m.Get(fields.ByName("seconds")).Int()) + float64(m.Get(fields.ByName("nanos")).Int())/1e9
+		}
+		return v.Message()
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return float64(v.Uint())
+	}
+
+	return float64(v.Int())
+}
+
+// normalize converts the values of JSON claims into the types used by the
+// evaluation: numbers are float64, and lists are []interface{}.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		values := make([]interface{}, 0, len(v))
+		for _, s := range v {
+			values = append(values, s)
+		}
+		return values
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			values = append(values, normalize(e))
+		}
+		return values
+	}
+
+	return v
+}
+
+// equal reports whether two values are equal. Values of different types are
+// never equal.
+func equal(x, y interface{}) bool {
+	switch x := x.(type) {
+	case nil:
+		return y == nil
+	case bool, float64, string:
+		return x == y
+	case []interface{}:
+		y, ok := y.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// contains implements the in operator: it reports whether the list y contains
+// x, or the object y has a member named x. If y is null, the result is false.
+func contains(y, x interface{}) (interface{}, error) {
+	switch y := y.(type) {
+	case nil:
+		return false, nil
+	case []interface{}:
+		for _, e := range y {
+			if equal(x, e) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}, headerValue, protoreflect.Message:
+		name, ok := x.(string)
+		if !ok {
+			return nil, fmt.Errorf("left operand of in must be a string for %s, got %s", typeName(y), typeName(x))
+		}
+		v, err := lookup(y, name)
+		return v != nil, err
+	}
+
+	return nil, fmt.Errorf("right operand of in must be a list or object, got %s", typeName(y))
+}
+
+// compare implements the ordering operators for numbers and strings.
+func compare(op string, x, y interface{}) (interface{}, error) {
+	var c int
+
+	switch x := x.(type) {
+	case float64:
+		f, ok := y.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare number with %s", typeName(y))
+		}
+		switch {
+		case x < f:
+			c = -1
+		case x > f:
+			c = 1
+		}
+	case string:
+		s, ok := y.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare string with %s", typeName(y))
+		}
+		c = strings.Compare(x, s)
+	default:
+		return nil, fmt.Errorf("operands of %s must be numbers or strings, got %s", op, typeName(x))
+	}
+
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+
+	return c >= 0, nil
+}
+
+// typeName returns the name of the type of a value for error messages.
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	}
+
+	return "object"
+}
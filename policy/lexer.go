@@ -0,0 +1,134 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+)
+
+// token is a lexical token of an expression.
+type token struct {
+	kind tokenKind
+	text string  // text is the operator, identifier or unquoted string
+	num  float64 // num is the value of a number
+	pos  int     // pos is the byte offset of the token in the expression
+}
+
+// operators are ordered such that longer operators are matched first.
+var operators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"<", ">", "!", "-", "(", ")", "[", "]", ".", ",",
+}
+
+// lex splits an expression into tokens.
+func lex(src string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+
+		case r == '"' || r == '\'':
+			text, n, err := lexString(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at position %d", err, i)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: i})
+			i += n
+
+		case r >= '0' && r <= '9':
+			n := i
+			for n < len(src) && (isDigit(src[n]) || src[n] == '.' || src[n] == 'e' || src[n] == 'E' ||
+				((src[n] == '+' || src[n] == '-') && (src[n-1] == 'e' || src[n-1] == 'E'))) {
+				n++
+			}
+			num, err := strconv.ParseFloat(src[i:n], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %s at position %d", src[i:n], i)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[i:n], num: num, pos: i})
+			i = n
+
+		case r == '_' || unicode.IsLetter(r):
+			n := i
+			for n < len(src) {
+				r, size := utf8.DecodeRuneInString(src[n:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				n += size
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[i:n], pos: i})
+			i = n
+
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+			i += len(op)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+// lexString unquotes the string literal at the beginning of src, which is
+// either enclosed in double or single quotes. It returns the string and the
+// length of the literal.
+func lexString(src string) (string, int, error) {
+	quote := src[0]
+
+	var b strings.Builder
+	for i := 1; i < len(src); i++ {
+		switch c := src[i]; c {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 >= len(src) {
+				return "", 0, fmt.Errorf("unterminated string")
+			}
+			i++
+			switch e := src[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '\'':
+				b.WriteByte(e)
+			default:
+				return "", 0, fmt.Errorf("invalid escape sequence \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
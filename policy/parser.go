@@ -0,0 +1,285 @@
+package policy
+
+import (
+	"fmt"
+)
+
+// node is a node of the syntax tree of an expression.
+type node interface {
+	eval(env *environment) (interface{}, error)
+}
+
+type (
+	// literal is a string, number, boolean or null literal.
+	literal struct {
+		value interface{}
+	}
+
+	// list is a list literal.
+	list struct {
+		elems []node
+	}
+
+	// root is one of the root identifiers `claims` and `header`.
+	root struct {
+		name string
+	}
+
+	// member is a member access, either x.name or x[index].
+	member struct {
+		x     node
+		index node
+	}
+
+	// unary is a unary operation.
+	unary struct {
+		op string
+		x  node
+	}
+
+	// binary is a binary operation.
+	binary struct {
+		op   string
+		x, y node
+	}
+)
+
+// roots are the identifiers an expression can refer to.
+var roots = map[string]bool{
+	"claims": true,
+	"header": true,
+}
+
+// parser is a recursive descent parser for expressions. The grammar is, in
+// order of increasing precedence:
+//
+//	or         = and { "||" and }
+//	and        = comparison { "&&" comparison }
+//	comparison = unary [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "in" ) unary ]
+//	unary      = ( "!" | "-" ) unary | postfix
+//	postfix    = primary { "." identifier | "[" or "]" }
+//	primary    = number | string | "true" | "false" | "null" | "claims" | "header"
+//	           | "(" or ")" | "[" [ or { "," or } ] "]"
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse parses an expression into its syntax tree.
+func parse(src string) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.unexpected(t)
+	}
+
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token, if it is one of the operators or keywords.
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator && t.kind != tokenIdent {
+		return "", false
+	}
+
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		return p.unexpected(p.peek())
+	}
+	return nil
+}
+
+func (p *parser) unexpected(t token) error {
+	if t.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected %s at position %d", t.text, t.pos)
+}
+
+func (p *parser) or() (node, error) {
+	x, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("||"); !ok {
+			return x, nil
+		}
+
+		y, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{op: "||", x: x, y: y}
+	}
+}
+
+func (p *parser) and() (node, error) {
+	x, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("&&"); !ok {
+			return x, nil
+		}
+
+		y, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{op: "&&", x: x, y: y}
+	}
+}
+
+func (p *parser) comparison() (node, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	op, ok := p.accept("==", "!=", "<", "<=", ">", ">=", "in")
+	if !ok {
+		return x, nil
+	}
+
+	y, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	return &binary{op: op, x: x, y: y}, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{op: op, x: x}, nil
+	}
+
+	return p.postfix()
+}
+
+func (p *parser) postfix() (node, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("."); ok {
+			t := p.next()
+			if t.kind != tokenIdent {
+				return nil, p.unexpected(t)
+			}
+			x = &member{x: x, index: &literal{value: t.text}}
+		} else if _, ok := p.accept("["); ok {
+			index, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = &member{x: x, index: index}
+		} else {
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenNumber:
+		return &literal{value: t.num}, nil
+	case tokenString:
+		return &literal{value: t.text}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		case "null":
+			return &literal{value: nil}, nil
+		}
+
+		if !roots[t.text] {
+			return nil, fmt.Errorf("unknown identifier %s at position %d, expected claims or header", t.text, t.pos)
+		}
+		return &root{name: t.text}, nil
+	case tokenOperator:
+		switch t.text {
+		case "(":
+			x, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		case "[":
+			l := &list{}
+			if _, ok := p.accept("]"); ok {
+				return l, nil
+			}
+			for {
+				elem, err := p.or()
+				if err != nil {
+					return nil, err
+				}
+				l.elems = append(l.elems, elem)
+
+				if _, ok := p.accept("]"); ok {
+					return l, nil
+				}
+				if err = p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return nil, p.unexpected(t)
+}
@@ -0,0 +1,141 @@
+// Package policy implements [xwt.Policy] using a small expression language, so
+// that authorization rules can be shipped as configuration instead of code:
+//
+//	p, err := policy.Compile(`claims.tenant == header.tenant && "admin" in claims.roles && claims.age >= 18`)
+//	if err != nil {
+//	    return err
+//	}
+//	parser := xwt.NewParser(xwt.WithPolicy(p))
+//
+// An expression refers to the claims of the token as `claims` and to its JOSE
+// header as `header`. Members are accessed using `.name` or `["name"]`, list
+// elements using `[index]`. Accessing a missing member results in null, also
+// for nested members.
+//
+// JSON claims are accessed by their member names, e.g. `claims.sub`. PWT claims
+// are accessed through protobuf reflection by their field names, e.g.
+// `claims.Subject` or, ignoring case, `claims.subject`. Timestamps are
+// converted into seconds since the UNIX epoch, like the times of JWT claims.
+//
+// The language supports string ("..." or '...'), number, boolean and null
+// literals, list literals such as ["a", "b"], and the following operators, in
+// order of increasing precedence:
+//
+//	||                    logical or
+//	&&                    logical and
+//	== != < <= > >= in    comparison, list membership and member presence
+//	! -                   logical not, negation
+//
+// Values of different types are never equal, and ordering is only defined for
+// numbers and strings. An expression, which does not evaluate to a boolean or
+// fails to evaluate, is not satisfied.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Policy is a compiled expression. It is safe for concurrent use. The
+// [Compile] function should be used to create an instance of this struct.
+type Policy struct {
+	src  string
+	root node
+}
+
+// Compile parses an expression into a policy.
+func Compile(src string) (*Policy, error) {
+	root, err := parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile policy %s: %w", src, err)
+	}
+
+	return &Policy{src: src, root: root}, nil
+}
+
+// MustCompile is like [Compile], but panics if the expression cannot be
+// parsed. It simplifies the initialization of global variables holding
+// policies.
+func MustCompile(src string) *Policy {
+	p, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// String returns the source of the policy.
+func (p *Policy) String() string {
+	return p.src
+}
+
+// Evaluate implements the [xwt.Policy] interface.
+func (p *Policy) Evaluate(claims xwt.Claims, header *xwt.Header) error {
+	ok, err := p.Satisfied(claims, header)
+	if err != nil {
+		return internal.NewError(fmt.Sprintf("policy %s could not be evaluated", p.src), internal.ErrTokenPolicyViolation, err)
+	}
+	if !ok {
+		return internal.NewError(fmt.Sprintf("policy %s is not satisfied", p.src), internal.ErrTokenPolicyViolation)
+	}
+
+	return nil
+}
+
+// Satisfied evaluates the policy and reports whether the claims and header
+// satisfy it. The header may be nil.
+func (p *Policy) Satisfied(claims xwt.Claims, header *xwt.Header) (bool, error) {
+	env := &environment{}
+
+	if header != nil {
+		env.header = headerValue{header: header}
+	}
+
+	var err error
+	if env.claims, err = claimsValue(claims); err != nil {
+		return false, err
+	}
+
+	v, err := p.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("policy evaluates to %s instead of a boolean", typeName(v))
+	}
+
+	return b, nil
+}
+
+// claimsValue returns the value of the `claims` root identifier: a protobuf
+// message for PWT claims, and the decoded JSON object for any other claims.
+func claimsValue(claims xwt.Claims) (interface{}, error) {
+	switch c := claims.(type) {
+	case nil:
+		return nil, nil
+	case *jwt.MapClaims:
+		return map[string]interface{}(*c), nil
+	case protoreflect.ProtoMessage:
+		return c.ProtoReflect(), nil
+	}
+
+	data, err := claims.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("claims of type %s are not supported: %w", claims.Type(), err)
+	}
+
+	return m, nil
+}
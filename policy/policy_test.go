@@ -0,0 +1,267 @@
+package policy_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/policy"
+	"github.com/lkyzhu/xwt/pwt"
+	"github.com/lkyzhu/xwt/pwt/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		src     string
+		wantErr string
+	}{
+		{`claims.sub == "alice`, "unterminated string at position 14"},
+		{`claims.sub == 'alice\`, "unterminated string at position 14"},
+		{`claims.sub == "a\x"`, "invalid escape sequence \\x at position 14"},
+		{`claims.sub # "alice"`, "unexpected character '#' at position 11"},
+		{`claims.sub = "alice"`, "unexpected character '=' at position 11"},
+		{`claims.age > 1e`, "invalid number 1e at position 13"},
+		{`claims.age > 1.2.3`, "invalid number 1.2.3 at position 13"},
+		{`user.sub == "alice"`, "unknown identifier user at position 0, expected claims or header"},
+		{`claims.sub == "alice" "bob"`, "unexpected bob at position 22"},
+		{`claims.sub ==`, "unexpected end of expression"},
+		{`claims.`, "unexpected end of expression"},
+		{`claims.["sub"]`, "unexpected [ at position 7"},
+		{`claims["sub" == "alice"`, "unexpected end of expression"},
+		{`(claims.sub == "alice"`, "unexpected end of expression"},
+		{`claims.sub in ["alice", "bob"`, "unexpected end of expression"},
+		{`claims.sub in ["alice" "bob"]`, "unexpected bob at position 23"},
+		{`claims.age > 1 < 2`, "unexpected < at position 15"},
+		{`claims.sub == )`, "unexpected ) at position 14"},
+		{``, "unexpected end of expression"},
+	}
+
+	for _, tt := range tests {
+		_, err := policy.Compile(tt.src)
+		if err == nil || !strings.HasSuffix(err.Error(), tt.wantErr) {
+			t.Errorf("Compile(%s) error = %v, want %s", tt.src, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSatisfied(t *testing.T) {
+	claims := &jwt.MapClaims{
+		"sub":    "alice",
+		"age":    42,
+		"roles":  []interface{}{"admin", "user"},
+		"tenant": "acme",
+		"admin":  true,
+		"quote":  "it's \"quoted\"\n",
+		"address": map[string]interface{}{
+			"country": "DE",
+		},
+	}
+
+	header := &xwt.Header{}
+	header.Set("tenant", "acme")
+
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		// Literals
+		{`true`, true},
+		{`false`, false},
+		{`null == null`, true},
+		{`1.5e1 == 15`, true},
+		{`2E-1 == 0.2`, true},
+		{`"a" == 'a'`, true},
+		{`claims.quote == "it's \"quoted\"\n"`, true},
+		{`claims.quote == 'it\'s "quoted"\n'`, true},
+		{`"a\tb\\" == 'a	b\\'`, true},
+
+		// Precedence
+		{`true || false && false`, true},
+		{`(true || false) && false`, false},
+		{`!false && true`, true},
+		{`!(true && false)`, true},
+		{`!!true`, true},
+		{`-1 < 0`, true},
+		{`--1 == 1`, true},
+		{`claims.age == 42 || claims.age < 0 && false`, true},
+
+		// Members
+		{`claims.sub == "alice"`, true},
+		{`claims["sub"] == "alice"`, true},
+		{`claims.address.country == "DE"`, true},
+		{`claims["address"]["country"] == "DE"`, true},
+		{`claims.roles[0] == "admin"`, true},
+		{`claims.roles[1] == "user"`, true},
+		{`claims.roles[2] == null`, true},
+		{`claims.roles[0.5] == null`, true},
+		{`claims.missing == null`, true},
+		{`claims.missing.nested == null`, true},
+		{`header.tenant == claims.tenant`, true},
+		{`header.alg == null`, true},
+
+		// Membership and presence
+		{`"admin" in claims.roles`, true},
+		{`"guest" in claims.roles`, false},
+		{`claims.sub in ["alice", "bob"]`, true},
+		{`claims.sub in []`, false},
+		{`"admin" in claims.missing`, false},
+		{`"sub" in claims`, true},
+		{`"missing" in claims`, false},
+		{`"tenant" in header`, true},
+		{`"country" in claims.address`, true},
+		{`[1, 2] in [[1, 2], [3]]`, true},
+
+		// Comparisons
+		{`claims.age >= 42`, true},
+		{`claims.age > 42`, false},
+		{`claims.age <= 42`, true},
+		{`claims.age < 42`, false},
+		{`claims.age != 42`, false},
+		{`"a" < "b"`, true},
+		{`"b" >= "a"`, true},
+		{`claims.admin == true`, true},
+		{`claims.roles == ["admin", "user"]`, true},
+		{`claims.roles == ["user", "admin"]`, false},
+		// Values of different types are never equal
+		{`claims.age == "42"`, false},
+		{`claims.age != "42"`, true},
+		{`claims.missing == false`, false},
+		{`0 == false`, false},
+
+		// Logical operators short-circuit
+		{`false && claims.sub`, false},
+		{`true || claims.sub`, true},
+	}
+
+	for _, tt := range tests {
+		got, err := policy.MustCompile(tt.src).Satisfied(claims, header)
+		if err != nil {
+			t.Errorf("Satisfied(%s) error = %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Satisfied(%s) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestSatisfiedErrors(t *testing.T) {
+	claims := &jwt.MapClaims{"sub": "alice", "age": 42, "roles": []interface{}{"admin"}}
+
+	tests := []struct {
+		src     string
+		wantErr string
+	}{
+		{`claims.sub`, "policy evaluates to string instead of a boolean"},
+		{`claims.missing`, "policy evaluates to null instead of a boolean"},
+		{`claims.sub || true`, "operand of || must be a boolean, got string"},
+		{`true && claims.age`, "operand of && must be a boolean, got number"},
+		{`!claims.sub`, "operand of ! must be a boolean, got string"},
+		{`-claims.sub == 1`, "operand of - must be a number, got string"},
+		{`claims.age < "42"`, "cannot compare number with string"},
+		{`claims.sub > 1`, "cannot compare string with number"},
+		{`claims.missing < 1`, "operands of < must be numbers or strings, got null"},
+		{`true < false`, "operands of < must be numbers or strings, got boolean"},
+		{`claims.sub in claims.sub`, "right operand of in must be a list or object, got string"},
+		{`1 in claims`, "left operand of in must be a string for object, got number"},
+		{`claims[0] == null`, "cannot index object with a number"},
+		{`claims.roles.first == null`, "cannot access member first of list"},
+		{`claims.sub.first == null`, "cannot access member first of string"},
+		{`claims[true] == null`, "cannot index object with boolean"},
+		{`header.alg == null`, ""},
+	}
+
+	for _, tt := range tests {
+		_, err := policy.MustCompile(tt.src).Satisfied(claims, nil)
+		if tt.wantErr == "" {
+			// Without a header, header is null, so that its members are null
+			if err != nil {
+				t.Errorf("Satisfied(%s) error = %v", tt.src, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("Satisfied(%s) error = %v, want %s", tt.src, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSatisfiedProto(t *testing.T) {
+	claims := &pwt.RegisteredClaims{StandardClaims: pb.StandardClaims{
+		Subject:  "alice",
+		Roles:    []string{"admin"},
+		IssuedAt: 1700000000,
+	}}
+	claimsV2 := &pwt.RegisteredClaimsV2{StandardClaimsV2: pb.StandardClaimsV2{
+		Subject:  "alice",
+		IssuedAt: &timestamppb.Timestamp{Seconds: 1700000000, Nanos: 500000000},
+	}}
+
+	tests := []struct {
+		claims xwt.Claims
+		src    string
+		want   bool
+	}{
+		{claims, `claims.Subject == "alice"`, true},
+		{claims, `claims.subject == "alice"`, true},
+		{claims, `claims["SUBJECT"] == "alice"`, true},
+		{claims, `"admin" in claims.roles`, true},
+		{claims, `claims.IssuedAt == 1700000000`, true},
+		{claims, `claims.Issuer == ""`, true},
+		{claims, `claims.Confirmation == null`, true},
+		{claims, `claims.Confirmation.jkt == null`, true},
+		{claims, `claims.missing == null`, true},
+		{claims, `"Subject" in claims`, true},
+		{claims, `"missing" in claims`, false},
+		{claimsV2, `claims.IssuedAt == 1700000000.5`, true},
+		{claimsV2, `claims.ExpiresAt == null`, true},
+	}
+
+	for _, tt := range tests {
+		got, err := policy.MustCompile(tt.src).Satisfied(tt.claims, nil)
+		if err != nil {
+			t.Errorf("Satisfied(%s) error = %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Satisfied(%s) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	p := policy.MustCompile(`claims.sub == "alice"`)
+	if got := p.String(); got != `claims.sub == "alice"` {
+		t.Fatalf("String() = %s", got)
+	}
+
+	if err := p.Evaluate(&jwt.MapClaims{"sub": "alice"}, nil); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if err := p.Evaluate(&jwt.MapClaims{"sub": "bob"}, nil); !errors.Is(err, internal.ErrTokenPolicyViolation) {
+		t.Fatalf("Evaluate() error = %v, want %v", err, internal.ErrTokenPolicyViolation)
+	}
+	if err := policy.MustCompile(`claims.sub`).Evaluate(&jwt.MapClaims{"sub": "alice"}, nil); !errors.Is(err, internal.ErrTokenPolicyViolation) {
+		t.Fatalf("Evaluate() error = %v, want %v", err, internal.ErrTokenPolicyViolation)
+	}
+
+	// Claims, which are neither maps nor protobuf messages, are accessed by
+	// their JSON members
+	if err := p.Evaluate(&jwt.RegisteredClaims{Subject: "alice"}, nil); err != nil {
+		t.Fatalf("Evaluate() of RegisteredClaims error = %v", err)
+	}
+}
+
+func TestMustCompile(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustCompile() did not panic")
+		}
+	}()
+
+	policy.MustCompile(`claims.sub ==`)
+}
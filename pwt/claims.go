@@ -1,6 +1,12 @@
 package pwt
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/lkyzhu/xwt/internal"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
@@ -10,11 +16,53 @@ const (
 
 // Claims represent any form of a PWT Claims
 type Claims interface {
-	GetExpirationTime() int64
-	GetIssuedAt() int64
-	GetNotBefore() int64
+	GetExpirationTime() time.Time
+	GetIssuedAt() time.Time
+	GetNotBefore() time.Time
 	GetIssuer() string
 	GetSubject() string
 	GetAudience() []string
 	protoreflect.ProtoMessage
 }
+
+// timeFields are the names of the fields of the registered claims containing
+// times, whose encoding differs between [RegisteredClaims] and
+// [RegisteredClaimsV2].
+var timeFields = []protoreflect.Name{"ExpiresAt", "NotBefore", "IssuedAt"}
+
+// unmarshalClaims unmarshals the registered claims m. As the time fields of
+// the versions of the registered claims have the same numbers, but different
+// wire types, a time of the other version is not decoded, but retained as an
+// unknown field. In that case, an error is returned instead of silently
+// dropping the time, e.g. the expiration of the token.
+func unmarshalClaims(data []byte, m proto.Message) error {
+	if err := proto.Unmarshal(data, m); err != nil {
+		return err
+	}
+
+	msg := m.ProtoReflect()
+	fields := msg.Descriptor().Fields()
+
+	unknown := msg.GetUnknown()
+	for len(unknown) > 0 {
+		num, typ, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		unknown = unknown[n:]
+
+		for _, name := range timeFields {
+			if fd := fields.ByName(name); fd != nil && fd.Number() == num {
+				return internal.NewError(fmt.Sprintf("%s claim has wire type %d of another version of the registered claims", name, typ), internal.ErrTokenMalformed)
+			}
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, unknown)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		unknown = unknown[n:]
+	}
+
+	return nil
+}
@@ -1,6 +1,9 @@
 package pwt
 
 import (
+	"strings"
+	"time"
+
 	"github.com/lkyzhu/xwt/pwt/pb"
 	"google.golang.org/protobuf/proto"
 )
@@ -13,24 +16,27 @@ import (
 // public claims embedded in the pwt will not be parsed. The typical use-case
 // therefore is to embedded this in a user-defined claim type.
 //
+// The times are whole seconds since the UNIX epoch. [RegisteredClaimsV2]
+// should be used for sub-second precision.
+//
 // See examples for how to use this with your own claim types.
 type RegisteredClaims struct {
 	pb.StandardClaims
 }
 
 // GetExpirationTime implements the Claims interface.
-func (c *RegisteredClaims) GetExpirationTime() int64 {
-	return c.ExpiresAt
+func (c *RegisteredClaims) GetExpirationTime() time.Time {
+	return unixTime(c.ExpiresAt)
 }
 
 // GetNotBefore implements the Claims interface.
-func (c *RegisteredClaims) GetNotBefore() int64 {
-	return c.NotBefore
+func (c *RegisteredClaims) GetNotBefore() time.Time {
+	return unixTime(c.NotBefore)
 }
 
 // GetIssuedAt implements the Claims interface.
-func (c *RegisteredClaims) GetIssuedAt() int64 {
-	return c.IssuedAt
+func (c *RegisteredClaims) GetIssuedAt() time.Time {
+	return unixTime(c.IssuedAt)
 }
 
 // GetAudience implements the Claims interface.
@@ -48,6 +54,23 @@ func (c *RegisteredClaims) GetSubject() string {
 	return c.Subject
 }
 
+// GetConfirmationJWKThumbprint returns the JWK thumbprint of the `cnf` claim,
+// which binds the token to a DPoP key.
+func (c *RegisteredClaims) GetConfirmationJWKThumbprint() string {
+	return c.GetConfirmation().GetJWKThumbprint()
+}
+
+// GetConfirmationX509Thumbprint returns the certificate thumbprint of the `cnf`
+// claim, which binds the token to a mutual TLS client certificate.
+func (c *RegisteredClaims) GetConfirmationX509Thumbprint() string {
+	return c.GetConfirmation().GetX509CertThumbprintS256()
+}
+
+// GetScopes returns the scopes of the space-delimited `scope` claim.
+func (c *RegisteredClaims) GetScopes() []string {
+	return strings.Fields(c.Scope)
+}
+
 // Type implements the Claims interface.
 func (c *RegisteredClaims) Type() string {
 	return Type
@@ -58,7 +81,18 @@ func (c *RegisteredClaims) Marshal() ([]byte, error) {
 	return proto.Marshal(c)
 }
 
-// Unmarshal implements the Claims interface.
+// Unmarshal implements the Claims interface. It fails if the claims were
+// marshalled by [RegisteredClaimsV2], whose times are not compatible.
 func (c *RegisteredClaims) Unmarshal(data []byte) error {
-	return proto.Unmarshal(data, c)
+	return unmarshalClaims(data, c)
+}
+
+// unixTime returns the time of the seconds since the UNIX epoch, or the zero
+// time if sec is zero.
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(sec, 0)
 }
@@ -0,0 +1,106 @@
+package pwt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/method"
+	"github.com/lkyzhu/xwt/pwt"
+	"github.com/lkyzhu/xwt/pwt/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func keyfunc(*xwt.Token) (interface{}, error) {
+	return secret, nil
+}
+
+func TestRegisteredClaimsVersions(t *testing.T) {
+	exp := time.Now().Add(time.Hour)
+
+	v1, err := xwt.NewWithClaims(method.SigningMethodHS256, &pwt.RegisteredClaims{StandardClaims: pb.StandardClaims{
+		Subject:   "alice",
+		ExpiresAt: exp.Unix(),
+	}}).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := xwt.NewWithClaims(method.SigningMethodHS256, &pwt.RegisteredClaimsV2{StandardClaimsV2: pb.StandardClaimsV2{
+		Subject:   "alice",
+		ExpiresAt: timestamppb.New(exp),
+	}}).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Tokens without times can be parsed with either version
+	untimed, err := xwt.NewWithClaims(method.SigningMethodHS256, &pwt.RegisteredClaimsV2{StandardClaimsV2: pb.StandardClaimsV2{
+		Subject: "alice",
+	}}).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		claims  xwt.Claims
+		wantErr bool
+	}{
+		{"v1", v1, &pwt.RegisteredClaims{}, false},
+		{"v2", v2, &pwt.RegisteredClaimsV2{}, false},
+		{"v2 with v1", v2, &pwt.RegisteredClaims{}, true},
+		{"v1 with v2", v1, &pwt.RegisteredClaimsV2{}, true},
+		{"untimed with v1", untimed, &pwt.RegisteredClaims{}, false},
+		{"untimed with v2", untimed, &pwt.RegisteredClaimsV2{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := xwt.NewParser().ParseWithClaims(tt.token, tt.claims, keyfunc)
+			if tt.wantErr {
+				if !errors.Is(err, internal.ErrTokenMalformed) {
+					t.Fatalf("ParseWithClaims() error = %v, want %v", err, internal.ErrTokenMalformed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWithClaims() error = %v", err)
+			}
+			if got := tt.claims.GetSubject(); got != "alice" {
+				t.Fatalf("GetSubject() = %s, want alice", got)
+			}
+			if tt.token != untimed && tt.claims.GetExpirationTime().Unix() != exp.Unix() {
+				t.Fatalf("GetExpirationTime() = %v, want %v", tt.claims.GetExpirationTime(), exp)
+			}
+		})
+	}
+}
+
+func TestRegisteredClaimsV2Precision(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	claims := &pwt.RegisteredClaimsV2{StandardClaimsV2: pb.StandardClaimsV2{
+		ExpiresAt: timestamppb.New(now.Add(250 * time.Millisecond)),
+	}}
+
+	tests := []struct {
+		now     time.Time
+		wantErr error
+	}{
+		{now.Add(249 * time.Millisecond), nil},
+		{now.Add(250 * time.Millisecond), internal.ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		err := xwt.NewValidator(xwt.WithTimeFunc(func() time.Time { return tt.now })).Validate(claims)
+		if tt.wantErr == nil && err != nil {
+			t.Fatalf("Validate() at %v error = %v", tt.now, err)
+		}
+		if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Fatalf("Validate() at %v error = %v, want %v", tt.now, err, tt.wantErr)
+		}
+	}
+}
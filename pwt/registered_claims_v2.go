@@ -0,0 +1,99 @@
+package pwt
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lkyzhu/xwt/pwt/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RegisteredClaimsV2 are the registered claims with sub-second precision. The
+// times are [timestamppb.Timestamp] values, which can be created using
+// [timestamppb.New]:
+//
+//	claims := &pwt.RegisteredClaimsV2{StandardClaimsV2: pb.StandardClaimsV2{
+//	    Subject:   "alice",
+//	    ExpiresAt: timestamppb.New(time.Now().Add(250 * time.Millisecond)),
+//	}}
+//
+// Apart from the times, it is the same as [RegisteredClaims], but it is not
+// wire compatible with it: parsing a token of one with the other fails with
+// ErrTokenMalformed, so issuers and parsers must agree on the version.
+type RegisteredClaimsV2 struct {
+	pb.StandardClaimsV2
+}
+
+// GetExpirationTime implements the Claims interface.
+func (c *RegisteredClaimsV2) GetExpirationTime() time.Time {
+	return timestampTime(c.ExpiresAt)
+}
+
+// GetNotBefore implements the Claims interface.
+func (c *RegisteredClaimsV2) GetNotBefore() time.Time {
+	return timestampTime(c.NotBefore)
+}
+
+// GetIssuedAt implements the Claims interface.
+func (c *RegisteredClaimsV2) GetIssuedAt() time.Time {
+	return timestampTime(c.IssuedAt)
+}
+
+// GetAudience implements the Claims interface.
+func (c *RegisteredClaimsV2) GetAudience() []string {
+	return c.Audience
+}
+
+// GetIssuer implements the Claims interface.
+func (c *RegisteredClaimsV2) GetIssuer() string {
+	return c.Issuer
+}
+
+// GetSubject implements the Claims interface.
+func (c *RegisteredClaimsV2) GetSubject() string {
+	return c.Subject
+}
+
+// GetConfirmationJWKThumbprint returns the JWK thumbprint of the `cnf` claim,
+// which binds the token to a DPoP key.
+func (c *RegisteredClaimsV2) GetConfirmationJWKThumbprint() string {
+	return c.GetConfirmation().GetJWKThumbprint()
+}
+
+// GetConfirmationX509Thumbprint returns the certificate thumbprint of the `cnf`
+// claim, which binds the token to a mutual TLS client certificate.
+func (c *RegisteredClaimsV2) GetConfirmationX509Thumbprint() string {
+	return c.GetConfirmation().GetX509CertThumbprintS256()
+}
+
+// GetScopes returns the scopes of the space-delimited `scope` claim.
+func (c *RegisteredClaimsV2) GetScopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// Type implements the Claims interface.
+func (c *RegisteredClaimsV2) Type() string {
+	return Type
+}
+
+// Marshal implements the Claims interface.
+func (c *RegisteredClaimsV2) Marshal() ([]byte, error) {
+	return proto.Marshal(c)
+}
+
+// Unmarshal implements the Claims interface. It fails if the claims were
+// marshalled by [RegisteredClaims], whose times are not compatible.
+func (c *RegisteredClaimsV2) Unmarshal(data []byte) error {
+	return unmarshalClaims(data, c)
+}
+
+// timestampTime returns the time of the timestamp, or the zero time if ts is
+// not set.
+func timestampTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return ts.AsTime()
+}
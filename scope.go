@@ -0,0 +1,78 @@
+package xwt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lkyzhu/xwt/internal"
+)
+
+// ScopedClaims is implemented by claims carrying authorization information in
+// the `scope` and `permissions` claims. [jwt.RegisteredClaims], [jwt.MapClaims]
+// and [pwt.RegisteredClaims] implement it.
+type ScopedClaims interface {
+	Claims
+	GetScopes() []string
+	GetPermissions() []string
+}
+
+// MatchScope reports whether the granted scope covers the required one. Scopes
+// are hierarchical, with segments separated by colons. Besides an exact match,
+// a granted scope ending in the wildcard segment "*" covers all scopes below
+// its prefix, e.g. "orders:*" covers "orders:read" and "orders:items:write",
+// but not "orders" itself. The scope "*" covers every scope.
+func MatchScope(granted, required string) bool {
+	if granted == required || granted == "*" {
+		return true
+	}
+
+	prefix, ok := strings.CutSuffix(granted, ":*")
+	if !ok {
+		return false
+	}
+
+	return strings.HasPrefix(required, prefix+":")
+}
+
+// verifyScopes checks that the scopes and permissions of the claims cover all
+// required scopes, and at least one of anyScopes.
+func (v *Validator) verifyScopes(claims Claims, required, anyScopes []string) error {
+	scoped, ok := claims.(ScopedClaims)
+	if !ok {
+		return errorIfRequired(true, "scope")
+	}
+
+	var granted []string
+	granted = append(granted, scoped.GetScopes()...)
+	granted = append(granted, scoped.GetPermissions()...)
+
+	for _, scope := range required {
+		if !grantsScope(granted, scope) {
+			return internal.NewError(fmt.Sprintf("scope %s is required", scope), internal.ErrTokenInsufficientScope)
+		}
+	}
+
+	if len(anyScopes) == 0 {
+		return nil
+	}
+
+	for _, scope := range anyScopes {
+		if grantsScope(granted, scope) {
+			return nil
+		}
+	}
+
+	return internal.NewError(fmt.Sprintf("one of the scopes %s is required", strings.Join(anyScopes, ", ")), internal.ErrTokenInsufficientScope)
+}
+
+// grantsScope reports whether any of the granted scopes covers the required
+// one.
+func grantsScope(granted []string, required string) bool {
+	for _, scope := range granted {
+		if MatchScope(scope, required) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,76 @@
+package xwt_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+)
+
+func TestMatchScope(t *testing.T) {
+	tests := []struct {
+		granted  string
+		required string
+		want     bool
+	}{
+		{"orders:read", "orders:read", true},
+		{"orders:read", "orders:write", false},
+		{"orders:read", "orders", false},
+		{"orders", "orders:read", false},
+		{"orders:*", "orders:read", true},
+		{"orders:*", "orders:items:write", true},
+		{"orders:*", "orders", false},
+		{"orders:*", "ordersx:read", false},
+		{"orders:*", "customers:read", false},
+		{"orders:items:*", "orders:items:read", true},
+		{"orders:items:*", "orders:read", false},
+		{"*", "orders:read", true},
+		{"*", "orders", true},
+		{"orders*", "orders:read", false},
+		{"orders:r*", "orders:read", false},
+		// A wildcard is only special in the granted scope.
+		{"orders:read", "orders:*", false},
+		{"orders:*", "orders:*", true},
+		{"*:read", "orders:read", false},
+	}
+
+	for _, tt := range tests {
+		if got := xwt.MatchScope(tt.granted, tt.required); got != tt.want {
+			t.Errorf("MatchScope(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestScopeValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  xwt.Claims
+		opts    []xwt.ParserOption
+		wantErr error
+	}{
+		{"required", &jwt.MapClaims{"scope": "orders:read orders:write"}, []xwt.ParserOption{xwt.WithRequiredScopes("orders:read", "orders:write")}, nil},
+		{"required missing", &jwt.MapClaims{"scope": "orders:read"}, []xwt.ParserOption{xwt.WithRequiredScopes("orders:read", "orders:write")}, internal.ErrTokenInsufficientScope},
+		{"required wildcard", &jwt.MapClaims{"scope": "orders:*"}, []xwt.ParserOption{xwt.WithRequiredScopes("orders:read", "orders:write")}, nil},
+		{"permissions", &jwt.MapClaims{"permissions": []string{"orders:read"}}, []xwt.ParserOption{xwt.WithRequiredScopes("orders:read")}, nil},
+		{"scope and permissions", &jwt.RegisteredClaims{Scope: "orders:read", Permissions: []string{"orders:write"}}, []xwt.ParserOption{xwt.WithRequiredScopes("orders:read", "orders:write")}, nil},
+		{"any", &jwt.MapClaims{"scope": "orders:write"}, []xwt.ParserOption{xwt.WithAnyScope("orders:read", "orders:write")}, nil},
+		{"any missing", &jwt.MapClaims{"scope": "customers:read"}, []xwt.ParserOption{xwt.WithAnyScope("orders:read", "orders:write")}, internal.ErrTokenInsufficientScope},
+		{"required and any", &jwt.MapClaims{"scope": "orders:read admin"}, []xwt.ParserOption{xwt.WithRequiredScopes("orders:read"), xwt.WithAnyScope("admin", "support")}, nil},
+		{"required and any missing", &jwt.MapClaims{"scope": "admin"}, []xwt.ParserOption{xwt.WithRequiredScopes("orders:read"), xwt.WithAnyScope("admin", "support")}, internal.ErrTokenInsufficientScope},
+		{"no scope", &jwt.MapClaims{}, []xwt.ParserOption{xwt.WithRequiredScopes("orders:read")}, internal.ErrTokenInsufficientScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := xwt.NewValidator(tt.opts...).Validate(tt.claims)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
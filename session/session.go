@@ -0,0 +1,355 @@
+// Package session issues pairs of access and refresh tokens, as used by an
+// OAuth 2.0 authorization server, and implements refresh token rotation with
+// reuse detection.
+//
+// Every grant starts a new refresh token [Family]. Each refresh replaces the
+// refresh token of the family by a new one. If a replaced refresh token is
+// presented again, either the legitimate client or an attacker is holding a
+// stolen copy, so the whole family is revoked.
+//
+// Access tokens are regular xwt tokens (JWT or PWT), whose claims are created
+// by the [Manager]. Refresh tokens are opaque to clients: a random token ID,
+// signed using a xwt signing method, so that forged tokens are rejected
+// without consulting the [FamilyStore].
+package session
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid")
+	ErrFamilyRevoked       = errors.New("refresh token family has been revoked")
+)
+
+const (
+	// DefaultAccessTTL is the lifetime of access tokens, if not configured
+	// otherwise in the [Manager].
+	DefaultAccessTTL = 15 * time.Minute
+
+	// DefaultRefreshTTL is the lifetime of a refresh token family, if not
+	// configured otherwise in the [Manager].
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+// Grant is the authorization represented by a refresh token family. It is used
+// to create the claims of every access token issued for the family.
+type Grant struct {
+	Subject  string            // Subject is the resource owner
+	ClientID string            // ClientID identifies the client the grant was issued to
+	Audience []string          // Audience are the intended recipients of the access tokens
+	Scope    []string          // Scope are the scopes granted to the client
+	Extra    map[string]string // Extra contains application-specific data of the grant
+}
+
+// TokenPair is the result of issuing or refreshing a grant.
+type TokenPair struct {
+	AccessToken      string    // AccessToken is the signed access token
+	RefreshToken     string    // RefreshToken is the opaque refresh token
+	ExpiresAt        time.Time // ExpiresAt is the time the access token expires
+	RefreshExpiresAt time.Time // RefreshExpiresAt is the time the refresh token family expires
+}
+
+// Manager issues and refreshes token pairs. Store, AccessMethod, AccessKey,
+// RefreshMethod and RefreshKey are required.
+//
+//	m := &session.Manager{
+//	    Store:         session.NewMemoryFamilyStore(),
+//	    Issuer:        "https://auth.example.com",
+//	    AccessMethod:  method.SigningMethodES256,
+//	    AccessKey:     ecKey,
+//	    RefreshMethod: method.SigningMethodHS256,
+//	    RefreshKey:    secret,
+//	}
+//	pair, err := m.Issue(ctx, &session.Grant{Subject: "alice"})
+type Manager struct {
+	// Store stores the refresh token families.
+	Store FamilyStore
+
+	// Issuer is the `iss` claim of the access tokens.
+	Issuer string
+
+	// AccessMethod is the signing method of the access tokens.
+	AccessMethod method.SigningMethod
+
+	// AccessKey is the key used to sign the access tokens.
+	AccessKey interface{}
+
+	// AccessOptions are applied to every access token, e.g.
+	// [xwt.WithThumbprintKeyID].
+	AccessOptions []xwt.TokenOption
+
+	// AccessTTL is the lifetime of access tokens. If zero, DefaultAccessTTL
+	// is used.
+	AccessTTL time.Duration
+
+	// AccessClaims creates the claims of an access token, which is issued for
+	// grant with the given ID at iat and expires at exp. Use it to issue
+	// PWTs or custom claims. If nil, DefaultAccessClaims is used.
+	AccessClaims func(grant *Grant, id string, iat, exp time.Time) xwt.Claims
+
+	// RefreshMethod is the signing method of the refresh tokens, usually a
+	// HMAC method.
+	RefreshMethod method.SigningMethod
+
+	// RefreshKey is the key used to sign the refresh tokens. If it is a
+	// crypto.Signer, its public key is used to verify them.
+	RefreshKey interface{}
+
+	// RefreshTTL is the lifetime of a refresh token family. Rotating a
+	// refresh token does not extend it. If zero, DefaultRefreshTTL is used.
+	RefreshTTL time.Duration
+
+	// TimeFunc supplies the current time. If nil, time.Now is used.
+	TimeFunc func() time.Time
+}
+
+// DefaultAccessClaims creates the access token claims of a grant as
+// [jwt.MapClaims]. Besides the registered claims, they contain the `scope`
+// and `client_id` claims of RFC 9068, as well as the Extra data of the grant.
+func DefaultAccessClaims(issuer string, grant *Grant, id string, iat, exp time.Time) xwt.Claims {
+	claims := jwt.MapClaims{}
+	for k, v := range grant.Extra {
+		claims[k] = v
+	}
+
+	if issuer != "" {
+		claims["iss"] = issuer
+	}
+	if grant.Subject != "" {
+		claims["sub"] = grant.Subject
+	}
+	if len(grant.Audience) > 0 {
+		claims["aud"] = grant.Audience
+	}
+	if grant.ClientID != "" {
+		claims["client_id"] = grant.ClientID
+	}
+	if len(grant.Scope) > 0 {
+		claims["scope"] = strings.Join(grant.Scope, " ")
+	}
+	claims["jti"] = id
+	claims["iat"] = jwt.NewNumericDate(iat)
+	claims["exp"] = jwt.NewNumericDate(exp)
+
+	return &claims
+}
+
+// Issue starts a new refresh token family for grant and returns its first
+// token pair.
+func (m *Manager) Issue(ctx context.Context, grant *Grant) (*TokenPair, error) {
+	now := m.now()
+
+	familyID, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	tokenID, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	family := &Family{
+		ID:        familyID,
+		Grant:     *grant,
+		Current:   tokenID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(m.refreshTTL()),
+	}
+
+	if err = m.Store.Create(ctx, family); err != nil {
+		return nil, err
+	}
+
+	return m.pair(family, tokenID, now)
+}
+
+// Refresh rotates a refresh token and returns a new token pair. If the refresh
+// token has already been rotated, the whole family is revoked and
+// ErrRefreshTokenReused is returned.
+func (m *Manager) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	now := m.now()
+
+	family, tokenID, err := m.family(ctx, refreshToken, now)
+	if err != nil {
+		return nil, err
+	}
+
+	newTokenID, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	// The token is rotated atomically, so that only one of several concurrent
+	// refreshes with the same token succeeds
+	if family.Current == tokenID {
+		err = m.Store.Rotate(ctx, family.ID, tokenID, newTokenID)
+	} else {
+		err = ErrRefreshTokenReused
+	}
+
+	if errors.Is(err, ErrRefreshTokenReused) {
+		if rerr := m.Store.Revoke(ctx, family.ID); rerr != nil {
+			return nil, internal.JoinErrors(err, rerr)
+		}
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	return m.pair(family, newTokenID, now)
+}
+
+// Revoke revokes the family of a refresh token, e.g. when the user logs out.
+func (m *Manager) Revoke(ctx context.Context, refreshToken string) error {
+	family, _, err := m.family(ctx, refreshToken, m.now())
+	if errors.Is(err, ErrFamilyRevoked) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return m.Store.Revoke(ctx, family.ID)
+}
+
+// family verifies a refresh token and returns its family and token ID.
+func (m *Manager) family(ctx context.Context, refreshToken string, now time.Time) (*Family, string, error) {
+	tokenID, err := m.verifyRefreshToken(refreshToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	family, err := m.Store.FindByToken(ctx, tokenID)
+	if errors.Is(err, ErrFamilyNotFound) {
+		return nil, "", internal.NewError("refresh token is unknown", ErrInvalidRefreshToken)
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	if family.Revoked {
+		return nil, "", ErrFamilyRevoked
+	}
+	if !now.Before(family.ExpiresAt) {
+		return nil, "", internal.NewError("refresh token is expired", ErrInvalidRefreshToken, internal.ErrTokenExpired)
+	}
+
+	return family, tokenID, nil
+}
+
+// pair creates the token pair of a family with the given refresh token ID.
+func (m *Manager) pair(family *Family, tokenID string, now time.Time) (*TokenPair, error) {
+	accessID, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	exp := now.Add(m.accessTTL())
+	if exp.After(family.ExpiresAt) {
+		exp = family.ExpiresAt
+	}
+
+	var claims xwt.Claims
+	if m.AccessClaims != nil {
+		claims = m.AccessClaims(&family.Grant, accessID, now, exp)
+	} else {
+		claims = DefaultAccessClaims(m.Issuer, &family.Grant, accessID, now, exp)
+	}
+
+	accessToken, err := xwt.NewWithClaims(m.AccessMethod, claims, m.AccessOptions...).SignedString(m.AccessKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign access token: %w", err)
+	}
+
+	refreshToken, err := m.signRefreshToken(tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign refresh token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        exp,
+		RefreshExpiresAt: family.ExpiresAt,
+	}, nil
+}
+
+// signRefreshToken creates the refresh token of the form id.signature.
+func (m *Manager) signRefreshToken(tokenID string) (string, error) {
+	sig, err := m.RefreshMethod.Sign(tokenID, m.RefreshKey)
+	if err != nil {
+		return "", err
+	}
+
+	return tokenID + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// verifyRefreshToken verifies the signature of a refresh token and returns its
+// token ID.
+func (m *Manager) verifyRefreshToken(refreshToken string) (string, error) {
+	tokenID, encoded, ok := strings.Cut(refreshToken, ".")
+	if !ok || tokenID == "" {
+		return "", internal.NewError("refresh token is malformed", ErrInvalidRefreshToken)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", internal.NewError("refresh token is malformed", ErrInvalidRefreshToken, err)
+	}
+
+	key := m.RefreshKey
+	if signer, ok := key.(crypto.Signer); ok {
+		key = signer.Public()
+	}
+
+	if err = m.RefreshMethod.Verify(tokenID, sig, key); err != nil {
+		return "", internal.NewError("refresh token signature is invalid", ErrInvalidRefreshToken, err)
+	}
+
+	return tokenID, nil
+}
+
+func (m *Manager) now() time.Time {
+	if m.TimeFunc != nil {
+		return m.TimeFunc()
+	}
+
+	return time.Now()
+}
+
+func (m *Manager) accessTTL() time.Duration {
+	if m.AccessTTL != 0 {
+		return m.AccessTTL
+	}
+
+	return DefaultAccessTTL
+}
+
+func (m *Manager) refreshTTL() time.Duration {
+	if m.RefreshTTL != 0 {
+		return m.RefreshTTL
+	}
+
+	return DefaultRefreshTTL
+}
+
+// newID returns a random, base64url encoded identifier.
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
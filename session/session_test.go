@@ -0,0 +1,288 @@
+package session_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+	"github.com/lkyzhu/xwt/session"
+)
+
+var (
+	accessSecret  = []byte("access-0123456789abcdef0123456789")
+	refreshSecret = []byte("refresh-0123456789abcdef012345678")
+)
+
+func newManager() *session.Manager {
+	return &session.Manager{
+		Store:         session.NewMemoryFamilyStore(),
+		Issuer:        "https://auth.example.com",
+		AccessMethod:  method.SigningMethodHS256,
+		AccessKey:     accessSecret,
+		RefreshMethod: method.SigningMethodHS256,
+		RefreshKey:    refreshSecret,
+	}
+}
+
+var grant = &session.Grant{
+	Subject:  "alice",
+	ClientID: "app",
+	Audience: []string{"https://api.example.com"},
+	Scope:    []string{"orders:read", "orders:write"},
+	Extra:    map[string]string{"tenant": "acme"},
+}
+
+func TestIssue(t *testing.T) {
+	m := newManager()
+
+	pair, err := m.Issue(context.Background(), grant)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = xwt.NewParser(xwt.WithIssuer(m.Issuer), xwt.WithAudience("https://api.example.com"), xwt.WithExpirationRequired()).ParseWithClaims(pair.AccessToken, &claims, func(*xwt.Token) (interface{}, error) {
+		return accessSecret, nil
+	})
+	if err != nil {
+		t.Fatalf("ParseWithClaims() error = %v", err)
+	}
+
+	for name, want := range map[string]string{"sub": "alice", "client_id": "app", "scope": "orders:read orders:write", "tenant": "acme"} {
+		if claims[name] != want {
+			t.Errorf("claim %s = %v, want %v", name, claims[name], want)
+		}
+	}
+	if got := pair.RefreshExpiresAt.Sub(pair.ExpiresAt); got != session.DefaultRefreshTTL-session.DefaultAccessTTL {
+		t.Errorf("RefreshExpiresAt - ExpiresAt = %v, want %v", got, session.DefaultRefreshTTL-session.DefaultAccessTTL)
+	}
+}
+
+func TestRefreshRotation(t *testing.T) {
+	m := newManager()
+	ctx := context.Background()
+
+	first, err := m.Issue(ctx, grant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := m.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("Refresh() did not rotate the refresh token")
+	}
+	if !second.RefreshExpiresAt.Equal(first.RefreshExpiresAt) {
+		t.Fatalf("RefreshExpiresAt = %v, want %v", second.RefreshExpiresAt, first.RefreshExpiresAt)
+	}
+
+	third, err := m.Refresh(ctx, second.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() of rotated token error = %v", err)
+	}
+
+	// Reusing a replaced token revokes the family, so the current token is
+	// not usable anymore either.
+	if _, err := m.Refresh(ctx, first.RefreshToken); !errors.Is(err, session.ErrRefreshTokenReused) {
+		t.Fatalf("Refresh() of reused token error = %v, want %v", err, session.ErrRefreshTokenReused)
+	}
+	if _, err := m.Refresh(ctx, third.RefreshToken); !errors.Is(err, session.ErrFamilyRevoked) {
+		t.Fatalf("Refresh() of current token error = %v, want %v", err, session.ErrFamilyRevoked)
+	}
+
+	// Other families are not affected.
+	other, err := m.Issue(ctx, grant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Refresh(ctx, other.RefreshToken); err != nil {
+		t.Fatalf("Refresh() of other family error = %v", err)
+	}
+}
+
+func TestRefreshConcurrent(t *testing.T) {
+	m := newManager()
+	ctx := context.Background()
+
+	pair, err := m.Issue(ctx, grant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Refresh(ctx, pair.RefreshToken); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Fatalf("%d concurrent refreshes succeeded, want 1", succeeded)
+	}
+}
+
+func TestRefreshInvalid(t *testing.T) {
+	now := time.Now()
+	m := newManager()
+	m.TimeFunc = func() time.Time { return now }
+	ctx := context.Background()
+
+	pair, err := m.Issue(ctx, grant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenID, _, _ := strings.Cut(pair.RefreshToken, ".")
+
+	forger := newManager()
+	forger.RefreshKey = []byte("forged-0123456789abcdef0123456789")
+	forged, err := forger.Issue(ctx, grant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, forgedSig, _ := strings.Cut(forged.RefreshToken, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"malformed", "token", session.ErrInvalidRefreshToken},
+		{"invalid encoding", tokenID + ".!", session.ErrInvalidRefreshToken},
+		{"forged signature", tokenID + "." + forgedSig, session.ErrInvalidRefreshToken},
+		{"unknown", forged.RefreshToken, session.ErrInvalidRefreshToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.Refresh(ctx, tt.token); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Refresh() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The family expires, regardless of rotation.
+	now = now.Add(session.DefaultRefreshTTL)
+	if _, err := m.Refresh(ctx, pair.RefreshToken); !errors.Is(err, internal.ErrTokenExpired) {
+		t.Fatalf("Refresh() of expired token error = %v, want %v", err, internal.ErrTokenExpired)
+	}
+}
+
+func TestAccessExpiration(t *testing.T) {
+	now := time.Now()
+	m := newManager()
+	m.TimeFunc = func() time.Time { return now }
+	m.RefreshTTL = time.Hour
+	ctx := context.Background()
+
+	pair, err := m.Issue(ctx, grant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Access tokens do not outlive their family.
+	now = now.Add(time.Hour - time.Minute)
+	pair, err = m.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pair.ExpiresAt.Equal(pair.RefreshExpiresAt) {
+		t.Fatalf("ExpiresAt = %v, want %v", pair.ExpiresAt, pair.RefreshExpiresAt)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	m := newManager()
+	ctx := context.Background()
+
+	pair, err := m.Issue(ctx, grant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Revoke(ctx, pair.RefreshToken); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := m.Refresh(ctx, pair.RefreshToken); !errors.Is(err, session.ErrFamilyRevoked) {
+		t.Fatalf("Refresh() after Revoke() error = %v, want %v", err, session.ErrFamilyRevoked)
+	}
+
+	// Revoking twice is not an error.
+	if err := m.Revoke(ctx, pair.RefreshToken); err != nil {
+		t.Fatalf("Revoke() of revoked family error = %v", err)
+	}
+}
+
+func TestMemoryFamilyStore(t *testing.T) {
+	s := session.NewMemoryFamilyStore()
+	ctx := context.Background()
+
+	family := &session.Family{ID: "f", Current: "t1", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := s.Create(ctx, family); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := s.Create(ctx, family); !errors.Is(err, session.ErrFamilyExists) {
+		t.Fatalf("Create() of existing family error = %v, want %v", err, session.ErrFamilyExists)
+	}
+
+	if err := s.Rotate(ctx, "f", "t1", "t2"); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if err := s.Rotate(ctx, "f", "t1", "t3"); !errors.Is(err, session.ErrRefreshTokenReused) {
+		t.Fatalf("Rotate() of replaced token error = %v, want %v", err, session.ErrRefreshTokenReused)
+	}
+	if err := s.Rotate(ctx, "unknown", "t1", "t3"); !errors.Is(err, session.ErrFamilyNotFound) {
+		t.Fatalf("Rotate() of unknown family error = %v, want %v", err, session.ErrFamilyNotFound)
+	}
+
+	// Replaced tokens are still found, so that their reuse is detected.
+	for _, token := range []string{"t1", "t2"} {
+		f, err := s.FindByToken(ctx, token)
+		if err != nil {
+			t.Fatalf("FindByToken(%s) error = %v", token, err)
+		}
+		if f.ID != "f" || f.Current != "t2" {
+			t.Fatalf("FindByToken(%s) = %+v", token, f)
+		}
+	}
+	if _, err := s.FindByToken(ctx, "t3"); !errors.Is(err, session.ErrFamilyNotFound) {
+		t.Fatalf("FindByToken() of unknown token error = %v, want %v", err, session.ErrFamilyNotFound)
+	}
+
+	// The returned family is a copy.
+	f, _ := s.FindByToken(ctx, "t2")
+	f.Revoked = true
+	if f, _ = s.FindByToken(ctx, "t2"); f.Revoked {
+		t.Fatal("modifying the returned family changed the store")
+	}
+
+	// Expired families are removed, when the next family is created.
+	if err := s.Create(ctx, &session.Family{ID: "expired", Current: "e1", ExpiresAt: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Create(ctx, &session.Family{ID: "g", Current: "g1", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FindByToken(ctx, "e1"); !errors.Is(err, session.ErrFamilyNotFound) {
+		t.Fatalf("FindByToken() of expired family error = %v, want %v", err, session.ErrFamilyNotFound)
+	}
+}
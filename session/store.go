@@ -0,0 +1,157 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrFamilyNotFound     = errors.New("refresh token family not found")
+	ErrFamilyExists       = errors.New("refresh token family already exists")
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+)
+
+// Family is a refresh token family, i.e. the chain of refresh tokens that were
+// issued for a single grant, each one replacing its predecessor. Only the
+// current refresh token of a family can be used. Presenting any of its
+// predecessors indicates that a refresh token has been stolen, and revokes the
+// family, see
+// https://datatracker.ietf.org/doc/html/draft-ietf-oauth-v2-1#section-4.3.1.
+type Family struct {
+	ID        string    // ID identifies the family
+	Grant     Grant     // Grant is the authorization represented by the family
+	Current   string    // Current is the ID of the only usable refresh token
+	CreatedAt time.Time // CreatedAt is the time the grant was issued
+	ExpiresAt time.Time // ExpiresAt is the time after which no refresh token of the family is accepted
+	Revoked   bool      // Revoked specifies whether the family has been revoked
+}
+
+// FamilyStore stores refresh token families. Implementations must be safe for
+// concurrent use, and Rotate must be atomic, as it decides which of several
+// concurrent refreshes with the same token succeeds.
+type FamilyStore interface {
+	// Create stores a new family. It returns ErrFamilyExists, if a family
+	// with the same ID already exists.
+	Create(ctx context.Context, family *Family) error
+
+	// FindByToken returns the family that issued the refresh token with the
+	// given ID, regardless of whether it is the current token or one of its
+	// predecessors. It returns ErrFamilyNotFound, if no family issued the
+	// token.
+	FindByToken(ctx context.Context, tokenID string) (*Family, error)
+
+	// Rotate replaces the current refresh token of a family, if it equals
+	// oldTokenID. Otherwise, ErrRefreshTokenReused is returned. The old token
+	// must still be found by FindByToken afterwards, so that its reuse can be
+	// detected.
+	Rotate(ctx context.Context, familyID, oldTokenID, newTokenID string) error
+
+	// Revoke marks a family as revoked.
+	Revoke(ctx context.Context, familyID string) error
+}
+
+// MemoryFamilyStore is an in-memory [FamilyStore]. Expired families are
+// removed whenever a new family is created. The [NewMemoryFamilyStore] function
+// should be used to create an instance of this struct.
+type MemoryFamilyStore struct {
+	mu       sync.Mutex
+	families map[string]*Family
+	tokens   map[string]string // tokens maps every issued token ID to its family ID
+	now      func() time.Time
+}
+
+// NewMemoryFamilyStore creates an empty in-memory family store.
+func NewMemoryFamilyStore() *MemoryFamilyStore {
+	return &MemoryFamilyStore{
+		families: map[string]*Family{},
+		tokens:   map[string]string{},
+		now:      time.Now,
+	}
+}
+
+// Create implements the [FamilyStore] interface.
+func (s *MemoryFamilyStore) Create(_ context.Context, family *Family) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire()
+
+	if _, ok := s.families[family.ID]; ok {
+		return ErrFamilyExists
+	}
+
+	f := *family
+	s.families[f.ID] = &f
+	s.tokens[f.Current] = f.ID
+
+	return nil
+}
+
+// FindByToken implements the [FamilyStore] interface.
+func (s *MemoryFamilyStore) FindByToken(_ context.Context, tokenID string) (*Family, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.families[s.tokens[tokenID]]
+	if !ok {
+		return nil, ErrFamilyNotFound
+	}
+
+	family := *f
+
+	return &family, nil
+}
+
+// Rotate implements the [FamilyStore] interface.
+func (s *MemoryFamilyStore) Rotate(_ context.Context, familyID, oldTokenID, newTokenID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.families[familyID]
+	if !ok {
+		return ErrFamilyNotFound
+	}
+
+	if f.Revoked || f.Current != oldTokenID {
+		return ErrRefreshTokenReused
+	}
+
+	f.Current = newTokenID
+	s.tokens[newTokenID] = familyID
+
+	return nil
+}
+
+// Revoke implements the [FamilyStore] interface.
+func (s *MemoryFamilyStore) Revoke(_ context.Context, familyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.families[familyID]
+	if !ok {
+		return ErrFamilyNotFound
+	}
+	f.Revoked = true
+
+	return nil
+}
+
+// expire removes all expired families and their tokens. The caller must hold
+// the lock.
+func (s *MemoryFamilyStore) expire() {
+	now := s.now()
+
+	for id, f := range s.families {
+		if f.ExpiresAt.Before(now) {
+			delete(s.families, id)
+		}
+	}
+
+	for token, id := range s.tokens {
+		if _, ok := s.families[id]; !ok {
+			delete(s.tokens, token)
+		}
+	}
+}
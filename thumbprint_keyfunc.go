@@ -0,0 +1,61 @@
+package xwt
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/method"
+)
+
+// NewThumbprintKeyfunc returns a [Keyfunc] that selects the verification key by
+// the `kid` header parameter of the token, where the key ID of every key is its
+// JWK SHA-256 thumbprint. This matches the key IDs derived using
+// [WithThumbprintKeyID] when signing.
+//
+// keys may contain any asymmetric key supported by [method.JWKThumbprint], as
+// well as [Key] entries, whose thumbprint is computed from their Material.
+func NewThumbprintKeyfunc(keys ...interface{}) (Keyfunc, error) {
+	byKeyID := make(map[string]interface{}, len(keys))
+
+	for i, key := range keys {
+		material := key
+		switch k := key.(type) {
+		case Key:
+			material = k.Material
+		case *Key:
+			material = k.Material
+		}
+
+		kid, err := thumbprintKeyID(material)
+		if err != nil {
+			return nil, fmt.Errorf("could not compute thumbprint of key %d: %w", i, err)
+		}
+		byKeyID[kid] = key
+	}
+
+	return func(t *Token) (interface{}, error) {
+		if t.Header.KeyID == "" {
+			return nil, errors.New("kid header parameter is missing")
+		}
+
+		key, ok := byKeyID[t.Header.KeyID]
+		if !ok {
+			return nil, fmt.Errorf("unknown kid %s", t.Header.KeyID)
+		}
+
+		return key, nil
+	}, nil
+}
+
+// thumbprintKeyID returns the JWK SHA-256 thumbprint of key as key ID. The
+// thumbprint of a symmetric key is an unsalted hash of the secret, which would
+// allow to brute-force a weak secret offline, so it must not be published in
+// the header.
+func thumbprintKeyID(key interface{}) (string, error) {
+	if _, ok := key.([]byte); ok {
+		return "", internal.NewError("key ID must not be derived from a symmetric key", internal.ErrInvalidKeyType)
+	}
+
+	return method.JWKThumbprint(key)
+}
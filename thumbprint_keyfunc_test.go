@@ -0,0 +1,75 @@
+package xwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+func TestThumbprintKeyID(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := xwt.NewWithClaims(method.SigningMethodES256, &jwt.MapClaims{"sub": "alice"}, xwt.WithThumbprintKeyID())
+	signed, err := token.SignedString(priv)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	want, err := method.JWKThumbprint(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Header.KeyID != want {
+		t.Fatalf("kid = %s, want %s", token.Header.KeyID, want)
+	}
+
+	keyfunc, err := xwt.NewThumbprintKeyfunc(&other.PublicKey, &priv.PublicKey)
+	if err != nil {
+		t.Fatalf("NewThumbprintKeyfunc() error = %v", err)
+	}
+	if _, err := xwt.NewParser().ParseWithClaims(signed, &jwt.MapClaims{}, keyfunc); err != nil {
+		t.Fatalf("ParseWithClaims() error = %v", err)
+	}
+
+	// An explicit key ID takes precedence.
+	token = xwt.NewWithClaims(method.SigningMethodES256, &jwt.MapClaims{}, xwt.WithThumbprintKeyID(), xwt.WithKeyID("explicit"))
+	if _, err := token.SignedString(priv); err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	if token.Header.KeyID != "explicit" {
+		t.Fatalf("kid = %s, want explicit", token.Header.KeyID)
+	}
+}
+
+func TestThumbprintKeyIDSymmetric(t *testing.T) {
+	// The thumbprint of a secret must not be published as key ID.
+	_, err := xwt.NewWithClaims(method.SigningMethodHS256, &jwt.MapClaims{}, xwt.WithThumbprintKeyID()).SignedString(testSecret)
+	if !errors.Is(err, internal.ErrInvalidKeyType) {
+		t.Fatalf("SignedString() error = %v, want %v", err, internal.ErrInvalidKeyType)
+	}
+
+	if _, err := xwt.NewThumbprintKeyfunc(testSecret); !errors.Is(err, internal.ErrInvalidKeyType) {
+		t.Fatalf("NewThumbprintKeyfunc() error = %v, want %v", err, internal.ErrInvalidKeyType)
+	}
+	if _, err := xwt.NewThumbprintKeyfunc(xwt.Key{Material: testSecret}); !errors.Is(err, internal.ErrInvalidKeyType) {
+		t.Fatalf("NewThumbprintKeyfunc() with Key error = %v, want %v", err, internal.ErrInvalidKeyType)
+	}
+
+	// The thumbprint itself is still available, e.g. for JWK sets.
+	if _, err := method.JWKThumbprint(testSecret); err != nil {
+		t.Fatalf("JWKThumbprint() error = %v", err)
+	}
+}
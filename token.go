@@ -1,10 +1,14 @@
 package xwt
 
 import (
+	"context"
 	"crypto"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/lkyzhu/xwt/method"
 )
@@ -14,8 +18,8 @@ import (
 // Token.  This allows you to use properties in the Header of the token (such as
 // `kid`) to identify which key to use.
 //
-// The returned interface{} may be a single key or a VerificationKeySet containing
-// multiple keys.
+// The returned interface{} may be a single key, a [Key] bound to an algorithm or
+// a VerificationKeySet containing multiple keys.
 type Keyfunc func(*Token) (interface{}, error)
 
 // VerificationKey represents a public or secret key for verifying a token's signature.
@@ -24,6 +28,10 @@ type VerificationKey interface {
 }
 
 // VerificationKeySet is a set of public or secret keys. It is used by the parser to verify a token.
+//
+// The set may contain [Key] entries to bind each key to an algorithm. In that
+// case, only the keys bound to the algorithm of the token are used to verify
+// it.
 type VerificationKeySet struct {
 	Keys []VerificationKey
 }
@@ -31,32 +39,55 @@ type VerificationKeySet struct {
 // Token represents a *WT Token.  Different fields will be used depending on
 // whether you're creating or parsing/verifying a token.
 type Token struct {
-	Raw       string                 // Raw contains the raw token.  Populated when you [Parse] a token
-	Method    method.SigningMethod   // Method is the signing method used or to be used
-	Header    map[string]interface{} // Header is the first segment of the token in decoded form
-	Claims    Claims                 // Claims is the second segment of the token in decoded form
-	Signature []byte                 // Signature is the third segment of the token in decoded form.  Populated when you Parse a token
-	Valid     bool                   // Valid specifies if the token is valid.  Populated when you Parse/Verify a token
+	Raw       string               // Raw contains the raw token.  Populated when you [Parse] a token
+	Method    method.SigningMethod // Method is the signing method used or to be used
+	Header    Header               // Header is the first segment of the token in decoded form
+	Claims    Claims               // Claims is the second segment of the token in decoded form
+	Signature []byte               // Signature is the third segment of the token in decoded form.  Populated when you Parse a token
+	Valid     bool                 // Valid specifies if the token is valid.  Populated when you Parse/Verify a token
+
+	Signatures []JSONSignature // Signatures contains all signatures of a token in JWS JSON serialization.  Populated when you [Parser.ParseJSON] a token
+
+	IssuerParams map[string]string // IssuerParams contains the placeholders of the issuer pattern matching the `iss` claim, e.g. the tenant.  Populated when you Parse a token using [WithIssuerPatterns]
+
+	// thumbprintKeyID specifies whether the `kid` header parameter is derived
+	// from the signing key, see [WithThumbprintKeyID]
+	thumbprintKeyID bool
+
+	// signerHooks observe the signing of the token, see [WithSignerHooks]
+	signerHooks []SignerHook
+
+	// err is the first error of the token options, which is returned when
+	// the token is signed
+	err error
 }
 
 // New creates a new [Token] with the specified signing method and an nil
-// claims. Additional options can be specified, but are currently unused.
+// claims. Additional options can be specified to populate the [Header].
 func New(method method.SigningMethod, opts ...TokenOption) *Token {
 	return NewWithClaims(method, nil, opts...)
 }
 
 // NewWithClaims creates a new [Token] with the specified signing method and
-// claims. Additional options can be specified, but are currently unused.
+// claims. Additional options can be specified to populate the [Header].
 func NewWithClaims(method method.SigningMethod, claims Claims, opts ...TokenOption) *Token {
 	token := &Token{
-		Header: map[string]interface{}{
-			"typ": "*WT",
-			"alg": method.Alg(),
+		Header: Header{
+			Algorithm: method.Alg(),
 		},
 		Claims: claims,
 		Method: method,
 	}
 
+	if claims != nil {
+		token.Header.Type = claims.Type()
+	}
+
+	// Loop through our token options and apply them
+	for _, opt := range opts {
+		opt(token)
+	}
+
 	return token
 }
 
@@ -66,11 +97,31 @@ func NewWithClaims(method method.SigningMethod, claims Claims, opts ...TokenOpti
 // for an overview of the different signing methods and their respective key
 // types.
 func (t *Token) SignedString(key interface{}) (string, error) {
+	start := time.Now()
+
+	s, err := t.signedString(key)
+	t.signed(start, err)
+
+	return s, err
+}
+
+// signedString implements SignedString without calling the signer hooks.
+func (t *Token) signedString(key interface{}) (string, error) {
+	if err := t.deriveKeyID(key); err != nil {
+		return "", err
+	}
+
 	sstr, err := t.SigningString()
 	if err != nil {
 		return "", err
 	}
 
+	// An unencoded payload can only be part of the compact serialization if it
+	// does not contain a period, see RFC 7797 section 5.2
+	if t.Header.unencodedPayload() && strings.Count(sstr, ".") != 1 {
+		return "", errors.New("unencoded payload contains a period, use SignedDetached instead")
+	}
+
 	sig, err := t.Method.Sign(sstr, key)
 	if err != nil {
 		return "", err
@@ -79,26 +130,113 @@ func (t *Token) SignedString(key interface{}) (string, error) {
 	return sstr + "." + t.EncodeSegment(sig), nil
 }
 
+// SignedStringWithContext is like SignedString, but binds ctx to key, if it is
+// a [method.SignerWithContext], e.g. a key held by a remote KMS. This allows to
+// cancel the remote signing operation or to apply a deadline to it.
+func (t *Token) SignedStringWithContext(ctx context.Context, key interface{}) (string, error) {
+	if signer, ok := key.(method.SignerWithContext); ok {
+		key = method.ContextSigner(ctx, signer)
+	}
+
+	return t.SignedString(key)
+}
+
+// SignedDetached creates and returns a signed xwt with detached content, i.e.
+// of the form header..signature, as described in
+// https://datatracker.ietf.org/doc/html/rfc7515#appendix-F. The serialized
+// payload, which needs to be transmitted alongside the token, is returned as
+// well. Use [Parser.ParseDetached] to verify the token.
+//
+// In combination with [WithUnencodedPayload], the serialized payload is signed
+// directly rather than its base64url encoding.
+func (t *Token) SignedDetached(key interface{}) (string, []byte, error) {
+	start := time.Now()
+
+	s, payload, err := t.signedDetached(key)
+	t.signed(start, err)
+
+	return s, payload, err
+}
+
+// signedDetached implements SignedDetached without calling the signer hooks.
+func (t *Token) signedDetached(key interface{}) (string, []byte, error) {
+	if err := t.deriveKeyID(key); err != nil {
+		return "", nil, err
+	}
+
+	h, payload, err := t.marshal()
+	if err != nil {
+		return "", nil, err
+	}
+
+	header := t.EncodeSegment(h)
+	sig, err := t.Method.Sign(header+"."+t.payloadSegment(payload), key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return header + ".." + t.EncodeSegment(sig), payload, nil
+}
+
+// deriveKeyID sets the `kid` header parameter to the thumbprint of key, if
+// requested by [WithThumbprintKeyID] and no key ID is set yet.
+func (t *Token) deriveKeyID(key interface{}) error {
+	if !t.thumbprintKeyID || t.Header.KeyID != "" {
+		return nil
+	}
+
+	kid, err := thumbprintKeyID(key)
+	if err != nil {
+		return fmt.Errorf("could not derive key ID: %w", err)
+	}
+	t.Header.KeyID = kid
+
+	return nil
+}
+
 // SigningString generates the signing string.  This is the most expensive part
 // of the whole deal. Unless you need this for something special, just go
 // straight for the SignedString.
 func (t *Token) SigningString() (string, error) {
+	h, c, err := t.marshal()
+	if err != nil {
+		return "", err
+	}
+
+	return t.EncodeSegment(h) + "." + t.payloadSegment(c), nil
+}
+
+// marshal serializes the header and the claims of the token.
+func (t *Token) marshal() (header []byte, payload []byte, err error) {
+	if t.err != nil {
+		return nil, nil, t.err
+	}
 	if t.Claims == nil {
-		return "", errors.New("claims is nil")
+		return nil, nil, errors.New("claims is nil")
 	}
 
-	t.Header["typ"] = t.Claims.Type()
+	if t.Header.Type == "" {
+		t.Header.Type = t.Claims.Type()
+	}
 
-	h, err := json.Marshal(t.Header)
-	if err != nil {
-		return "", err
+	if header, err = json.Marshal(t.Header); err != nil {
+		return nil, nil, err
 	}
-	c, err := t.Claims.Marshal()
-	if err != nil {
-		return "", nil
+	if payload, err = t.Claims.Marshal(); err != nil {
+		return nil, nil, err
+	}
+
+	return header, payload, nil
+}
+
+// payloadSegment returns the payload as it is contained in the signing input,
+// i.e. base64url encoded unless the header specifies an unencoded payload.
+func (t *Token) payloadSegment(payload []byte) string {
+	if t.Header.unencodedPayload() {
+		return string(payload)
 	}
 
-	return t.EncodeSegment(h) + "." + t.EncodeSegment(c), nil
+	return t.EncodeSegment(payload)
 }
 
 // EncodeSegment encodes a xwt specific base64url encoding with padding
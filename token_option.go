@@ -1,5 +1,100 @@
 package xwt
 
-// TokenOption is a reserved type, which provides some forward compatibility,
-// if we ever want to introduce token creation-related options.
+import (
+	"crypto/x509"
+	"encoding/base64"
+)
+
+// TokenOption is used to implement functional-style options that modify the
+// token on creation, e.g. to populate additional [Header] parameters. To add
+// new options, just create a function (ideally beginning with With or Without)
+// that returns an anonymous function that takes a *Token type as input and
+// manipulates it accordingly.
 type TokenOption func(*Token)
+
+// WithKeyID is an option to set the `kid` (Key ID) header parameter, which
+// can be used by the verifier to select the key in a [Keyfunc].
+func WithKeyID(kid string) TokenOption {
+	return func(t *Token) {
+		t.Header.KeyID = kid
+	}
+}
+
+// WithThumbprintKeyID is an option to derive the `kid` (Key ID) header
+// parameter from the signing key, when the token is signed. The key ID is the
+// JWK SHA-256 thumbprint of the key, as computed by [method.JWKThumbprint], so
+// it is stable and equal for the private and the public key. Verifiers can
+// therefore select the key deterministically, e.g. using
+// [NewThumbprintKeyfunc], without any out-of-band key ID bookkeeping.
+//
+// Only asymmetric keys are supported; signing with a symmetric key fails, since
+// the thumbprint would disclose a hash of the secret.
+//
+// A key ID set explicitly using [WithKeyID] takes precedence.
+func WithThumbprintKeyID() TokenOption {
+	return func(t *Token) {
+		t.thumbprintKeyID = true
+	}
+}
+
+// WithContentType is an option to set the `cty` (Content Type) header
+// parameter.
+func WithContentType(cty string) TokenOption {
+	return func(t *Token) {
+		t.Header.ContentType = cty
+	}
+}
+
+// WithHeader is an option to set an arbitrary header parameter. See
+// [Header.Set] for how registered parameters are handled. If the value of a
+// registered parameter is of the wrong type, signing the token fails.
+func WithHeader(name string, value interface{}) TokenOption {
+	return func(t *Token) {
+		if err := t.Header.Set(name, value); err != nil && t.err == nil {
+			t.err = err
+		}
+	}
+}
+
+// WithUnencodedPayload is an option to sign the payload as-is instead of its
+// base64url encoding, as described in RFC 7797. It sets the `b64` header
+// parameter to false and lists it in `crit`. This is mainly useful in
+// combination with [Token.SignedDetached], e.g. to sign large protobuf payloads
+// that are transmitted separately from the token.
+func WithUnencodedPayload() TokenOption {
+	return func(t *Token) {
+		t.Header.Set("b64", false)
+		if !t.Header.isCritical("b64") {
+			t.Header.Critical = append(t.Header.Critical, "b64")
+		}
+	}
+}
+
+// WithX509CertChain is an option to set the `x5c` header parameter to the
+// given certificate chain, starting with the certificate containing the key
+// used to sign the token. Additionally, the `x5t#S256` header parameter is set
+// to the thumbprint of that certificate. Verifiers can use an
+// [X509ChainVerifier] to obtain the key.
+func WithX509CertChain(chain ...*x509.Certificate) TokenOption {
+	return func(t *Token) {
+		if len(chain) == 0 {
+			return
+		}
+
+		t.Header.X509CertChain = make([]string, 0, len(chain))
+		for _, cert := range chain {
+			t.Header.X509CertChain = append(t.Header.X509CertChain, base64.StdEncoding.EncodeToString(cert.Raw))
+		}
+
+		t.Header.X509CertThumbprintS256 = CertificateThumbprint(chain[0])
+	}
+}
+
+// WithSignerHooks registers hooks, which observe the signing of the token, e.g.
+// to export metrics or to write audit events. The hooks are called in the
+// order they are registered.
+func WithSignerHooks(hooks ...SignerHook) TokenOption {
+	return func(t *Token) {
+		t.signerHooks = append(t.signerHooks, hooks...)
+	}
+}
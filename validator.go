@@ -2,6 +2,7 @@ package xwt
 
 import (
 	"crypto/subtle"
+	"crypto/tls"
 	"fmt"
 	"time"
 
@@ -53,17 +54,59 @@ type Validator struct {
 	// unrealistic, i.e., in the future.
 	verifyIat bool
 
+	// maxFutureIat is the tolerance for an iat in the future, which replaces
+	// leeway in the iat check, if hasMaxFutureIat is set.
+	maxFutureIat time.Duration
+
+	// hasMaxFutureIat specifies whether maxFutureIat is configured.
+	hasMaxFutureIat bool
+
+	// maxAge is the maximum age of the token since its iat, regardless of
+	// its exp. Zero disables the check.
+	maxAge time.Duration
+
+	// maxLifetime is the maximum duration between iat and exp. Zero disables
+	// the check.
+	maxLifetime time.Duration
+
 	// expectedAud contains the audience this token expects. Supplying an empty
 	// string will disable aud checking.
 	expectedAud string
 
+	// expectedAuds contains the groups of audiences this token expects. Each
+	// group is matched according to its own mode.
+	expectedAuds []audienceGroup
+
 	// expectedIss contains the issuer this token expects. Supplying an empty
 	// string will disable iss checking.
 	expectedIss string
 
+	// expectedIssuers contains further issuers this token may be issued by.
+	expectedIssuers []string
+
+	// issuerPatterns contains the patterns of further issuers this token may
+	// be issued by.
+	issuerPatterns []*IssuerPattern
+
 	// expectedSub contains the subject this token expects. Supplying an empty
 	// string will disable sub checking.
 	expectedSub string
+
+	// requireCertBinding specifies whether the token must be bound to the
+	// client certificate of the connection it was presented on, using the
+	// `cnf` claim.
+	requireCertBinding bool
+
+	// requiredScopes contains the scopes that must all be granted by the
+	// token.
+	requiredScopes []string
+
+	// anyScopes contains the scopes of which at least one must be granted by
+	// the token.
+	anyScopes []string
+
+	// policies contains application-specific policies the token must satisfy.
+	policies []Policy
 }
 
 // NewValidator can be used to create a stand-alone validator with the supplied
@@ -87,18 +130,43 @@ func NewValidator(opts ...ParserOption) *Validator {
 // Note: It will NOT perform any *signature verification* on the token that
 // contains the claims and expects that the [Claim] was already successfully
 // verified.
+//
+// Claims that are bound to a client certificate are rejected, since there is
+// no connection to verify the binding; use [Validator.ValidateWithConnection]
+// instead.
 func (v *Validator) Validate(claims Claims) error {
+	return v.validate(claims, nil, nil)
+}
+
+// ValidateWithConnection validates the given claims like [Validator.Validate],
+// for a token that was presented on the TLS connection with the given state.
+// If the token is bound to a client certificate, it must be the client
+// certificate of the connection, see [WithCertificateBinding].
+func (v *Validator) ValidateWithConnection(claims Claims, state *tls.ConnectionState) error {
+	return v.validate(claims, nil, state)
+}
+
+// validate validates the given claims of token. The token is nil, if the
+// claims are not validated by a [Parser]. Otherwise, its header is available
+// to policies and the placeholders of a matching issuer pattern are stored in
+// it. state is the TLS connection the token was presented on, if known.
+func (v *Validator) validate(claims Claims, token *Token, state *tls.ConnectionState) error {
 	var (
-		now  int64
-		errs []error = make([]error, 0, 6)
-		err  error
+		now    time.Time
+		errs   []error = make([]error, 0, 7)
+		err    error
+		header *Header
 	)
 
+	if token != nil {
+		header = &token.Header
+	}
+
 	// Check, if we have a time func
 	if v.timeFunc != nil {
-		now = v.timeFunc().Unix()
+		now = v.timeFunc()
 	} else {
-		now = time.Now().Unix()
+		now = time.Now()
 	}
 
 	// We always need to check the expiration time, but usage of the claim
@@ -121,6 +189,21 @@ func (v *Validator) Validate(claims Claims) error {
 		}
 	}
 
+	// If we have a maximum age, we also require the issued-at claim
+	if v.maxAge > 0 {
+		if err = v.verifyMaxAge(claims, now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// If we have a maximum lifetime, we also require the issued-at and
+	// expiration claims
+	if v.maxLifetime > 0 {
+		if err = v.verifyMaxLifetime(claims); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	// If we have an expected audience, we also require the audience claim
 	if v.expectedAud != "" {
 		if err = v.verifyAudience(claims, v.expectedAud, true); err != nil {
@@ -128,10 +211,21 @@ func (v *Validator) Validate(claims Claims) error {
 		}
 	}
 
-	// If we have an expected issuer, we also require the issuer claim
-	if v.expectedIss != "" {
-		if err = v.verifyIssuer(claims, v.expectedIss, true); err != nil {
+	// If we have expected audiences, we also require the audience claim
+	for _, group := range v.expectedAuds {
+		if err = v.verifyAudiences(claims, group.auds, group.match, true); err != nil {
 			errs = append(errs, err)
+			break
+		}
+	}
+
+	// If we have expected issuers, we also require the issuer claim
+	if v.expectedIss != "" || len(v.expectedIssuers) > 0 || len(v.issuerPatterns) > 0 {
+		var params map[string]string
+		if params, err = v.verifyIssuer(claims, true); err != nil {
+			errs = append(errs, err)
+		} else if token != nil {
+			token.IssuerParams = params
 		}
 	}
 
@@ -142,6 +236,30 @@ func (v *Validator) Validate(claims Claims) error {
 		}
 	}
 
+	// A certificate-bound token must be presented on a connection with this
+	// client certificate, even if binding is not required. Without a
+	// connection, the binding cannot be verified, so the token is rejected.
+	// If required, the token must be bound at all.
+	if v.requireCertBinding || isCertificateBound(claims) {
+		if err = v.verifyCertificateBinding(claims, state); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// If scopes are required, the token must grant them
+	if len(v.requiredScopes) > 0 || len(v.anyScopes) > 0 {
+		if err = v.verifyScopes(claims, v.requiredScopes, v.anyScopes); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Evaluate application-specific policies
+	for _, policy := range v.policies {
+		if err = policy.Evaluate(claims, header); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	// Finally, we want to give the claim itself some possibility to do some
 	// additional custom validation based on a custom Validate function.
 	cvt, ok := claims.(ClaimsValidator)
@@ -163,33 +281,64 @@ func (v *Validator) Validate(claims Claims) error {
 //
 // If exp is not set, it will succeed if the claim is not required,
 // otherwise ErrTokenRequiredClaimMissing will be returned.
-//
-// Additionally, if any error occurs while retrieving the claim, e.g., when its
-// the wrong type, an ErrTokenUnverifiable error will be returned.
-func (v *Validator) verifyExpiresAt(claims Claims, cur int64, required bool) error {
-	if required {
-		exp := claims.GetExpirationTime()
-
-		return errorIfFalse(cur < exp+int64(v.leeway/time.Second), internal.ErrTokenExpired)
+func (v *Validator) verifyExpiresAt(claims Claims, cmp time.Time, required bool) error {
+	exp := claims.GetExpirationTime()
+	if exp.IsZero() {
+		return errorIfRequired(required, "exp")
 	}
-	return nil
+
+	return errorIfFalse(cmp.Before(exp.Add(v.leeway)), internal.ErrTokenExpired)
 }
 
 // verifyIssuedAt compares the iat claim in claims against cmp. This function
-// will succeed if cmp >= iat. Additional leeway is taken into account.
+// will succeed if cmp >= iat. Additional leeway, or the tolerance configured
+// with [WithMaxFutureIssuedAt], is taken into account.
 //
 // If iat is not set, it will succeed if the claim is not required,
 // otherwise ErrTokenRequiredClaimMissing will be returned.
+func (v *Validator) verifyIssuedAt(claims Claims, cmp time.Time, required bool) error {
+	iat := claims.GetIssuedAt()
+	if iat.IsZero() {
+		return errorIfRequired(required, "iat")
+	}
+
+	tolerance := v.leeway
+	if v.hasMaxFutureIat {
+		tolerance = v.maxFutureIat
+	}
+
+	return errorIfFalse(!cmp.Before(iat.Add(-tolerance)), internal.ErrTokenUsedBeforeIssued)
+}
+
+// verifyMaxAge checks that the iat claim in claims is not older than the
+// maximum age at cmp. Additional leeway is taken into account.
 //
-// Additionally, if any error occurs while retrieving the claim, e.g., when its
-// the wrong type, an ErrTokenUnverifiable error will be returned.
-func (v *Validator) verifyIssuedAt(claims Claims, cur int64, required bool) error {
-	if required {
-		iat := claims.GetIssuedAt()
+// If iat is not set, ErrTokenRequiredClaimMissing will be returned.
+func (v *Validator) verifyMaxAge(claims Claims, cmp time.Time) error {
+	iat := claims.GetIssuedAt()
+	if iat.IsZero() {
+		return errorIfRequired(true, "iat")
+	}
 
-		return errorIfFalse(cur >= iat-int64(v.leeway/time.Second), internal.ErrTokenUsedBeforeIssued)
+	return errorIfFalse(!cmp.After(iat.Add(v.maxAge+v.leeway)), internal.ErrTokenTooOld)
+}
+
+// verifyMaxLifetime checks that the duration between the iat and exp claims
+// in claims does not exceed the maximum lifetime.
+//
+// If iat or exp is not set, ErrTokenRequiredClaimMissing will be returned.
+func (v *Validator) verifyMaxLifetime(claims Claims) error {
+	iat := claims.GetIssuedAt()
+	if iat.IsZero() {
+		return errorIfRequired(true, "iat")
 	}
-	return nil
+
+	exp := claims.GetExpirationTime()
+	if exp.IsZero() {
+		return errorIfRequired(true, "exp")
+	}
+
+	return errorIfFalse(exp.Sub(iat) <= v.maxLifetime, internal.ErrTokenLifetimeTooLong)
 }
 
 // verifyNotBefore compares the nbf claim in claims against cmp. This function
@@ -197,16 +346,13 @@ func (v *Validator) verifyIssuedAt(claims Claims, cur int64, required bool) erro
 //
 // If nbf is not set, it will succeed if the claim is not required,
 // otherwise ErrTokenRequiredClaimMissing will be returned.
-//
-// Additionally, if any error occurs while retrieving the claim, e.g., when its
-// the wrong type, an ErrTokenUnverifiable error will be returned.
-func (v *Validator) verifyNotBefore(claims Claims, cur int64, required bool) error {
-	if required {
-		nbf := claims.GetNotBefore()
-
-		return errorIfFalse(cur >= nbf-int64(v.leeway/time.Second), internal.ErrTokenNotValidYet)
+func (v *Validator) verifyNotBefore(claims Claims, cmp time.Time, required bool) error {
+	nbf := claims.GetNotBefore()
+	if nbf.IsZero() {
+		return errorIfRequired(required, "nbf")
 	}
-	return nil
+
+	return errorIfFalse(!cmp.Before(nbf.Add(-v.leeway)), internal.ErrTokenNotValidYet)
 }
 
 // verifyAudience compares the aud claim against cmp.
@@ -238,20 +384,52 @@ func (v *Validator) verifyAudience(claims Claims, cmp string, required bool) err
 	return errorIfFalse(result, internal.ErrTokenInvalidAudience)
 }
 
-// verifyIssuer compares the iss claim in claims against cmp.
+// verifyAudiences compares the aud claim against multiple audiences, of which
+// any or all are required, depending on match.
+func (v *Validator) verifyAudiences(claims Claims, cmp []string, match AudienceMatch, required bool) error {
+	var err error
+
+	for _, aud := range cmp {
+		err = v.verifyAudience(claims, aud, required)
+
+		// Stop at the first match for any, and at the first mismatch for all
+		if (err == nil) == (match == AudienceMatchAny) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// verifyIssuer compares the iss claim in claims against the expected issuer,
+// the further expected issuers and the issuer patterns. If the claim matches a
+// pattern, the values of its placeholders are returned.
 //
 // If iss is not set, it will succeed if the claim is not required,
 // otherwise ErrTokenRequiredClaimMissing will be returned.
-//
-// Additionally, if any error occurs while retrieving the claim, e.g., when its
-// the wrong type, an ErrTokenUnverifiable error will be returned.
-func (v *Validator) verifyIssuer(claims Claims, cmp string, required bool) error {
+func (v *Validator) verifyIssuer(claims Claims, required bool) (map[string]string, error) {
 	iss := claims.GetIssuer()
 	if iss == "" {
-		return errorIfRequired(required, "iss")
+		return nil, errorIfRequired(required, "iss")
+	}
+
+	if iss == v.expectedIss {
+		return nil, nil
+	}
+
+	for _, cmp := range v.expectedIssuers {
+		if iss == cmp {
+			return nil, nil
+		}
+	}
+
+	for _, pattern := range v.issuerPatterns {
+		if params, ok := pattern.Match(iss); ok {
+			return params, nil
+		}
 	}
 
-	return errorIfFalse(iss == cmp, internal.ErrTokenInvalidIssuer)
+	return nil, internal.ErrTokenInvalidIssuer
 }
 
 // verifySubject compares the sub claim against cmp.
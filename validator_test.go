@@ -0,0 +1,62 @@
+package xwt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+)
+
+func TestTokenAgeValidation(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	at := func(d time.Duration) int64 {
+		return now.Add(d).Unix()
+	}
+
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		opts    []xwt.ParserOption
+		wantErr error
+	}{
+		{"max age", jwt.MapClaims{"iat": at(-time.Minute)}, []xwt.ParserOption{xwt.WithMaxAge(time.Hour)}, nil},
+		{"max age boundary", jwt.MapClaims{"iat": at(-time.Hour)}, []xwt.ParserOption{xwt.WithMaxAge(time.Hour)}, nil},
+		{"max age exceeded", jwt.MapClaims{"iat": at(-time.Hour - time.Second)}, []xwt.ParserOption{xwt.WithMaxAge(time.Hour)}, internal.ErrTokenTooOld},
+		{"max age with leeway", jwt.MapClaims{"iat": at(-time.Hour - time.Second)}, []xwt.ParserOption{xwt.WithMaxAge(time.Hour), xwt.WithLeeway(time.Minute)}, nil},
+		{"max age exceeded with leeway", jwt.MapClaims{"iat": at(-time.Hour - 2*time.Minute)}, []xwt.ParserOption{xwt.WithMaxAge(time.Hour), xwt.WithLeeway(time.Minute)}, internal.ErrTokenTooOld},
+		// The age is checked regardless of the expiration time
+		{"max age not expired", jwt.MapClaims{"iat": at(-2 * time.Hour), "exp": at(time.Hour)}, []xwt.ParserOption{xwt.WithMaxAge(time.Hour)}, internal.ErrTokenTooOld},
+		{"max age without iat", jwt.MapClaims{"exp": at(time.Hour)}, []xwt.ParserOption{xwt.WithMaxAge(time.Hour)}, internal.ErrTokenRequiredClaimMissing},
+
+		{"max lifetime", jwt.MapClaims{"iat": at(0), "exp": at(time.Hour)}, []xwt.ParserOption{xwt.WithMaxLifetime(time.Hour)}, nil},
+		{"max lifetime exceeded", jwt.MapClaims{"iat": at(0), "exp": at(time.Hour + time.Second)}, []xwt.ParserOption{xwt.WithMaxLifetime(time.Hour)}, internal.ErrTokenLifetimeTooLong},
+		{"max lifetime of years", jwt.MapClaims{"iat": at(-time.Minute), "exp": at(10 * 365 * 24 * time.Hour)}, []xwt.ParserOption{xwt.WithMaxLifetime(24 * time.Hour)}, internal.ErrTokenLifetimeTooLong},
+		{"max lifetime without iat", jwt.MapClaims{"exp": at(time.Hour)}, []xwt.ParserOption{xwt.WithMaxLifetime(time.Hour)}, internal.ErrTokenRequiredClaimMissing},
+		{"max lifetime without exp", jwt.MapClaims{"iat": at(0)}, []xwt.ParserOption{xwt.WithMaxLifetime(time.Hour)}, internal.ErrTokenRequiredClaimMissing},
+
+		{"future iat", jwt.MapClaims{"iat": at(time.Minute)}, []xwt.ParserOption{xwt.WithIssuedAt()}, internal.ErrTokenUsedBeforeIssued},
+		{"future iat with leeway", jwt.MapClaims{"iat": at(time.Minute)}, []xwt.ParserOption{xwt.WithIssuedAt(), xwt.WithLeeway(time.Minute)}, nil},
+		{"future iat with tolerance", jwt.MapClaims{"iat": at(time.Minute)}, []xwt.ParserOption{xwt.WithMaxFutureIssuedAt(time.Minute)}, nil},
+		{"future iat exceeding tolerance", jwt.MapClaims{"iat": at(2 * time.Minute)}, []xwt.ParserOption{xwt.WithMaxFutureIssuedAt(time.Minute)}, internal.ErrTokenUsedBeforeIssued},
+		// The tolerance is used instead of the leeway
+		{"future iat with tolerance and leeway", jwt.MapClaims{"iat": at(2 * time.Minute)}, []xwt.ParserOption{xwt.WithMaxFutureIssuedAt(time.Minute), xwt.WithLeeway(time.Hour)}, internal.ErrTokenUsedBeforeIssued},
+		{"future iat without tolerance", jwt.MapClaims{"iat": at(time.Second)}, []xwt.ParserOption{xwt.WithMaxFutureIssuedAt(0), xwt.WithLeeway(time.Hour)}, internal.ErrTokenUsedBeforeIssued},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]xwt.ParserOption{xwt.WithTimeFunc(func() time.Time { return now })}, tt.opts...)
+
+			err := xwt.NewValidator(opts...).Validate(&tt.claims)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
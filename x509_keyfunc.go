@@ -0,0 +1,122 @@
+package xwt
+
+import (
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// X509ChainVerifier supplies the key for verification from the certificate
+// chain contained in the `x5c` header parameter of a token, as described in
+// https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.6. The chain is
+// only trusted, if it can be verified against the configured roots.
+//
+// Its Keyfunc method can be directly used as a [Keyfunc]. As the chain is
+// verified at the time of the iat claim, the age of tokens should be limited
+// by the parser:
+//
+//	verifier := &xwt.X509ChainVerifier{
+//	    Options: x509.VerifyOptions{Roots: roots},
+//	}
+//	parser := xwt.NewParser(
+//	    xwt.WithMaxAge(time.Hour),
+//	    xwt.WithMaxFutureIssuedAt(time.Minute),
+//	)
+//	token, err := parser.ParseWithClaims(tokenString, claims, verifier.Keyfunc)
+type X509ChainVerifier struct {
+	// Options are used to verify the certificate chain. Roots should be set
+	// to the pool of trusted CA certificates, otherwise the system pool is
+	// used. The certificates following the leaf in `x5c` are added to the
+	// Intermediates.
+	//
+	// If CurrentTime is not set, the chain is verified at the time of the iat
+	// claim of the token, or the current time, if the token has no iat claim.
+	// If KeyUsages is not set, any extended key usage is accepted.
+	Options x509.VerifyOptions
+
+	// RequireThumbprint specifies whether the `x5t#S256` header parameter is
+	// required. If present, it is always compared against the leaf
+	// certificate, regardless of this setting.
+	RequireThumbprint bool
+}
+
+// Keyfunc implements the [Keyfunc] type. It verifies the certificate chain in
+// the header of the token and returns the public key of the leaf certificate.
+//
+// Note: Since the Keyfunc is called before the signature is verified, the iat
+// claim used to verify the chain is not yet integrity protected. It will
+// however be protected by the key of the leaf certificate, once the parser
+// verifies the signature.
+//
+// Note: Anyone holding the key of an expired leaf certificate can still sign
+// tokens with an iat within the validity of the certificate, i.e. backdate
+// them. Without [WithMaxAge], such tokens are accepted for as long as they
+// do not expire, so the maximum age should always be configured, unless
+// Options.CurrentTime is set. [WithMaxFutureIssuedAt] further limits iat to
+// the near future.
+func (v *X509ChainVerifier) Keyfunc(t *Token) (interface{}, error) {
+	if len(t.Header.X509CertChain) == 0 {
+		return nil, errors.New("x5c header parameter is missing")
+	}
+
+	// The certificates are base64 encoded (not base64url), see RFC 7515
+	// section 4.1.6
+	certs := make([]*x509.Certificate, 0, len(t.Header.X509CertChain))
+	for i, enc := range t.Header.X509CertChain {
+		der, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return nil, fmt.Errorf("could not base64 decode certificate %d of x5c: %w", i, err)
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse certificate %d of x5c: %w", i, err)
+		}
+
+		certs = append(certs, cert)
+	}
+
+	leaf := certs[0]
+
+	// Compare the thumbprint of the leaf, if present or required
+	if t.Header.X509CertThumbprintS256 != "" || v.RequireThumbprint {
+		if t.Header.X509CertThumbprintS256 == "" {
+			return nil, errors.New("x5t#S256 header parameter is missing")
+		}
+
+		thumbprint := CertificateThumbprint(leaf)
+		if subtle.ConstantTimeCompare([]byte(thumbprint), []byte(t.Header.X509CertThumbprintS256)) != 1 {
+			return nil, errors.New("x5t#S256 header parameter does not match the certificate")
+		}
+	}
+
+	// The leaf certificate must be allowed to create digital signatures
+	if leaf.KeyUsage != 0 && leaf.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		return nil, errors.New("certificate is not valid for digital signatures")
+	}
+
+	opts := v.Options
+	opts.Intermediates = x509.NewCertPool()
+	if v.Options.Intermediates != nil {
+		opts.Intermediates = v.Options.Intermediates.Clone()
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	if opts.CurrentTime.IsZero() && t.Claims != nil {
+		opts.CurrentTime = t.Claims.GetIssuedAt()
+	}
+
+	if len(opts.KeyUsages) == 0 {
+		opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+
+	if _, err := leaf.Verify(opts); err != nil {
+		return nil, fmt.Errorf("could not verify certificate chain: %w", err)
+	}
+
+	return leaf.PublicKey, nil
+}
@@ -0,0 +1,182 @@
+package xwt_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/lkyzhu/xwt"
+	"github.com/lkyzhu/xwt/internal"
+	"github.com/lkyzhu/xwt/jwt"
+	"github.com/lkyzhu/xwt/method"
+)
+
+// testCA is a certificate authority issuing certificates for tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue creates a certificate from template signed by parent, or a
+// self-signed one if parent is nil.
+func issue(t *testing.T, template *x509.Certificate, parent *testCA) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+	}
+	if template.NotAfter.IsZero() {
+		template.NotAfter = time.Now().Add(time.Hour)
+	}
+
+	issuer, signer := template, crypto.Signer(key)
+	if parent != nil {
+		issuer, signer = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{cert: cert, key: key}
+}
+
+func caTemplate(name string) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		NotBefore:             time.Now().Add(-24 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+	}
+}
+
+func TestX509ChainVerifier(t *testing.T) {
+	root := issue(t, caTemplate("root"), nil)
+	intermediate := issue(t, caTemplate("intermediate"), root)
+	leaf := issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "signer"}, KeyUsage: x509.KeyUsageDigitalSignature}, intermediate)
+
+	untrusted := issue(t, caTemplate("untrusted"), nil)
+	untrustedLeaf := issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "signer"}, KeyUsage: x509.KeyUsageDigitalSignature}, untrusted)
+
+	encipherment := issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "signer"}, KeyUsage: x509.KeyUsageKeyAgreement}, intermediate)
+
+	issuedAt := time.Now().Add(-2 * time.Hour)
+	expired := issue(t, &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "signer"},
+		KeyUsage:  x509.KeyUsageDigitalSignature,
+		NotBefore: issuedAt.Add(-time.Hour),
+		NotAfter:  issuedAt.Add(time.Hour),
+	}, intermediate)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	sign := func(signer *testCA, claims jwt.MapClaims, opts ...xwt.TokenOption) string {
+		t.Helper()
+		s, err := xwt.NewWithClaims(method.SigningMethodES256, &claims, opts...).SignedString(signer.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	other := issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "other"}, KeyUsage: x509.KeyUsageDigitalSignature}, intermediate)
+
+	tests := []struct {
+		name     string
+		token    string
+		verifier *xwt.X509ChainVerifier
+		opts     []xwt.ParserOption
+		wantErr  bool
+	}{
+		{"chain", sign(leaf, jwt.MapClaims{}, xwt.WithX509CertChain(leaf.cert, intermediate.cert)), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots}}, nil, false},
+		{"missing intermediate", sign(leaf, jwt.MapClaims{}, xwt.WithX509CertChain(leaf.cert)), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots}}, nil, true},
+		{"configured intermediate", sign(leaf, jwt.MapClaims{}, xwt.WithX509CertChain(leaf.cert)), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots, Intermediates: pool(intermediate.cert)}}, nil, false},
+		{"untrusted root", sign(untrustedLeaf, jwt.MapClaims{}, xwt.WithX509CertChain(untrustedLeaf.cert, untrusted.cert)), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots}}, nil, true},
+		{"no x5c", sign(leaf, jwt.MapClaims{}), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots}}, nil, true},
+		{"thumbprint mismatch", sign(leaf, jwt.MapClaims{}, xwt.WithX509CertChain(leaf.cert, intermediate.cert), xwt.WithHeader("x5t#S256", xwt.CertificateThumbprint(other.cert))), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots}}, nil, true},
+		{"thumbprint required", sign(leaf, jwt.MapClaims{}, xwt.WithHeader("x5c", []string{encode(leaf.cert), encode(intermediate.cert)})), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots}, RequireThumbprint: true}, nil, true},
+		{"thumbprint optional", sign(leaf, jwt.MapClaims{}, xwt.WithHeader("x5c", []string{encode(leaf.cert), encode(intermediate.cert)})), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots}}, nil, false},
+		{"other leaf key", sign(other, jwt.MapClaims{}, xwt.WithX509CertChain(leaf.cert, intermediate.cert)), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots}}, nil, true},
+		{"no digital signature", sign(encipherment, jwt.MapClaims{}, xwt.WithX509CertChain(encipherment.cert, intermediate.cert)), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots}}, nil, true},
+		{"expired", sign(expired, jwt.MapClaims{}, xwt.WithX509CertChain(expired.cert, intermediate.cert)), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots}}, nil, true},
+		// The chain is verified at the time of iat, so the maximum age of the
+		// token limits how long the key of an expired certificate can be used
+		{"expired valid at iat", sign(expired, jwt.MapClaims{"iat": issuedAt.Unix()}, xwt.WithX509CertChain(expired.cert, intermediate.cert)), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots}}, nil, false},
+		{"expired valid at iat too old", sign(expired, jwt.MapClaims{"iat": issuedAt.Unix()}, xwt.WithX509CertChain(expired.cert, intermediate.cert)), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots}}, []xwt.ParserOption{xwt.WithMaxAge(time.Hour)}, true},
+		{"expired at current time", sign(expired, jwt.MapClaims{"iat": issuedAt.Unix()}, xwt.WithX509CertChain(expired.cert, intermediate.cert)), &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: roots, CurrentTime: time.Now()}}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := xwt.NewParser(tt.opts...).ParseWithClaims(tt.token, &jwt.MapClaims{}, tt.verifier.Keyfunc)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ParseWithClaims() succeeded")
+				}
+				return
+			}
+			if err != nil || !token.Valid {
+				t.Fatalf("ParseWithClaims() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestX509ChainVerifierKeyUsage(t *testing.T) {
+	root := issue(t, caTemplate("root"), nil)
+	leaf := issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "signer"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, root)
+
+	s, err := xwt.NewWithClaims(method.SigningMethodES256, &jwt.MapClaims{}, xwt.WithX509CertChain(leaf.cert)).SignedString(leaf.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// By default, any extended key usage is accepted
+	v := &xwt.X509ChainVerifier{Options: x509.VerifyOptions{Roots: pool(root.cert)}}
+	if _, err = xwt.NewParser().ParseWithClaims(s, &jwt.MapClaims{}, v.Keyfunc); err != nil {
+		t.Fatalf("ParseWithClaims() error = %v", err)
+	}
+
+	v.Options.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}
+	if _, err = xwt.NewParser().ParseWithClaims(s, &jwt.MapClaims{}, v.Keyfunc); !errors.Is(err, internal.ErrTokenUnverifiable) {
+		t.Fatalf("ParseWithClaims() error = %v, want %v", err, internal.ErrTokenUnverifiable)
+	}
+}
+
+func pool(certs ...*x509.Certificate) *x509.CertPool {
+	p := x509.NewCertPool()
+	for _, cert := range certs {
+		p.AddCert(cert)
+	}
+	return p
+}
+
+func encode(cert *x509.Certificate) string {
+	return base64.StdEncoding.EncodeToString(cert.Raw)
+}